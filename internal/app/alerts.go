@@ -0,0 +1,364 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// Alert kinds. Each fires at most once per budget period and category.
+const (
+	alertWarn = "warn"
+	alertOver = "over"
+	alertPace = "pace"
+)
+
+type budgetAlert struct {
+	Key        db.AlertKey
+	LimitBani  int64
+	SpentBani  int64
+	UsedPct    int
+	ElapsedPct int
+	Message    string
+}
+
+type alertThresholds struct {
+	WarnPct int // warn once spending reaches this share of the limit
+	PacePct int // pace alert when spending runs this many points ahead of time
+}
+
+// evaluateAlerts turns resolved budgets into alerts as of asOf. Pace alerts
+// only fire below the warn threshold; past that the warn/over alerts say more.
+func evaluateAlerts(d display, month string, budgets []budgetLine, annual []annualLine, asOf time.Time, th alertThresholds) []budgetAlert {
+	var out []budgetAlert
+
+	start, _ := parseMonth(month)
+	end := start.AddDate(0, 1, 0)
+	elapsed := 100
+	if asOf.Before(end) {
+		elapsed = int(float64(asOf.Day()) * 100 / float64(end.AddDate(0, 0, -1).Day()))
+	}
+
+	for _, b := range budgets {
+		limit := b.EffectiveBani()
+		if limit <= 0 {
+			continue
+		}
+		used := int(b.SpentBani * 100 / limit)
+		a := budgetAlert{
+			Key:        db.AlertKey{Period: month, Category: b.Category},
+			LimitBani:  limit,
+			SpentBani:  b.SpentBani,
+			UsedPct:    used,
+			ElapsedPct: elapsed,
+		}
+		switch {
+		case b.SpentBani > limit:
+			a.Key.Kind = alertOver
+			a.Message = fmt.Sprintf("%s is over budget for %s: spent %s of %s (%d%%)",
+				b.Category, month, d.money(b.SpentBani), d.money(limit), used)
+		case used >= th.WarnPct:
+			a.Key.Kind = alertWarn
+			a.Message = fmt.Sprintf("%s has used %d%% of its %s budget for %s",
+				b.Category, used, d.money(limit), month)
+		case elapsed < 100 && used >= elapsed+th.PacePct:
+			a.Key.Kind = alertPace
+			a.Message = fmt.Sprintf("%s: spent %d%% of %s with %d%% of %s left",
+				b.Category, used, d.money(limit), 100-elapsed, month)
+		default:
+			continue
+		}
+		out = append(out, a)
+	}
+
+	for _, b := range annual {
+		if b.LimitBani <= 0 {
+			continue
+		}
+		year := b.AsOf.Format("2006")
+		used := int(b.SpentBani * 100 / b.LimitBani)
+		yearElapsed := 0
+		if b.ExpectedBani > 0 {
+			yearElapsed = int(b.ExpectedBani * 100 / b.LimitBani)
+		}
+		a := budgetAlert{
+			Key:        db.AlertKey{Period: year, Category: b.Category},
+			LimitBani:  b.LimitBani,
+			SpentBani:  b.SpentBani,
+			UsedPct:    used,
+			ElapsedPct: yearElapsed,
+		}
+		switch {
+		case b.SpentBani > b.LimitBani:
+			a.Key.Kind = alertOver
+			a.Message = fmt.Sprintf("%s is over its %s yearly budget: spent %s of %s (%d%%)",
+				b.Category, year, d.money(b.SpentBani), d.money(b.LimitBani), used)
+		case used >= th.WarnPct:
+			a.Key.Kind = alertWarn
+			a.Message = fmt.Sprintf("%s has used %d%% of its %s yearly budget of %s",
+				b.Category, used, year, d.money(b.LimitBani))
+		case used >= yearElapsed+th.PacePct:
+			a.Key.Kind = alertPace
+			a.Message = fmt.Sprintf("%s: spent %d%% of the %s yearly budget with %d%% of the year left",
+				b.Category, used, year, 100-yearElapsed)
+		default:
+			continue
+		}
+		out = append(out, a)
+	}
+
+	return out
+}
+
+// alertSink delivers alerts somewhere. Alerts are only recorded as sent once
+// every configured sink has succeeded.
+type alertSink interface {
+	Name() string
+	Send(alerts []budgetAlert) error
+}
+
+type stdoutSink struct{}
+
+func (stdoutSink) Name() string { return "stdout" }
+
+func (stdoutSink) Send(alerts []budgetAlert) error {
+	for _, a := range alerts {
+		fmt.Printf("[%s] %s\n", strings.ToUpper(a.Key.Kind), a.Message)
+	}
+	return nil
+}
+
+// commandSink runs a shell command once per alert with the alert in
+// PFM_ALERT_* environment variables and the message on stdin.
+type commandSink struct {
+	Command string
+}
+
+func (s commandSink) Name() string { return "exec" }
+
+func (s commandSink) Send(alerts []budgetAlert) error {
+	for _, a := range alerts {
+		cmd := exec.Command("sh", "-c", s.Command)
+		cmd.Env = append(os.Environ(),
+			"PFM_ALERT_KIND="+a.Key.Kind,
+			"PFM_ALERT_PERIOD="+a.Key.Period,
+			"PFM_ALERT_CATEGORY="+a.Key.Category,
+			"PFM_ALERT_MESSAGE="+a.Message,
+			fmt.Sprintf("PFM_ALERT_LIMIT_BANI=%d", a.LimitBani),
+			fmt.Sprintf("PFM_ALERT_SPENT_BANI=%d", a.SpentBani),
+			fmt.Sprintf("PFM_ALERT_USED_PCT=%d", a.UsedPct),
+		)
+		cmd.Stdin = strings.NewReader(a.Message + "\n")
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("alert command: %w", err)
+		}
+	}
+	return nil
+}
+
+// mailSink pipes one message with all alerts into a sendmail-compatible binary.
+type mailSink struct {
+	To       string
+	Sendmail string
+}
+
+func (s mailSink) Name() string { return "mail" }
+
+func (s mailSink) Send(alerts []budgetAlert) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "To: %s\n", s.To)
+	fmt.Fprintf(&msg, "Subject: pfm: %d budget alert(s)\n", len(alerts))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\n\n")
+	for _, a := range alerts {
+		fmt.Fprintf(&msg, "[%s] %s\n", strings.ToUpper(a.Key.Kind), a.Message)
+	}
+
+	cmd := exec.Command(s.Sendmail, "-i", "--", s.To)
+	cmd.Stdin = &msg
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sendmail: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// webhookSink POSTs all alerts as one JSON document.
+type webhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s webhookSink) Name() string { return "webhook" }
+
+type webhookAlert struct {
+	Period     string `json:"period"`
+	Category   string `json:"category"`
+	Kind       string `json:"kind"`
+	Message    string `json:"message"`
+	LimitBani  int64  `json:"limit_bani"`
+	SpentBani  int64  `json:"spent_bani"`
+	UsedPct    int    `json:"used_pct"`
+	ElapsedPct int    `json:"elapsed_pct"`
+}
+
+func (s webhookSink) Send(alerts []budgetAlert) error {
+	payload := struct {
+		Alerts []webhookAlert `json:"alerts"`
+	}{}
+	for _, a := range alerts {
+		payload.Alerts = append(payload.Alerts, webhookAlert{
+			Period:     a.Key.Period,
+			Category:   a.Key.Category,
+			Kind:       a.Key.Kind,
+			Message:    a.Message,
+			LimitBani:  a.LimitBani,
+			SpentBani:  a.SpentBani,
+			UsedPct:    a.UsedPct,
+			ElapsedPct: a.ElapsedPct,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s returned %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+func defaultSendmail() string {
+	if p, err := exec.LookPath("sendmail"); err == nil {
+		return p
+	}
+	return "/usr/sbin/sendmail"
+}
+
+func (a *App) cmdBudgetCheck(args []string) error {
+	fs := flag.NewFlagSet("budget check", flag.ContinueOnError)
+
+	asOfStr := fs.String("as-of", "", "Evaluate as of this date (YYYY-MM-DD), default today")
+	warnPct := fs.Int("warn", a.Settings.WarnPct, "Warn threshold percent")
+	pacePct := fs.Int("pace", 20, "Pace alert when spending is this many points ahead of the calendar")
+	quiet := fs.Bool("quiet", false, "Do not print alerts to stdout")
+	execCmd := fs.String("exec", "", "Shell command to run per alert (PFM_ALERT_* env vars)")
+	mailTo := fs.String("mail", "", "Mail alerts to this address via sendmail")
+	sendmail := fs.String("sendmail", defaultSendmail(), "Path to a sendmail-compatible binary")
+	webhook := fs.String("webhook", "", "POST alerts as JSON to this URL")
+	dryRun := fs.Bool("dry-run", false, "Print alerts without sending or remembering them")
+	reset := fs.Bool("reset", false, "Forget alerts already sent for the month before checking")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *warnPct <= 0 || *warnPct > 1000 {
+		return errors.New("--warn must be a reasonable percent (1..1000)")
+	}
+	if *pacePct <= 0 || *pacePct > 100 {
+		return errors.New("--pace must be between 1 and 100")
+	}
+
+	asOf := time.Now()
+	if *asOfStr != "" {
+		t, err := time.Parse("2006-01-02", *asOfStr)
+		if err != nil {
+			return fmt.Errorf("invalid --as-of %q (expected YYYY-MM-DD)", *asOfStr)
+		}
+		asOf = t
+	}
+	month := asOf.Format("2006-01")
+	year := asOf.Format("2006")
+
+	var sinks []alertSink
+	if !*quiet || *dryRun {
+		sinks = append(sinks, stdoutSink{})
+	}
+	if !*dryRun {
+		if *execCmd != "" {
+			sinks = append(sinks, commandSink{Command: *execCmd})
+		}
+		if *mailTo != "" {
+			sinks = append(sinks, mailSink{To: *mailTo, Sendmail: *sendmail})
+		}
+		if *webhook != "" {
+			sinks = append(sinks, webhookSink{URL: *webhook, Client: &http.Client{Timeout: 10 * time.Second}})
+		}
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if *reset && !*dryRun {
+		if _, err := db.ResetAlerts(conn, month); err != nil {
+			return err
+		}
+	}
+
+	budgets, err := resolveBudgets(conn, month)
+	if err != nil {
+		return err
+	}
+	annual, err := resolveAnnualBudgets(conn, month, asOf)
+	if err != nil {
+		return err
+	}
+
+	sent, err := db.ListSentAlerts(conn, month, year)
+	if err != nil {
+		return err
+	}
+
+	var fresh []budgetAlert
+	for _, al := range evaluateAlerts(a.disp, month, budgets, annual, asOf, alertThresholds{WarnPct: *warnPct, PacePct: *pacePct}) {
+		if !*reset && sent[al.Key] {
+			continue
+		}
+		fresh = append(fresh, al)
+	}
+
+	if len(fresh) == 0 {
+		if !*quiet {
+			fmt.Printf("No new budget alerts for %s.\n", month)
+		}
+		return nil
+	}
+
+	for _, s := range sinks {
+		if err := s.Send(fresh); err != nil {
+			return fmt.Errorf("%s sink: %w (alerts not recorded; they will be retried)", s.Name(), err)
+		}
+	}
+
+	if *dryRun {
+		return nil
+	}
+	for _, al := range fresh {
+		if err := db.RecordAlert(conn, al.Key, al.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+func TestBudgetCheckWebhook(t *testing.T) {
+	a := New()
+	a.DBPath = filepath.Join(t.TempDir(), "pfm.db")
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpsertBudget(conn, "2026-10", "groceries", 100000); err != nil {
+		t.Fatal(err)
+	}
+	spend := func(date string, bani int64) {
+		t.Helper()
+		posted, _ := time.Parse("2006-01-02", date)
+		if _, _, err := db.InsertTransaction(conn, db.AddTxParams{PostedAt: posted, Payee: "Lidl", AmountBani: -bani, Category: "groceries"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var posts []webhookAlert
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		var payload struct {
+			Alerts []webhookAlert `json:"alerts"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		posts = append(posts, payload.Alerts...)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	check := func() error {
+		return a.cmdBudgetCheck([]string{"--as-of", "2026-10-20", "--quiet", "--webhook", srv.URL})
+	}
+	sent := func() map[db.AlertKey]bool {
+		t.Helper()
+		m, err := db.ListSentAlerts(conn, "2026-10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	warn := db.AlertKey{Period: "2026-10", Category: "groceries", Kind: alertWarn}
+	over := db.AlertKey{Period: "2026-10", Category: "groceries", Kind: alertOver}
+
+	spend("2026-10-05", 85000)
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+	want := []webhookAlert{{
+		Period: "2026-10", Category: "groceries", Kind: alertWarn,
+		Message:   "groceries has used 85% of its 1000.00 RON budget for 2026-10",
+		LimitBani: 100000, SpentBani: 85000, UsedPct: 85, ElapsedPct: 64,
+	}}
+	if !reflect.DeepEqual(posts, want) {
+		t.Errorf("payload:\n got %+v\nwant %+v", posts, want)
+	}
+	if got := sent(); !reflect.DeepEqual(got, map[db.AlertKey]bool{warn: true}) {
+		t.Errorf("recorded %v, want the warn alert", got)
+	}
+
+	// The warn alert has been sent; checking again posts nothing.
+	posts = nil
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("second check posted %+v", posts)
+	}
+
+	// A failing sink leaves the over alert unrecorded, so it is retried.
+	spend("2026-10-18", 20000)
+	status = http.StatusInternalServerError
+	if err := check(); err == nil {
+		t.Fatal("check with a failing webhook: want an error")
+	}
+	if sent()[over] {
+		t.Error("over alert recorded although the webhook failed")
+	}
+
+	status = http.StatusOK
+	posts = nil
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 || posts[0].Kind != alertOver || posts[0].SpentBani != 105000 {
+		t.Errorf("retry posted %+v, want one over alert", posts)
+	}
+	if got := sent(); !reflect.DeepEqual(got, map[db.AlertKey]bool{warn: true, over: true}) {
+		t.Errorf("recorded %v, want warn and over", got)
+	}
+
+	posts = nil
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("check after over posted %+v", posts)
+	}
+}
+
+func TestEvaluateAlertsPace(t *testing.T) {
+	th := alertThresholds{WarnPct: 80, PacePct: 20}
+	// 2026-10-10 is 32% of October (10 of 31 days).
+	asOf := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	kinds := func(budgets []budgetLine, annual []annualLine, asOf time.Time) []string {
+		var out []string
+		for _, a := range evaluateAlerts(builtinDisplay, "2026-10", budgets, annual, asOf, th) {
+			out = append(out, a.Key.Category+":"+a.Key.Kind)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		spent int64
+		asOf  time.Time
+		want  []string
+	}{
+		{"on pace", 40000, asOf, nil},
+		{"just under", 51000, asOf, nil},
+		{"ahead of pace", 52000, asOf, []string{"food:pace"}},
+		{"warn wins", 80000, asOf, []string{"food:warn"}},
+		{"month over", 70000, time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC), nil},
+	}
+	for _, tt := range tests {
+		b := []budgetLine{{Category: "food", LimitBani: 100000, SpentBani: tt.spent}}
+		if got := kinds(b, nil, tt.asOf); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: alerts %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	got := evaluateAlerts(builtinDisplay, "2026-10", []budgetLine{{Category: "food", LimitBani: 100000, SpentBani: 52000}}, nil, asOf, th)
+	if len(got) != 1 || got[0].UsedPct != 52 || got[0].ElapsedPct != 32 {
+		t.Errorf("pace alert = %+v, want used 52%% with 32%% elapsed", got)
+	}
+
+	// Yearly budgets compare against the pro-rated share of the limit.
+	annual := []annualLine{
+		{Category: "travel", LimitBani: 1000000, SpentBani: 900000, ExpectedBani: 770000, AsOf: asOf},
+		{Category: "gifts", LimitBani: 1000000, SpentBani: 500000, ExpectedBani: 250000, AsOf: asOf},
+		{Category: "books", LimitBani: 1000000, SpentBani: 400000, ExpectedBani: 250000, AsOf: asOf},
+	}
+	if got := kinds(nil, annual, asOf); !reflect.DeepEqual(got, []string{"travel:warn", "gifts:pace"}) {
+		t.Errorf("yearly alerts %v, want travel:warn and gifts:pace", got)
+	}
+}
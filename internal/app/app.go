@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,29 +18,30 @@ import (
 const Version = "0.1.0"
 
 type App struct {
-	DBPath     string
+	DBPath string
+	// SchemaPath overrides the schema built into the binary when set.
 	SchemaPath string
+	Settings   Settings
+	Config     *Config
+
+	// disp is how amounts and dates are shown, set from Settings.
+	disp display
 }
 
 func New() *App {
 	return &App{
-		DBPath:     "pfm.db",
-		SchemaPath: filepath.Join("internal", "db", "schema.sql"),
-	}
-}
-
-func trunc(s string, n int) string {
-	if n <= 0 {
-		return ""
+		DBPath:   builtinSettings.DB,
+		Settings: builtinSettings,
+		disp:     builtinDisplay,
+		Config:   &Config{Values: map[string]tomlValue{}, From: map[string]string{}},
 	}
-	if len(s) <= n {
-		return s
-	}
-	return s[:n]
 }
 
-
 func (a *App) Run(args []string) error {
+	args, err := a.configure(args)
+	if err != nil {
+		return err
+	}
 	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
 		a.printHelp()
 		return nil
@@ -64,12 +66,28 @@ func (a *App) Run(args []string) error {
 		return a.cmdBudget(args[1:])
 	case "rule":
 		return a.cmdRule(args[1:])
+	case "payee":
+		return a.cmdPayee(args[1:])
+	case "tag":
+		return a.cmdTag(args[1:])
+	case "goal":
+		return a.cmdGoal(args[1:])
+	case "loan":
+		return a.cmdLoan(args[1:])
+	case "asset":
+		return a.cmdAsset(args[1:])
+	case "invest":
+		return a.cmdInvest(args[1:])
+	case "tax":
+		return a.cmdTax(args[1:])
 	case "categorize":
 		return a.cmdCategorize(args[1:])
 	case "search":
 		return a.cmdSearch(args[1:])
 	case "tui":
     	return a.cmdTUI(args[1:])
+	case "config":
+		return a.cmdConfig(args[1:])
 
 	default:
 		return fmt.Errorf("unknown command: %q (try: pfm help)", args[0])
@@ -96,7 +114,7 @@ func (a *App) printHelp() {
 	fmt.Printf(`%s - Personal Finance CLI Manager
 
 Usage:
-  %s <command> [options]
+  %s [--db FILE] [--profile NAME] [--config FILE] <command> [options]
 
 Commands:
   help            Show this help
@@ -104,14 +122,27 @@ Commands:
   init            Create database + tables
   import          Import transactions from CSV/OFX (next)
   add             Add a transaction manually (later)
-  list			  List transactions
+  list            List transactions (search without a query)
   report          Generate reports (later)
   budget          Set/check budgets (later)
-  search          Search/filter transactions (later)
-  tui			  Start UI
+  rule            Manage, test and explain categorization rules
+  payee           Canonical payees and bank-text aliases
+  tag             Tag transactions (many-to-many labels)
+  goal            Savings goals and progress
+  loan            Loans, amortization schedules and payoff strategies
+  asset           Assets and liabilities with dated valuations
+  invest          Securities, trades, lots and prices
+  tax             Map categories and tags to tax buckets
+  categorize      Apply rules to uncategorized transactions
+  search          Search transactions with a query ('payee:~uber date:2026-Q1')
+  tui             Start UI
+  config          Settings file, profiles and import profiles
 
 Data:
-  Database file defaults to: pfm
+  Database file: %s
+  Chosen by --db, else $PFM_DB, else the db setting of the profile
+  (--profile, $PFM_PROFILE or the config file's profile), else pfm.db.
+  Config file: %s ($PFM_CONFIG or --config to use another)
 
 Examples:
   pfm init
@@ -123,7 +154,14 @@ Examples:
   pfm budget set --month 2025-12 --category groceries --limit 200
   pfm budget status --month 2025-12
   pfm search --min -200 --max -10
-`, exe, exe, filepath.Clean(a.DBPath), exe, exe)
+  pfm search 'payee:~uber amount<-50 (cat:transport OR cat:travel) -tag:reimbursed date:2026-Q1'
+  pfm search --save rides 'payee:~uber OR payee:~bolt'
+  pfm search @rides
+  pfm search --group-by month 'cat:groceries date:2026'
+  pfm list --sort amount --asc --limit 20 --offset 20
+  pfm --profile business list --month 2026-01
+  pfm config set date_format DD.MM.YYYY
+`, exe, exe, filepath.Clean(a.DBPath), a.Config.Path)
 }
 
 func (a *App) cmdAdd(args []string) error {
@@ -134,7 +172,7 @@ func (a *App) cmdAdd(args []string) error {
 	amountStr := fs.String("amount", "", "Amount in RON (e.g. -12.34) [required]")
 	category := fs.String("category", "uncategorized", "Category")
 	memo := fs.String("memo", "", "Memo/notes")
-	account := fs.String("account", "default", "Account name")
+	account := fs.String("account", a.Settings.Account, "Account name")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -164,9 +202,20 @@ func (a *App) cmdAdd(args []string) error {
 		return err
 	}
 
+	aliasRows, err := db.ListPayeeAliases(conn)
+	if err != nil {
+		return err
+	}
+	aliases, err := compileAliases(aliasRows)
+	if err != nil {
+		return err
+	}
+	canon := canonicalPayee(aliases, *payee)
+
 	id, inserted, err := db.InsertTransaction(conn, db.AddTxParams{
 		PostedAt:   postedAt,
-		Payee:      *payee,
+		Payee:      canon,
+		PayeeRaw:   *payee,
 		Memo:       *memo,
 		AmountBani: amountBani,
 		Category:   *category,
@@ -184,45 +233,48 @@ func (a *App) cmdAdd(args []string) error {
 
 	fmt.Printf("Added transaction #%d: %s | %s | %s | %s\n",
 		id,
-		postedAt.Format("2006-01-02"),
-		*payee,
-		FormatRON(amountBani),
+		a.disp.date(postedAt),
+		canon,
+		a.disp.money(amountBani),
 		*category,
 	)
 	return nil
 }
 
-func (a *App) cmdList(args []string) error {
-	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+func (a *App) cmdImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
 
-	month := fs.String("month", "", "Filter by month (YYYY-MM)")
-	fromStr := fs.String("from", "", "Start date (YYYY-MM-DD)")
-	toStr := fs.String("to", "", "End date (YYYY-MM-DD)")
-	category := fs.String("category", "", "Filter by category")
-	text := fs.String("text", "", "Search text in payee/memo (case-insensitive)")
-	limit := fs.Int("limit", 200, "Max rows to show")
+	file := fs.String("file", "", "CSV/OFX/QFX file path [required]")
+	account := fs.String("account", a.Settings.Account, "Account name")
+	source := fs.String("source", "", "Source label (default: csv/ofx based on extension)")
+	noRules := fs.Bool("no-rules", false, "Do not apply categorization rules to imported rows")
+	profile := fs.String("import-profile", "", "Import profile from the config file (account, delimiter, date format, column names)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-
-	var from *time.Time
-	var to *time.Time
-
-	if *fromStr != "" {
-		t, err := time.Parse("2006-01-02", *fromStr)
-		if err != nil {
-			return fmt.Errorf("invalid --from (expected YYYY-MM-DD): %w", err)
-		}
-		from = &t
+	if *file == "" {
+		return errors.New("missing required flag: --file")
 	}
 
-	if *toStr != "" {
-		t, err := time.Parse("2006-01-02", *toStr)
+	// Flags given on the command line win over the import profile.
+	var prof ImportOptions
+	if *profile != "" {
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		profNoRules, err := a.Config.applyImportProfile(*profile, &prof)
 		if err != nil {
-			return fmt.Errorf("invalid --to (expected YYYY-MM-DD): %w", err)
+			return err
+		}
+		if !set["account"] && prof.Account != "" {
+			*account = prof.Account
+		}
+		if !set["source"] && prof.Source != "" {
+			*source = prof.Source
+		}
+		if !set["no-rules"] {
+			*noRules = profNoRules
 		}
-		to = &t
 	}
 
 	conn, err := db.Open(a.DBPath)
@@ -235,87 +287,53 @@ func (a *App) cmdList(args []string) error {
 		return err
 	}
 
-	rows, err := db.ListTransactions(conn, db.ListFilter{
-		Month:    *month,
-		From:     from,
-		To:       to,
-		Category: *category,
-		Text:     *text,
-		Limit:    *limit,
-	})
-	if err != nil {
-		return err
-	}
-
-	if len(rows) == 0 {
-		fmt.Println("No transactions found.")
-		return nil
-	}
-
-	fmt.Printf("%-5s  %-10s  %-10s  %-18s  %-12s  %s\n", "ID", "DATE", "ACCOUNT", "PAYEE", "AMOUNT", "CATEGORY")
-	fmt.Printf("%s\n", "-----  ----------  ----------  ------------------  ------------  --------")
-
-	var total int64
-	for _, r := range rows {
-		total += r.AmountBani
-		payee := r.Payee
-		if len(payee) > 18 {
-			payee = payee[:18]
-		}
-		fmt.Printf("%-5d  %-10s  %-10s  %-18s  %-12s  %s\n",
-			r.ID,
-			r.PostedAt.Format("2006-01-02"),
-			trunc(r.Account, 10),
-			trunc(payee, 18),
-			FormatRON(r.AmountBani),
-			r.Category,
-		)
+	ext := strings.ToLower(filepath.Ext(*file))
+	src := *source
+	if src == "" {
+		if ext == ".csv" {
+			src = "csv"
+		} else {
+			src = "ofx"
+		}
 	}
 
-	fmt.Printf("\nShown: %d   Net total: %s\n", len(rows), FormatRON(total))
-	return nil
-}
-
-func (a *App) cmdImport(args []string) error {
-	fs := flag.NewFlagSet("import", flag.ContinueOnError)
-
-	file := fs.String("file", "", "CSV/OFX/QFX file path [required]")
-	account := fs.String("account", "default", "Account name")
-	source := fs.String("source", "", "Source label (default: csv/ofx based on extension)")
+	opts := ImportOptions{Account: *account, Source: src, Comma: prof.Comma, DateLayout: prof.DateLayout, Columns: prof.Columns}
 
-	if err := fs.Parse(args); err != nil {
+	aliasRows, err := db.ListPayeeAliases(conn)
+	if err != nil {
 		return err
 	}
-	if *file == "" {
-		return errors.New("missing required flag: --file")
+	opts.Aliases, err = compileAliases(aliasRows)
+	if err != nil {
+		return err
 	}
 
-	conn, err := db.Open(a.DBPath)
+	loanRows, err := db.ListLoans(conn)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
-
-	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+	opts.Loans, err = compileLoans(loanRows)
+	if err != nil {
 		return err
 	}
 
-	ext := strings.ToLower(filepath.Ext(*file))
-	src := *source
-	if src == "" {
-		if ext == ".csv" {
-			src = "csv"
-		} else {
-			src = "ofx"
+	if !*noRules {
+		ruleRows, err := db.ListRules(conn)
+		if err != nil {
+			return err
+		}
+		opts.Rules, err = compileRules(ruleRows)
+		if err != nil {
+			return err
 		}
 	}
 
 	var result ImportResult
 	switch ext {
 	case ".csv":
-		result, err = ImportCSV(conn, *file, *account, src)
+		result, err = ImportCSV(conn, *file, opts)
 	case ".ofx", ".qfx":
-		result, err = ImportOFX(conn, *file, *account, src)
+		result, err = ImportOFX(conn, *file, opts)
 	default:
 		return fmt.Errorf("unsupported file type: %s (use .csv, .ofx, .qfx)", ext)
 	}
@@ -323,22 +341,43 @@ func (a *App) cmdImport(args []string) error {
 		return err
 	}
 
-	fmt.Printf("Import complete: seen=%d inserted=%d ignored=%d\n", result.Seen, result.Inserted, result.Ignored)
+	if err := db.RecordRuleHits(conn, result.RuleHits); err != nil {
+		return err
+	}
+
+	fmt.Printf("Import complete: seen=%d inserted=%d ignored=%d categorized=%d\n",
+		result.Seen, result.Inserted, result.Ignored, result.Categorized)
+	if result.LoanPayments > 0 {
+		fmt.Printf("Loan payments split into principal and interest: %d\n", result.LoanPayments)
+	}
+	printRuleCounts(opts.Rules, result.RuleHits)
 	return nil
 }
 
 func (a *App) cmdReport(args []string) error {
 	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
-		fmt.Println(`Usage:
+		fmt.Print(`Usage:
   pfm report <subcommand> [options]
 
 Subcommands:
   month        Monthly summary
   categories   Category breakdown (expenses)
+  payees       Breakdown by canonical payee (expenses)
+  tags         Breakdown by tag (expenses)
+  networth     Net worth by month: accounts, assets, liabilities, loans
+  portfolio    Holdings: market value, cost basis, realized/unrealized gains
+  tax          Tax year totals per bucket, per month, with CSV export
 
 Examples:
   pfm report month --month 2026-01
   pfm report categories --month 2026-01
+  pfm report categories --month 2026-01 --by-payee
+  pfm report payees --month 2026-01
+  pfm report tags --month 2026-01
+  pfm report networth --from 2025-01 --to 2025-12
+  pfm report portfolio --as-of 2026-01-31 --method average
+  pfm report tax --year 2025 --csv tax-2025.csv
+  pfm report categories --month 2026-01 --tag vacation-2026
 `)
 		return nil
 	}
@@ -348,6 +387,16 @@ Examples:
 		return a.cmdReportMonth(args[1:])
 	case "categories":
 		return a.cmdReportCategories(args[1:])
+	case "payees":
+		return a.cmdReportPayees(args[1:])
+	case "tags":
+		return a.cmdReportTags(args[1:])
+	case "networth":
+		return a.cmdReportNetworth(args[1:])
+	case "portfolio":
+		return a.cmdReportPortfolio(args[1:])
+	case "tax":
+		return a.cmdReportTax(args[1:])
 	default:
 		return fmt.Errorf("unknown report subcommand: %q (try: pfm report help)", args[0])
 	}
@@ -356,6 +405,7 @@ Examples:
 func (a *App) cmdReportMonth(args []string) error {
 	fs := flag.NewFlagSet("report month", flag.ContinueOnError)
 	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	tag := fs.String("tag", "", "Only transactions with this tag")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -373,7 +423,7 @@ func (a *App) cmdReportMonth(args []string) error {
 		return err
 	}
 
-	s, err := db.GetMonthSummary(conn, *month)
+	s, err := db.GetMonthSummary(conn, *month, *tag)
 	if err != nil {
 		return err
 	}
@@ -381,10 +431,13 @@ func (a *App) cmdReportMonth(args []string) error {
 	expenseAbs := -s.ExpenseBani
 
 	fmt.Printf("Month: %s\n", s.Month)
+	if *tag != "" {
+		fmt.Printf("Tag: %s\n", *tag)
+	}
 	fmt.Printf("Transactions: %d\n", s.Count)
-	fmt.Printf("Income:   %s\n", FormatRON(s.IncomeBani))
-	fmt.Printf("Expenses: %s\n", FormatRON(expenseAbs))
-	fmt.Printf("Net:      %s\n", FormatRON(s.NetBani))
+	fmt.Printf("Income:   %s\n", a.disp.money(s.IncomeBani))
+	fmt.Printf("Expenses: %s\n", a.disp.money(expenseAbs))
+	fmt.Printf("Net:      %s\n", a.disp.money(s.NetBani))
 
 	return nil
 }
@@ -393,6 +446,8 @@ func (a *App) cmdReportCategories(args []string) error {
 	fs := flag.NewFlagSet("report categories", flag.ContinueOnError)
 	month := fs.String("month", "", "Month (YYYY-MM) [required]")
 	all := fs.Bool("all", false, "Include income categories too (default: expenses only)")
+	byPayee := fs.Bool("by-payee", false, "Break each category down by canonical payee")
+	tag := fs.String("tag", "", "Only transactions with this tag")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -411,7 +466,7 @@ func (a *App) cmdReportCategories(args []string) error {
 	}
 
 	expensesOnly := !*all
-	rows, grand, err := db.GetCategoryTotalsForMonth(conn, *month, expensesOnly)
+	rows, grand, err := db.GetCategoryTotalsForMonth(conn, *month, expensesOnly, *tag)
 	if err != nil {
 		return err
 	}
@@ -424,37 +479,188 @@ func (a *App) cmdReportCategories(args []string) error {
 	if *all {
 		title = "Category totals (all)"
 	}
+	if *tag != "" {
+		title += " tagged " + *tag
+	}
+	fmt.Printf("%s for %s\n\n", title, *month)
+	t := newTable(
+		tableCol{Title: "CATEGORY", Max: 40, Min: 12},
+		tableCol{Title: "COUNT", Right: true},
+		tableCol{Title: "TOTAL", Right: true},
+	)
+
+	for _, r := range rows {
+		amt := r.TotalBani
+		if expensesOnly {
+			amt = -amt
+		}
+		t.add(r.Category, strconv.FormatInt(r.Count, 10), a.disp.money(amt))
+
+		if !*byPayee {
+			continue
+		}
+		payees, _, err := db.GetPayeeTotalsForMonth(conn, *month, r.Category, expensesOnly, *tag)
+		if err != nil {
+			return err
+		}
+		for _, p := range payees {
+			amt := p.TotalBani
+			if expensesOnly {
+				amt = -amt
+			}
+			t.add("  "+p.Payee, strconv.FormatInt(p.Count, 10), a.disp.money(amt))
+		}
+	}
+	t.print()
+
+	if expensesOnly {
+		grand = -grand
+	}
+	fmt.Printf("\nGrand total: %s\n", a.disp.money(grand))
+	return nil
+}
+
+func (a *App) cmdReportPayees(args []string) error {
+	fs := flag.NewFlagSet("report payees", flag.ContinueOnError)
+	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	category := fs.String("category", "", "Only payees in this category")
+	tag := fs.String("tag", "", "Only transactions with this tag")
+	all := fs.Bool("all", false, "Include income too (default: expenses only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" {
+		return errors.New("missing required flag: --month (YYYY-MM)")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	expensesOnly := !*all
+	rows, grand, err := db.GetPayeeTotalsForMonth(conn, *month, *category, expensesOnly, *tag)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		fmt.Println("No matching transactions.")
+		return nil
+	}
+
+	title := "Payee totals (expenses)"
+	if *all {
+		title = "Payee totals (all)"
+	}
+	if *tag != "" {
+		title += " tagged " + *tag
+	}
 	fmt.Printf("%s for %s\n\n", title, *month)
-	fmt.Printf("%-18s  %-8s  %s\n", "CATEGORY", "COUNT", "TOTAL")
-	fmt.Printf("%s\n", "------------------  --------  ------------")
+	t := newTable(
+		tableCol{Title: "PAYEE", Max: 40, Min: 12},
+		tableCol{Title: "COUNT", Right: true},
+		tableCol{Title: "TOTAL", Right: true},
+	)
 
 	for _, r := range rows {
 		amt := r.TotalBani
 		if expensesOnly {
 			amt = -amt
 		}
-		fmt.Printf("%-18s  %-8d  %s\n", trunc(r.Category, 18), r.Count, FormatRON(amt))
+		t.add(r.Payee, strconv.FormatInt(r.Count, 10), a.disp.money(amt))
 	}
+	t.print()
 
 	if expensesOnly {
 		grand = -grand
 	}
-	fmt.Printf("\nGrand total: %s\n", FormatRON(grand))
+	fmt.Printf("\nGrand total: %s\n", a.disp.money(grand))
+	return nil
+}
+
+func (a *App) cmdReportTags(args []string) error {
+	fs := flag.NewFlagSet("report tags", flag.ContinueOnError)
+	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	all := fs.Bool("all", false, "Include income too (default: expenses only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" {
+		return errors.New("missing required flag: --month (YYYY-MM)")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	expensesOnly := !*all
+	rows, err := db.GetTagTotalsForMonth(conn, *month, expensesOnly)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		fmt.Println("No tagged transactions.")
+		return nil
+	}
+
+	title := "Tag totals (expenses)"
+	if *all {
+		title = "Tag totals (all)"
+	}
+	fmt.Printf("%s for %s\n\n", title, *month)
+	t := newTable(
+		tableCol{Title: "TAG", Max: 40, Min: 12},
+		tableCol{Title: "COUNT", Right: true},
+		tableCol{Title: "TOTAL", Right: true},
+	)
+
+	for _, r := range rows {
+		amt := r.TotalBani
+		if expensesOnly {
+			amt = -amt
+		}
+		t.add(r.Tag, strconv.FormatInt(r.Count, 10), a.disp.money(amt))
+	}
+	t.print()
+
+	fmt.Println("\nTransactions with several tags count toward each of them.")
 	return nil
 }
 
 func (a *App) cmdBudget(args []string) error {
 	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
-		fmt.Println(`Usage:
+		fmt.Print(`Usage:
   pfm budget <subcommand> [options]
 
 Subcommands:
-  set      Set a budget for a month+category
-  status   Show budgets vs spending for a month
+  set        Set a budget for a month+category, a recurring budget or a yearly budget
+  status     Show budgets vs spending for a month
+  templates  List recurring budgets
+  assign     Assign income to a category envelope (zero-based budgeting)
+  move       Move money between envelopes
+  envelopes  Show to-be-budgeted and available money per envelope
+  check      Send alerts for budgets that crossed a threshold (cron-friendly)
 
 Examples:
   pfm budget set --month 2026-01 --category groceries --limit 800
+  pfm budget set --month 2026-01 --category groceries --limit 800 --recurring --rollover
+  pfm budget set --year 2026 --category holidays --limit 6000
   pfm budget status --month 2026-01
+  pfm budget assign --month 2026-01 --category groceries --amount 1200
+  pfm budget move --month 2026-01 --from dining --to groceries --amount 100
+  pfm budget envelopes --month 2026-01
+  pfm budget check --quiet --webhook http://localhost:8080/hook
 `)
 		return nil
 	}
@@ -464,6 +670,16 @@ Examples:
 		return a.cmdBudgetSet(args[1:])
 	case "status":
 		return a.cmdBudgetStatus(args[1:])
+	case "templates":
+		return a.cmdBudgetTemplates(args[1:])
+	case "assign":
+		return a.cmdBudgetAssign(args[1:])
+	case "move":
+		return a.cmdBudgetMove(args[1:])
+	case "envelopes":
+		return a.cmdBudgetEnvelopes(args[1:])
+	case "check":
+		return a.cmdBudgetCheck(args[1:])
 	default:
 		return fmt.Errorf("unknown budget subcommand: %q (try: pfm budget help)", args[0])
 	}
@@ -472,15 +688,24 @@ Examples:
 func (a *App) cmdBudgetSet(args []string) error {
 	fs := flag.NewFlagSet("budget set", flag.ContinueOnError)
 
-	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	month := fs.String("month", "", "Month (YYYY-MM); with --recurring, the first month")
+	year := fs.String("year", "", "Year (YYYY) for a yearly budget, instead of --month")
 	category := fs.String("category", "", "Category [required]")
 	limitStr := fs.String("limit", "", "Limit in RON (e.g. 800 or 800.50) [required]")
+	recurring := fs.Bool("recurring", false, "Apply every month from --month until changed (--limit 0 stops it)")
+	rollover := fs.Bool("rollover", false, "With --recurring: carry unspent or overspent amounts into the next month")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	if *month == "" || *category == "" || *limitStr == "" {
-		return errors.New("missing required flags: --month, --category, --limit")
+	if (*month == "") == (*year == "") || *category == "" || *limitStr == "" {
+		return errors.New("missing required flags: --month or --year, --category, --limit")
+	}
+	if *year != "" && *recurring {
+		return errors.New("--recurring cannot be combined with --year")
+	}
+	if *rollover && !*recurring {
+		return errors.New("--rollover requires --recurring")
 	}
 
 	limitBani, err := ParseRON(*limitStr)
@@ -491,6 +716,17 @@ func (a *App) cmdBudgetSet(args []string) error {
 		return errors.New("--limit must be positive")
 	}
 
+	if *month != "" {
+		if _, err := parseMonth(*month); err != nil {
+			return err
+		}
+	}
+	if *year != "" {
+		if _, err := time.Parse("2006", *year); err != nil {
+			return fmt.Errorf("invalid --year %q (expected YYYY)", *year)
+		}
+	}
+
 	conn, err := db.Open(a.DBPath)
 	if err != nil {
 		return err
@@ -501,11 +737,39 @@ func (a *App) cmdBudgetSet(args []string) error {
 		return err
 	}
 
-	if err := db.UpsertBudget(conn, *month, *category, limitBani); err != nil {
-		return err
-	}
+	switch {
+	case *year != "":
+		if err := db.UpsertAnnualBudget(conn, *year, *category, limitBani); err != nil {
+			return err
+		}
+		fmt.Printf("Yearly budget set: %s %s = %s\n", *year, *category, a.disp.money(limitBani))
+
+	case *recurring:
+		err := db.UpsertBudgetTemplate(conn, db.BudgetTemplate{
+			Category:   *category,
+			StartMonth: *month,
+			LimitBani:  limitBani,
+			Rollover:   *rollover,
+		})
+		if err != nil {
+			return err
+		}
+		if limitBani == 0 {
+			fmt.Printf("Recurring budget stopped: %s from %s\n", *category, *month)
+			return nil
+		}
+		extra := ""
+		if *rollover {
+			extra = " with rollover"
+		}
+		fmt.Printf("Recurring budget set: %s = %s every month from %s%s\n", *category, a.disp.money(limitBani), *month, extra)
 
-	fmt.Printf("Budget set: %s %s = %s\n", *month, *category, FormatRON(limitBani))
+	default:
+		if err := db.UpsertBudget(conn, *month, *category, limitBani); err != nil {
+			return err
+		}
+		fmt.Printf("Budget set: %s %s = %s\n", *month, *category, a.disp.money(limitBani))
+	}
 	return nil
 }
 
@@ -513,7 +777,9 @@ func (a *App) cmdBudgetStatus(args []string) error {
 	fs := flag.NewFlagSet("budget status", flag.ContinueOnError)
 
 	month := fs.String("month", "", "Month (YYYY-MM) [required]")
-	warnPct := fs.Int("warn", 80, "Warn threshold percent (default 80)")
+	warnPct := fs.Int("warn", a.Settings.WarnPct, "Warn threshold percent")
+	asOfStr := fs.String("as-of", "", "Pace and project as of this date (YYYY-MM-DD), default today")
+	history := fs.Int("history", 0, "Also show each category against its budget over the previous N months")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -521,9 +787,24 @@ func (a *App) cmdBudgetStatus(args []string) error {
 	if *month == "" {
 		return errors.New("missing required flag: --month (YYYY-MM)")
 	}
+	if _, err := parseMonth(*month); err != nil {
+		return err
+	}
 	if *warnPct <= 0 || *warnPct > 1000 {
 		return errors.New("--warn must be a reasonable percent (1..1000)")
 	}
+	if *history < 0 || *history > 120 {
+		return errors.New("--history must be between 0 and 120")
+	}
+
+	asOf := time.Now()
+	if *asOfStr != "" {
+		t, err := time.Parse("2006-01-02", *asOfStr)
+		if err != nil {
+			return fmt.Errorf("invalid --as-of %q (expected YYYY-MM-DD)", *asOfStr)
+		}
+		asOf = t
+	}
 
 	conn, err := db.Open(a.DBPath)
 	if err != nil {
@@ -535,45 +816,126 @@ func (a *App) cmdBudgetStatus(args []string) error {
 		return err
 	}
 
-	budgets, err := db.ListBudgetsForMonth(conn, *month)
+	budgets, err := resolveBudgets(conn, *month)
+	if err != nil {
+		return err
+	}
+	annual, err := resolveAnnualBudgets(conn, *month, asOf)
 	if err != nil {
 		return err
 	}
-	if len(budgets) == 0 {
+	if len(budgets) == 0 && len(annual) == 0 {
 		fmt.Println("No budgets set for that month.")
 		return nil
 	}
 
-	fmt.Printf("Budget status for %s\n\n", *month)
-	fmt.Printf("%-18s  %-12s  %-12s  %-8s  %s\n", "CATEGORY", "LIMIT", "SPENT", "USED", "STATUS")
-	fmt.Printf("%s\n", "------------------  ------------  ------------  --------  ------")
+	if len(budgets) > 0 {
+		elapsed, days := monthProgress(*month, asOf)
+		fmt.Printf("Budget status for %s (day %d of %d)\n\n", *month, elapsed, days)
+		t := newTable(
+			tableCol{Title: "CATEGORY", Max: 18, Min: 8},
+			tableCol{Title: "LIMIT", Right: true},
+			tableCol{Title: "CARRY IN", Right: true},
+			tableCol{Title: "EFFECTIVE", Right: true},
+			tableCol{Title: "SPENT", Right: true},
+			tableCol{Title: "USED", Right: true},
+			tableCol{Title: "STATUS"},
+			tableCol{Title: "EXPECTED", Right: true},
+			tableCol{Title: "PER DAY LEFT", Right: true},
+			tableCol{Title: "PROJECTED", Right: true},
+			tableCol{Title: ""},
+		)
 
-	for _, b := range budgets {
-		spentNeg, err := db.GetSpentForMonthCategory(conn, b.Month, b.Category)
-		if err != nil {
-			return err
+		var pending int64
+		for _, b := range budgets {
+			effective := b.EffectiveBani()
+			usedPct, status := budgetStatus(b.SpentBani, effective, *warnPct)
+
+			carry := "-"
+			if b.Rollover {
+				carry = a.disp.money(b.CarryBani)
+			}
+
+			p, err := paceBudget(conn, b, *month, asOf)
+			if err != nil {
+				return err
+			}
+			perDay := "-"
+			if p.DaysLeft > 0 {
+				perDay = a.disp.money(p.DailyLeftBani)
+			}
+			projected := a.disp.money(p.ProjectedBani)
+			if p.PendingBani > 0 {
+				projected += "*"
+				pending += p.PendingBani
+			}
+			note := ""
+			if p.ProjectedBani > effective && status != "OVER" {
+				note = "(over)"
+			}
+
+			t.add(
+				b.Category,
+				a.disp.money(b.LimitBani),
+				carry,
+				a.disp.money(effective),
+				a.disp.money(b.SpentBani),
+				strconv.Itoa(usedPct)+"%",
+				status,
+				a.disp.money(p.ExpectedBani),
+				perDay,
+				projected,
+				note,
+			)
 		}
-		spentAbs := -spentNeg
-
-		usedPct := 0
-		if b.LimitBani > 0 {
-			usedPct = int((spentAbs * 100) / b.LimitBani)
+		t.print()
+		if pending > 0 {
+			fmt.Printf("\n* includes %s of recurring charges not yet posted this month\n", a.disp.money(pending))
 		}
 
-		status := "OK"
-		if spentAbs > b.LimitBani {
-			status = "OVER"
-		} else if usedPct >= *warnPct {
-			status = "WARN"
+		if *history > 0 {
+			if err := printBudgetHistory(conn, budgets, *month, *history, *warnPct); err != nil {
+				return err
+			}
 		}
+	}
 
-		fmt.Printf("%-18s  %-12s  %-12s  %-7d%%  %s\n",
-			trunc(b.Category, 18),
-			FormatRON(b.LimitBani),
-			FormatRON(spentAbs),
-			usedPct,
-			status,
+	if len(annual) > 0 {
+		if len(budgets) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Yearly budgets as of %s\n\n", a.disp.date(annual[0].AsOf))
+		t := newTable(
+			tableCol{Title: "CATEGORY", Max: 18, Min: 8},
+			tableCol{Title: "LIMIT", Right: true},
+			tableCol{Title: "EXPECTED", Right: true},
+			tableCol{Title: "YTD SPENT", Right: true},
+			tableCol{Title: "USED", Right: true},
+			tableCol{Title: "STATUS"},
 		)
+		for _, b := range annual {
+			usedPct := 0
+			if b.LimitBani > 0 {
+				usedPct = int((b.SpentBani * 100) / b.LimitBani)
+			}
+
+			status := "OK"
+			if b.SpentBani > b.LimitBani {
+				status = "OVER"
+			} else if b.SpentBani > b.ExpectedBani {
+				status = "AHEAD"
+			}
+
+			t.add(
+				b.Category,
+				a.disp.money(b.LimitBani),
+				a.disp.money(b.ExpectedBani),
+				a.disp.money(b.SpentBani),
+				strconv.Itoa(usedPct)+"%",
+				status,
+			)
+		}
+		t.print()
 	}
 
 	return nil
@@ -581,16 +943,34 @@ func (a *App) cmdBudgetStatus(args []string) error {
 
 func (a *App) cmdRule(args []string) error {
 	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
-		fmt.Println(`Usage:
+		fmt.Print(`Usage:
   pfm rule <subcommand> [options]
 
 Subcommands:
-  add    Add a categorization rule (regex)
-  list   List rules
+  add      Add a categorization rule (regex)
+  list     List rules with hit counters
+  edit     Change a rule's name, pattern, category or priority
+  delete   Delete a rule
+  disable  Stop evaluating a rule without deleting it
+  enable   Re-enable a disabled rule
+  move     Change a rule's position in the evaluation order
+  test     Show which transactions a candidate rule would match
+  explain  Show every rule evaluated against a transaction
+  lint     Find rules that never match or are shadowed by earlier rules
+  export   Write rules to a JSON or YAML file
+  import   Load rules from a JSON or YAML file
 
 Examples:
   pfm rule add --name groceries --pattern "(?i)lidl|kaufland" --category groceries --priority 10
   pfm rule list
+  pfm rule edit --id 3 --pattern "(?i)lidl|kaufland|auchan"
+  pfm rule move --id 3 --before 1
+  pfm rule disable --id 3
+  pfm rule test --pattern "(?i)uber" --category transport --month 2026-01
+  pfm rule explain 42
+  pfm rule lint
+  pfm rule export --file rules.yaml
+  pfm rule import --file rules.yaml
 `)
 		return nil
 	}
@@ -600,6 +980,26 @@ Examples:
 		return a.cmdRuleAdd(args[1:])
 	case "list":
 		return a.cmdRuleList(args[1:])
+	case "edit":
+		return a.cmdRuleEdit(args[1:])
+	case "delete":
+		return a.cmdRuleDelete(args[1:])
+	case "disable":
+		return a.cmdRuleSetEnabled(args[1:], false)
+	case "enable":
+		return a.cmdRuleSetEnabled(args[1:], true)
+	case "move":
+		return a.cmdRuleMove(args[1:])
+	case "test":
+		return a.cmdRuleTest(args[1:])
+	case "explain":
+		return a.cmdRuleExplain(args[1:])
+	case "lint":
+		return a.cmdRuleLint(args[1:])
+	case "export":
+		return a.cmdRuleExport(args[1:])
+	case "import":
+		return a.cmdRuleImport(args[1:])
 	default:
 		return fmt.Errorf("unknown rule subcommand: %q (try: pfm rule help)", args[0])
 	}
@@ -666,27 +1066,53 @@ func (a *App) cmdRuleList(args []string) error {
 		return nil
 	}
 
-	fmt.Printf("%-4s  %-10s  %-8s  %-8s  %s\n", "ID", "PRIORITY", "CATEGORY", "NAME", "PATTERN")
-	fmt.Printf("%s\n", "----  ----------  --------  --------  ------------------------------")
+	t := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "PRIORITY", Right: true},
+		tableCol{Title: "ON"},
+		tableCol{Title: "HITS", Right: true},
+		tableCol{Title: "LAST MATCHED"},
+		tableCol{Title: "CATEGORY", Max: 18, Min: 8},
+		tableCol{Title: "NAME", Max: 18, Min: 8},
+		tableCol{Title: "PATTERN", Min: 12},
+	)
 	for _, r := range rules {
-		fmt.Printf("%-4d  %-10d  %-8s  %-8s  %s\n",
-			r.ID, r.Priority, trunc(r.Category, 8), trunc(r.Name, 8), r.Pattern)
+		on := "yes"
+		if !r.Enabled {
+			on = "no"
+		}
+		last := a.disp.dateText(r.LastMatchedAt)
+		if last == "" {
+			last = "never"
+		}
+		t.add(strconv.FormatInt(r.ID, 10), strconv.FormatInt(r.Priority, 10), on, strconv.FormatInt(r.HitCount, 10), last, r.Category, r.Name, r.Pattern)
 	}
+	t.print()
 
 	return nil
 }
 
-func (a *App) cmdCategorize(args []string) error {
-	fs := flag.NewFlagSet("categorize", flag.ContinueOnError)
+func (a *App) cmdRuleEdit(args []string) error {
+	fs := flag.NewFlagSet("rule edit", flag.ContinueOnError)
 
-	month := fs.String("month", "", "Only categorize transactions in month (YYYY-MM)")
-	all := fs.Bool("all", false, "Categorize all uncategorized transactions (ignores --month if empty)")
-	dry := fs.Bool("dry-run", false, "Show changes without writing to DB")
-	limit := fs.Int("limit", 500, "Max transactions to process")
+	id := fs.Int64("id", 0, "Rule id [required]")
+	name := fs.String("name", "", "New rule name")
+	pattern := fs.String("pattern", "", "New regex pattern")
+	category := fs.String("category", "", "New category")
+	priority := fs.Int64("priority", 0, "New priority (lower runs first)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["name"] && !set["pattern"] && !set["category"] && !set["priority"] {
+		return errors.New("nothing to change: pass --name, --pattern, --category or --priority")
+	}
 
 	conn, err := db.Open(a.DBPath)
 	if err != nil {
@@ -697,84 +1123,205 @@ func (a *App) cmdCategorize(args []string) error {
 		return err
 	}
 
-	ruleRows, err := db.ListRules(conn)
+	r, err := db.GetRule(conn, *id)
 	if err != nil {
 		return err
 	}
-	if len(ruleRows) == 0 {
-		fmt.Println("No rules. Add some with: pfm rule add ...")
-		return nil
+	if set["name"] {
+		r.Name = *name
+	}
+	if set["pattern"] {
+		if _, err := regexp.Compile(*pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		r.Pattern = *pattern
+	}
+	if set["category"] {
+		r.Category = *category
+	}
+	if set["priority"] {
+		r.Priority = *priority
+	}
+	if r.Name == "" || r.Pattern == "" || r.Category == "" {
+		return errors.New("--name, --pattern and --category cannot be empty")
 	}
 
-	rules, err := compileRules(ruleRows)
-	if err != nil {
+	if err := db.UpdateRule(conn, r); err != nil {
 		return err
 	}
 
-	filterMonth := *month
-	if *all && filterMonth == "" {
-		filterMonth = ""
-	}
+	fmt.Printf("Updated rule #%d: %s -> %s (priority %d) %s\n", r.ID, r.Name, r.Category, r.Priority, r.Pattern)
+	return nil
+}
 
-	txs, err := db.ListTxForCategorize(conn, filterMonth, *all)
+func (a *App) cmdRuleDelete(args []string) error {
+	fs := flag.NewFlagSet("rule delete", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "Rule id [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	conn, err := db.Open(a.DBPath)
 	if err != nil {
 		return err
 	}
-	if len(txs) == 0 {
-		fmt.Println("No uncategorized transactions found.")
-		return nil
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
 	}
 
-	if *limit > 0 && len(txs) > *limit {
-		txs = txs[:*limit]
+	if err := db.DeleteRule(conn, *id); err != nil {
+		return err
 	}
 
-	changed := 0
-	for _, t := range txs {
-		newCat, rule := matchCategory(rules, t.Payee, t.Memo)
-		if newCat == "" {
-			continue
+	fmt.Printf("Deleted rule #%d\n", *id)
+	return nil
+}
+
+func (a *App) cmdRuleSetEnabled(args []string, enabled bool) error {
+	verb := "disable"
+	if enabled {
+		verb = "enable"
+	}
+
+	fs := flag.NewFlagSet("rule "+verb, flag.ContinueOnError)
+	id := fs.Int64("id", 0, "Rule id [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if err := db.SetRuleEnabled(conn, *id, enabled); err != nil {
+		return err
+	}
+
+	fmt.Printf("Rule #%d %sd\n", *id, verb)
+	return nil
+}
+
+func (a *App) cmdRuleMove(args []string) error {
+	fs := flag.NewFlagSet("rule move", flag.ContinueOnError)
+
+	id := fs.Int64("id", 0, "Rule id [required]")
+	before := fs.Int64("before", 0, "Place the rule right before this rule id")
+	after := fs.Int64("after", 0, "Place the rule right after this rule id")
+	top := fs.Bool("top", false, "Evaluate the rule first")
+	bottom := fs.Bool("bottom", false, "Evaluate the rule last")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	targets := 0
+	for _, set := range []bool{*before != 0, *after != 0, *top, *bottom} {
+		if set {
+			targets++
 		}
+	}
+	if targets != 1 {
+		return errors.New("pass exactly one of --before, --after, --top, --bottom")
+	}
 
-		changed++
-		if *dry {
-			fmt.Printf("[DRY] #%d %s %q -> %s (rule: %s)\n", t.ID, t.PostedAt, t.Payee, newCat, rule.Name)
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	rules, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+
+	order := make([]int64, 0, len(rules))
+	found := false
+	for _, r := range rules {
+		if r.ID == *id {
+			found = true
 			continue
 		}
+		order = append(order, r.ID)
+	}
+	if !found {
+		return fmt.Errorf("rule #%d not found", *id)
+	}
 
-		if err := db.UpdateTxCategory(conn, t.ID, newCat); err != nil {
-			return err
+	pos := -1
+	switch {
+	case *top:
+		pos = 0
+	case *bottom:
+		pos = len(order)
+	default:
+		ref := *before
+		if *after != 0 {
+			ref = *after
+		}
+		for i, rid := range order {
+			if rid == ref {
+				pos = i
+				if *after != 0 {
+					pos++
+				}
+			}
+		}
+		if pos < 0 {
+			return fmt.Errorf("rule #%d not found", ref)
 		}
-		fmt.Printf("Updated #%d -> %s (rule: %s)\n", t.ID, newCat, rule.Name)
 	}
 
-	if changed == 0 {
-		fmt.Println("No matches.")
-	} else if *dry {
-		fmt.Printf("Would update %d transaction(s).\n", changed)
-	} else {
-		fmt.Printf("Updated %d transaction(s).\n", changed)
+	order = append(order[:pos], append([]int64{*id}, order[pos:]...)...)
+	if err := db.ReorderRules(conn, order); err != nil {
+		return err
 	}
 
+	fmt.Printf("Moved rule #%d to position %d of %d (priorities renumbered)\n", *id, pos+1, len(order))
 	return nil
 }
 
-func (a *App) cmdSearch(args []string) error {
-	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+func (a *App) cmdRuleTest(args []string) error {
+	fs := flag.NewFlagSet("rule test", flag.ContinueOnError)
 
-	month := fs.String("month", "", "Filter by month (YYYY-MM)")
+	pattern := fs.String("pattern", "", "Candidate regex pattern [required]")
+	category := fs.String("category", "", "Category the candidate rule would apply")
+	priority := fs.Int64("priority", 100, "Priority the candidate rule would have (lower runs first)")
+	month := fs.String("month", "", "Only test transactions in month (YYYY-MM)")
 	fromStr := fs.String("from", "", "Start date (YYYY-MM-DD)")
 	toStr := fs.String("to", "", "End date (YYYY-MM-DD)")
-	category := fs.String("category", "", "Filter by category")
-	text := fs.String("text", "", "Search text in payee/memo (case-insensitive)")
 	account := fs.String("account", "", "Filter by account")
-	minStr := fs.String("min", "", "Min amount in RON (inclusive, e.g. -200 or 0)")
-	maxStr := fs.String("max", "", "Max amount in RON (inclusive, e.g. -10 or 5000)")
-	limit := fs.Int("limit", 200, "Max rows to show")
+	limit := fs.Int("limit", 5000, "Max transactions to scan")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *pattern == "" {
+		return errors.New("missing required flag: --pattern")
+	}
+
+	re, err := regexp.Compile(*pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
 
 	var from *time.Time
 	var to *time.Time
@@ -794,22 +1341,374 @@ func (a *App) cmdSearch(args []string) error {
 		to = &t
 	}
 
-	var minBani *int64
-	var maxBani *int64
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	ruleRows, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+	rules, err := compileRules(ruleRows)
+	if err != nil {
+		return err
+	}
+
+	txs, err := db.SearchTransactions(conn, db.SearchFilter{
+		Month:   *month,
+		From:    from,
+		To:      to,
+		Account: *account,
+		Limit:   *limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	candidate := compiledRule{Name: "(candidate)", Category: *category, Re: re, Priority: *priority}
+
+	fmt.Printf("Candidate: %s", *pattern)
+	if *category != "" {
+		fmt.Printf(" -> %s", *category)
+	}
+	fmt.Printf(" (priority %d)\n\n", *priority)
+
+	tbl := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "DATE"},
+		tableCol{Title: "PAYEE", Max: 30, Min: 10},
+		tableCol{Title: "CURRENT", Max: 18, Min: 8},
+		tableCol{Title: "RESULT", Min: 12},
+	)
+	var matched, fresh, changes, same, shadowed int
+	for _, row := range testRule(rules, txs, candidate) {
+		matched++
+		var result string
+		switch row.Outcome {
+		case ruleShadowed:
+			shadowed++
+			w := row.Winner
+			result = fmt.Sprintf("shadowed by rule #%d %s -> %s (priority %d)", w.ID, w.Name, w.Category, w.Priority)
+		case ruleMatch:
+			result = "match"
+		case ruleUnchanged:
+			same++
+			result = "unchanged"
+		case ruleSets:
+			fresh++
+			result = "would set " + *category
+		case ruleChanges:
+			changes++
+			result = "would change to " + *category
+		}
+
+		t := row.Tx
+		tbl.add(
+			strconv.FormatInt(t.ID, 10),
+			a.disp.date(t.PostedAt),
+			t.Payee,
+			t.Category,
+			result,
+		)
+	}
+
+	if matched == 0 {
+		fmt.Printf("No matches in %d transaction(s) scanned.\n", len(txs))
+		return nil
+	}
+	tbl.print()
+
+	fmt.Printf("\nMatched %d of %d transaction(s) scanned.\n", matched, len(txs))
+	if *category != "" {
+		fmt.Printf("Uncategorized: %d   Would change: %d   Unchanged: %d   Shadowed: %d\n", fresh, changes, same, shadowed)
+	} else if shadowed > 0 {
+		fmt.Printf("Shadowed by higher-priority rules: %d\n", shadowed)
+	}
+	return nil
+}
+
+func (a *App) cmdRuleExplain(args []string) error {
+	fs := flag.NewFlagSet("rule explain", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: pfm rule explain <tx-id>")
+	}
+
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction id %q", fs.Arg(0))
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	t, err := db.GetTransaction(conn, id)
+	if err != nil {
+		return err
+	}
+
+	ruleRows, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+	rules, err := compileRules(ruleRows)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transaction #%d: %s | %s | %s | %s\n",
+		t.ID, a.disp.date(t.PostedAt), t.Payee, a.disp.money(t.AmountBani), t.Category)
+	fmt.Printf("Matched text: %q\n", ruleText(t.Payee, t.Memo))
+	if t.PayeeRaw != "" && t.PayeeRaw != t.Payee {
+		fmt.Printf("          or: %q\n", ruleText(t.PayeeRaw, t.Memo))
+	}
+	fmt.Println()
+
+	if len(rules) == 0 {
+		fmt.Println("No rules.")
+		return nil
+	}
+
+	tbl := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "PRIORITY", Right: true},
+		tableCol{Title: "NAME", Max: 24, Min: 8},
+		tableCol{Title: "CATEGORY", Max: 18, Min: 8},
+		tableCol{Title: "RESULT"},
+	)
+	var winner *compiledRule
+	for _, r := range rules {
+		result := "no match"
+		if ruleMatches(r.Re, t.Payee, t.PayeeRaw, t.Memo) {
+			if winner == nil {
+				winner = &r
+				result = "WINNER"
+			} else {
+				result = fmt.Sprintf("match (shadowed by #%d)", winner.ID)
+			}
+		}
+		tbl.add(strconv.FormatInt(r.ID, 10), strconv.FormatInt(r.Priority, 10), r.Name, r.Category, result)
+	}
+	tbl.print()
+
+	fmt.Println()
+	if disabled := len(ruleRows) - len(rules); disabled > 0 {
+		fmt.Printf("%d disabled rule(s) not evaluated.\n", disabled)
+	}
+	if winner == nil {
+		fmt.Println("Result: no rule matches.")
+	} else {
+		fmt.Printf("Result: rule #%d (%s) -> %s\n", winner.ID, winner.Name, winner.Category)
+	}
+	return nil
+}
+
+func (a *App) cmdRuleLint(args []string) error {
+	fs := flag.NewFlagSet("rule lint", flag.ContinueOnError)
+	limit := fs.Int("limit", 100000, "Max transactions to scan")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	ruleRows, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+	rules, err := compileRules(ruleRows)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Println("No enabled rules.")
+		return nil
+	}
+
+	txs, err := db.SearchTransactions(conn, db.SearchFilter{Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	matches := map[int64]int{}
+	wins := map[int64]int{}
+	shadowers := map[int64]map[int64]bool{}
+	for _, t := range txs {
+		ms := matchAll(rules, t.Payee, t.PayeeRaw, t.Memo)
+		for i, r := range ms {
+			matches[r.ID]++
+			if i == 0 {
+				wins[r.ID]++
+				continue
+			}
+			if shadowers[r.ID] == nil {
+				shadowers[r.ID] = map[int64]bool{}
+			}
+			shadowers[r.ID][ms[0].ID] = true
+		}
+	}
+
+	fmt.Printf("Scanned %d transaction(s) against %d enabled rule(s).\n\n", len(txs), len(rules))
+	t := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "PRIORITY", Right: true},
+		tableCol{Title: "NAME", Max: 24, Min: 8},
+		tableCol{Title: "MATCHES", Right: true},
+		tableCol{Title: "WINS", Right: true},
+		tableCol{Title: "STATUS", Min: 12},
+	)
+	dead := 0
+	for _, r := range rules {
+		status := "ok"
+		switch {
+		case matches[r.ID] == 0:
+			status = "DEAD: never matches"
+		case wins[r.ID] == 0:
+			ids := make([]string, 0, len(shadowers[r.ID]))
+			for _, o := range rules {
+				if shadowers[r.ID][o.ID] {
+					ids = append(ids, fmt.Sprintf("#%d", o.ID))
+				}
+			}
+			status = "DEAD: always shadowed by " + strings.Join(ids, ", ")
+		}
+		if status != "ok" {
+			dead++
+		}
+		t.add(strconv.FormatInt(r.ID, 10), strconv.FormatInt(r.Priority, 10), r.Name, strconv.Itoa(matches[r.ID]), strconv.Itoa(wins[r.ID]), status)
+	}
+	t.print()
+
+	fmt.Printf("\nDead rules: %d\n", dead)
+	return nil
+}
+
+func (a *App) cmdRuleExport(args []string) error {
+	fs := flag.NewFlagSet("rule export", flag.ContinueOnError)
+	file := fs.String("file", "", "Output file (.json, .yaml or .yml) [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("missing required flag: --file")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	rules, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+	if err := writeRuleFile(*file, rules); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d rule(s) to %s\n", len(rules), *file)
+	return nil
+}
+
+func (a *App) cmdRuleImport(args []string) error {
+	fs := flag.NewFlagSet("rule import", flag.ContinueOnError)
+	file := fs.String("file", "", "Input file (.json, .yaml or .yml) [required]")
+	replace := fs.Bool("replace", false, "Delete all existing rules before importing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("missing required flag: --file")
+	}
+
+	rules, err := readRuleFile(*file)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	added, err := db.ImportRules(conn, rules, *replace)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Import complete: seen=%d added=%d skipped=%d\n", len(rules), added, len(rules)-added)
+	return nil
+}
+
+func (a *App) cmdCategorize(args []string) error {
+	fs := flag.NewFlagSet("categorize", flag.ContinueOnError)
+
+	month := fs.String("month", "", "Only categorize transactions in month (YYYY-MM)")
+	fromStr := fs.String("from", "", "Start date (YYYY-MM-DD)")
+	toStr := fs.String("to", "", "End date (YYYY-MM-DD)")
+	all := fs.Bool("all", false, "Process every matching transaction, ignoring --month/--from/--to")
+	recat := fs.Bool("recategorize", false, "Also re-run rules over rule- and import-categorized transactions (manual ones are kept)")
+	dry := fs.Bool("dry-run", false, "Show changes without writing to DB")
+	limit := fs.Int("limit", 500, "Max transactions to process")
+	suggest := fs.Bool("suggest", false, "Suggest categories learned from categorized history instead of applying rules")
+	accept := fs.Bool("accept", false, "With --suggest: apply suggestions at or above --threshold")
+	threshold := fs.Float64("threshold", 0.8, "With --accept: minimum confidence (0..1)")
+	propose := fs.Bool("propose-rules", false, "With --suggest: propose regex rules from clusters of similar payees")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*accept || *propose) && !*suggest {
+		return errors.New("--accept and --propose-rules require --suggest")
+	}
+	if *threshold < 0 || *threshold > 1 {
+		return errors.New("--threshold must be between 0 and 1")
+	}
+
+	var from *time.Time
+	var to *time.Time
 
-	if *minStr != "" {
-		v, err := ParseRON(*minStr)
+	if *fromStr != "" {
+		t, err := time.Parse("2006-01-02", *fromStr)
 		if err != nil {
-			return fmt.Errorf("invalid --min: %w", err)
+			return fmt.Errorf("invalid --from (expected YYYY-MM-DD): %w", err)
 		}
-		minBani = &v
+		from = &t
 	}
-	if *maxStr != "" {
-		v, err := ParseRON(*maxStr)
+	if *toStr != "" {
+		t, err := time.Parse("2006-01-02", *toStr)
 		if err != nil {
-			return fmt.Errorf("invalid --max: %w", err)
+			return fmt.Errorf("invalid --to (expected YYYY-MM-DD): %w", err)
 		}
-		maxBani = &v
+		to = &t
 	}
 
 	conn, err := db.Open(a.DBPath)
@@ -817,60 +1716,100 @@ func (a *App) cmdSearch(args []string) error {
 		return err
 	}
 	defer conn.Close()
-
 	if err := db.Migrate(conn, a.SchemaPath); err != nil {
 		return err
 	}
 
-	rows, err := db.SearchTransactions(conn, db.SearchFilter{
-		Month:    *month,
-		From:     from,
-		To:       to,
-		Category: *category,
-		Text:     *text,
-		Account:  *account,
-		MinBani:  minBani,
-		MaxBani:  maxBani,
-		Limit:    *limit,
-	})
+	filter := db.CategorizeFilter{Month: *month, From: from, To: to, Recategorize: *recat}
+	if *all {
+		filter = db.CategorizeFilter{Recategorize: *recat}
+	}
+
+	txs, err := db.ListTxForCategorize(conn, filter)
 	if err != nil {
 		return err
 	}
+	if *limit > 0 && len(txs) > *limit {
+		txs = txs[:*limit]
+	}
 
-	if len(rows) == 0 {
-		fmt.Println("No transactions found.")
+	if *suggest {
+		return a.categorizeSuggest(conn, txs, suggestOptions{
+			Accept:    *accept,
+			Threshold: *threshold,
+			Propose:   *propose,
+			DryRun:    *dry,
+		})
+	}
+
+	ruleRows, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+
+	rules, err := compileRules(ruleRows)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules. Add some with: pfm rule add ...")
+		return nil
+	}
+
+	if len(txs) == 0 {
+		if *recat {
+			fmt.Println("No transactions to re-categorize found.")
+		} else {
+			fmt.Println("No uncategorized transactions found.")
+		}
 		return nil
 	}
 
-	fmt.Printf("%-5s  %-10s  %-10s  %-18s  %-12s  %s\n", "ID", "DATE", "ACCOUNT", "PAYEE", "AMOUNT", "CATEGORY")
-	fmt.Printf("%s\n", "-----  ----------  ----------  ------------------  ------------  --------")
+	changed := 0
+	hits := map[int64]int{}
+	for _, t := range txs {
+		newCat, rule := matchCategory(rules, t.Payee, t.PayeeRaw, t.Memo)
+		if newCat == "" || newCat == t.Category {
+			continue
+		}
 
-	var total int64
-	for _, r := range rows {
-		total += r.AmountBani
-		payee := r.Payee
-		if len(payee) > 18 {
-			payee = payee[:18]
-		}
-		fmt.Printf("%-5d  %-10s  %-10s  %-18s  %-12s  %s\n",
-			r.ID,
-			r.PostedAt.Format("2006-01-02"),
-			trunc(r.Account, 10),
-			trunc(payee, 18),
-			FormatRON(r.AmountBani),
-			r.Category,
-		)
+		changed++
+		hits[rule.ID]++
+		if *dry {
+			fmt.Printf("[DRY] #%d %s %q %s -> %s (rule: %s)\n", t.ID, t.PostedAt, t.Payee, t.Category, newCat, rule.Name)
+			continue
+		}
+
+		if err := db.UpdateTxCategory(conn, t.ID, newCat, db.CategoryRule); err != nil {
+			return err
+		}
+		fmt.Printf("Updated #%d %s -> %s (rule: %s)\n", t.ID, t.Category, newCat, rule.Name)
+	}
+
+	if !*dry {
+		if err := db.RecordRuleHits(conn, hits); err != nil {
+			return err
+		}
+	}
+
+	if changed == 0 {
+		fmt.Println("No matches.")
+	} else if *dry {
+		fmt.Printf("Would update %d of %d transaction(s).\n", changed, len(txs))
+	} else {
+		fmt.Printf("Updated %d of %d transaction(s).\n", changed, len(txs))
 	}
+	printRuleCounts(rules, hits)
 
-	fmt.Printf("\nShown: %d   Net total: %s\n", len(rows), FormatRON(total))
 	return nil
 }
 
 func (a *App) cmdTUI(args []string) error {
 	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
 
-	month := fs.String("month", "", "Filter by month (YYYY-MM)")
-	limit := fs.Int("limit", 200, "Max rows to load")
+	month := fs.String("month", "", "Only load transactions of this month (YYYY-MM); also the starting month of the other tabs")
+	limit := fs.Int("limit", 200, "Transactions loaded per page")
+	inbox := fs.Bool("inbox", false, "Start in the inbox: categorize uncategorized transactions one by one")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -886,19 +1825,23 @@ func (a *App) cmdTUI(args []string) error {
 		return err
 	}
 
-	rows, err := db.SearchTransactions(conn, db.SearchFilter{
-		Month: *month,
-		Limit: *limit,
-	})
-	if err != nil {
+	if *limit <= 0 {
+		return errors.New("--limit must be positive")
+	}
+
+	start := *month
+	if start == "" {
+		start = time.Now().Format("2006-01")
+	} else if _, err := parseMonth(start); err != nil {
 		return err
 	}
-	if len(rows) == 0 {
-		fmt.Println("No transactions found.")
-		return nil
+
+	tab := tabDashboard
+	if *inbox {
+		tab = tabInbox
 	}
 
-	p := tea.NewProgram(newTUIModel(rows))
+	p := tea.NewProgram(newTUIModel(conn, a.disp, db.SearchFilter{Month: *month}, *limit, start, tab, a.Settings.WarnPct))
 	_, err = p.Run()
 	return err
 }
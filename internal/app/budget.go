@@ -0,0 +1,248 @@
+package app
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+func parseMonth(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q (expected YYYY-MM)", s)
+	}
+	return t, nil
+}
+
+// addMonths shifts a YYYY-MM month by n months.
+func addMonths(month string, n int) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, n, 0).Format("2006-01")
+}
+
+// budgetLine is one category's budget for a month after templates, one-off
+// overrides and rollover have been resolved.
+type budgetLine struct {
+	Category  string
+	LimitBani int64 // limit set for the month itself
+	CarryBani int64 // rolled over from the previous month; negative after overspending
+	SpentBani int64 // positive
+	Recurring bool  // limit comes from a template
+	Rollover  bool
+}
+
+// EffectiveBani is what may be spent this month: the limit plus carry-over.
+func (l budgetLine) EffectiveBani() int64 {
+	return l.LimitBani + l.CarryBani
+}
+
+// templateFor returns the template in effect for month, if any. tmpls must be
+// sorted by start month.
+func templateFor(tmpls []db.BudgetTemplate, month string) *db.BudgetTemplate {
+	var out *db.BudgetTemplate
+	for i := range tmpls {
+		if tmpls[i].StartMonth <= month {
+			out = &tmpls[i]
+		}
+	}
+	return out
+}
+
+// resolveBudgets returns the budget of every category that has one in month,
+// from one-off `budget set` rows and recurring templates, with rollover
+// carried forward month by month.
+func resolveBudgets(conn *sql.DB, month string) ([]budgetLine, error) {
+	templates, err := db.ListBudgetTemplates(conn)
+	if err != nil {
+		return nil, err
+	}
+	byCat := map[string][]db.BudgetTemplate{}
+	for _, t := range templates {
+		byCat[t.Category] = append(byCat[t.Category], t)
+	}
+
+	oneOff, err := db.ListBudgetsForMonth(conn, month)
+	if err != nil {
+		return nil, err
+	}
+
+	cats := map[string]bool{}
+	for _, b := range oneOff {
+		cats[b.Category] = true
+	}
+	for cat, tmpls := range byCat {
+		if t := templateFor(tmpls, month); t != nil && t.LimitBani > 0 {
+			cats[cat] = true
+		}
+	}
+
+	names := make([]string, 0, len(cats))
+	for cat := range cats {
+		names = append(names, cat)
+	}
+	sort.Strings(names)
+
+	out := make([]budgetLine, 0, len(names))
+	for _, cat := range names {
+		line, err := resolveCategoryBudget(conn, cat, byCat[cat], month)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, line)
+	}
+	return out, nil
+}
+
+func resolveCategoryBudget(conn *sql.DB, category string, tmpls []db.BudgetTemplate, month string) (budgetLine, error) {
+	overrides := map[string]int64{}
+	rows, err := db.ListBudgetsForCategory(conn, category)
+	if err != nil {
+		return budgetLine{}, err
+	}
+	for _, b := range rows {
+		overrides[b.Month] = b.LimitBani
+	}
+
+	start := month
+	if len(tmpls) > 0 && tmpls[0].StartMonth < start {
+		start = tmpls[0].StartMonth
+	}
+
+	spent, err := db.GetSpentByMonth(conn, category, start, month)
+	if err != nil {
+		return budgetLine{}, err
+	}
+
+	var line budgetLine
+	var carry int64
+	for m := start; m <= month; m = addMonths(m, 1) {
+		t := templateFor(tmpls, m)
+		line = budgetLine{Category: category}
+		if t != nil && t.LimitBani > 0 {
+			line.LimitBani = t.LimitBani
+			line.Recurring = true
+			line.Rollover = t.Rollover
+		}
+		if limit, ok := overrides[m]; ok {
+			line.LimitBani = limit
+		}
+		if line.Rollover {
+			line.CarryBani = carry
+		}
+		line.SpentBani = -spent[m]
+
+		carry = 0
+		if line.Rollover {
+			carry = line.EffectiveBani() - line.SpentBani
+		}
+	}
+	return line, nil
+}
+
+// annualLine tracks a yearly budget against year-to-date spending.
+type annualLine struct {
+	Category     string
+	LimitBani    int64
+	SpentBani    int64 // positive, Jan 1 through AsOf
+	ExpectedBani int64 // pro-rated share of the limit at AsOf
+	AsOf         time.Time
+}
+
+// resolveAnnualBudgets evaluates the yearly budgets of month's year as of the
+// end of month, or today if month is the current month.
+func resolveAnnualBudgets(conn *sql.DB, month string, today time.Time) ([]annualLine, error) {
+	start, err := parseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	year := start.Format("2006")
+
+	rows, err := db.ListAnnualBudgets(conn, year)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := start.AddDate(0, 1, -1)
+	if today.Before(asOf) {
+		asOf = today
+	}
+	yearStart := time.Date(start.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	daysInYear := yearStart.AddDate(1, 0, 0).Sub(yearStart).Hours() / 24
+	elapsed := float64(asOf.YearDay())
+	// A year that has not started yet has nothing spent or expected.
+	started := !asOf.Before(yearStart)
+	if !started {
+		asOf, elapsed = yearStart, 0
+	}
+
+	out := make([]annualLine, 0, len(rows))
+	for _, b := range rows {
+		var spent int64
+		if started {
+			if spent, err = db.GetSpentForRange(conn, b.Category, yearStart.Format("2006-01-02"), asOf.Format("2006-01-02")); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, annualLine{
+			Category:     b.Category,
+			LimitBani:    b.LimitBani,
+			SpentBani:    -spent,
+			ExpectedBani: int64(float64(b.LimitBani) * elapsed / daysInYear),
+			AsOf:         asOf,
+		})
+	}
+	return out, nil
+}
+
+func (a *App) cmdBudgetTemplates(args []string) error {
+	fs := flag.NewFlagSet("budget templates", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	templates, err := db.ListBudgetTemplates(conn)
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Println("No recurring budgets.")
+		return nil
+	}
+
+	tbl := newTable(
+		tableCol{Title: "CATEGORY", Max: 18, Min: 8},
+		tableCol{Title: "FROM"},
+		tableCol{Title: "LIMIT", Right: true},
+		tableCol{Title: "ROLLOVER"},
+	)
+	for _, t := range templates {
+		limit := a.disp.money(t.LimitBani)
+		if t.LimitBani == 0 {
+			limit = "(stopped)"
+		}
+		rollover := "no"
+		if t.Rollover {
+			rollover = "yes"
+		}
+		tbl.add(t.Category, t.StartMonth, limit, rollover)
+	}
+	tbl.print()
+	return nil
+}
@@ -16,9 +16,13 @@ type compiledRule struct {
 	Priority int64
 }
 
+// compileRules compiles the enabled rules, keeping their evaluation order.
 func compileRules(rows []db.RuleRow) ([]compiledRule, error) {
 	out := make([]compiledRule, 0, len(rows))
 	for _, r := range rows {
+		if !r.Enabled {
+			continue
+		}
 		re, err := regexp.Compile(r.Pattern)
 		if err != nil {
 			return nil, fmt.Errorf("rule %d (%s) bad regex: %w", r.ID, r.Name, err)
@@ -30,12 +34,101 @@ func compileRules(rows []db.RuleRow) ([]compiledRule, error) {
 	return out, nil
 }
 
-func matchCategory(rules []compiledRule, payee, memo string) (string, *compiledRule) {
-	text := strings.TrimSpace(payee + " " + memo)
+func ruleText(payee, memo string) string {
+	return strings.TrimSpace(payee + " " + memo)
+}
+
+// ruleMatches reports whether re matches the canonical payee or the payee
+// as the bank sent it, each followed by the memo.
+func ruleMatches(re *regexp.Regexp, payee, payeeRaw, memo string) bool {
+	if re.MatchString(ruleText(payee, memo)) {
+		return true
+	}
+	return payeeRaw != "" && payeeRaw != payee && re.MatchString(ruleText(payeeRaw, memo))
+}
+
+// matchAll returns every rule that matches, in evaluation order. The first
+// element (if any) is the rule that wins.
+func matchAll(rules []compiledRule, payee, payeeRaw, memo string) []compiledRule {
+	var out []compiledRule
+	for _, r := range rules {
+		if ruleMatches(r.Re, payee, payeeRaw, memo) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func matchCategory(rules []compiledRule, payee, payeeRaw, memo string) (string, *compiledRule) {
+	matches := matchAll(rules, payee, payeeRaw, memo)
+	if len(matches) == 0 {
+		return "", nil
+	}
+	return matches[0].Category, &matches[0]
+}
+
+// Outcomes of testing a candidate rule against a transaction it matches.
+const (
+	ruleShadowed  = "shadowed"  // an earlier rule matches and wins
+	ruleMatch     = "match"     // the candidate sets no category
+	ruleUnchanged = "unchanged" // the transaction already has the category
+	ruleSets      = "sets"      // the transaction is uncategorized
+	ruleChanges   = "changes"   // the transaction has another category
+)
+
+type ruleTestRow struct {
+	Tx      db.TxRow
+	Outcome string
+	Winner  *compiledRule // set when Outcome is ruleShadowed
+}
+
+// testRule runs cand against sample alongside the enabled rules and returns
+// the transactions it matches. Another rule wins when it runs first: a lower
+// priority, or the same priority and a lower id (a new rule, with id 0, gets
+// the largest id).
+func testRule(rules []compiledRule, sample []db.TxRow, cand compiledRule) []ruleTestRow {
+	var out []ruleTestRow
+	for _, t := range sample {
+		if !ruleMatches(cand.Re, t.Payee, t.PayeeRaw, t.Memo) {
+			continue
+		}
+		row := ruleTestRow{Tx: t}
+		for _, r := range matchAll(rules, t.Payee, t.PayeeRaw, t.Memo) {
+			if r.ID == cand.ID {
+				continue
+			}
+			if r.Priority < cand.Priority || (r.Priority == cand.Priority && (cand.ID == 0 || r.ID < cand.ID)) {
+				row.Winner = &r
+			}
+			break
+		}
+		switch {
+		case row.Winner != nil:
+			row.Outcome = ruleShadowed
+		case cand.Category == "":
+			row.Outcome = ruleMatch
+		case t.Category == cand.Category:
+			row.Outcome = ruleUnchanged
+		case t.Category == "uncategorized":
+			row.Outcome = ruleSets
+		default:
+			row.Outcome = ruleChanges
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// printRuleCounts prints how many transactions each rule categorized, in
+// evaluation order.
+func printRuleCounts(rules []compiledRule, hits map[int64]int) {
+	if len(hits) == 0 {
+		return
+	}
+	fmt.Println("\nPer rule:")
 	for _, r := range rules {
-		if r.Re.MatchString(text) {
-			return r.Category, &r
+		if n := hits[r.ID]; n > 0 {
+			fmt.Printf("  #%-4d %s -> %s %d\n", r.ID, cell(r.Name, 18), cell(r.Category, 14), n)
 		}
 	}
-	return "", nil
 }
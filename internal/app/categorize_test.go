@@ -0,0 +1,75 @@
+package app
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"example.com/pfm/internal/db"
+)
+
+func TestMatchCategory(t *testing.T) {
+	rules := []compiledRule{
+		{ID: 1, Category: "groceries", Re: regexp.MustCompile(`(?i)lidl`), Priority: 10},
+		{ID: 2, Category: "fuel", Re: regexp.MustCompile(`(?i)^pos 0342`), Priority: 20},
+		{ID: 3, Category: "gifts", Re: regexp.MustCompile(`(?i)cadou`), Priority: 30},
+	}
+	tests := []struct {
+		payee, raw, memo string
+		want             string
+	}{
+		{"Lidl", "POS 0342 LIDL BUCURESTI", "", "groceries"},
+		{"Petrom", "POS 0342 PETROM", "", "fuel"},
+		{"Petrom", "Petrom", "", ""},
+		{"Ana", "", "cadou nunta", "gifts"},
+		{"Ana", "TRANSFER ANA", "", ""},
+	}
+	for _, tt := range tests {
+		got, _ := matchCategory(rules, tt.payee, tt.raw, tt.memo)
+		if got != tt.want {
+			t.Errorf("matchCategory(%q, %q, %q) = %q, want %q", tt.payee, tt.raw, tt.memo, got, tt.want)
+		}
+	}
+}
+
+func TestTestRule(t *testing.T) {
+	rules := []compiledRule{
+		{ID: 1, Name: "lidl", Category: "groceries", Re: regexp.MustCompile(`(?i)lidl`), Priority: 10},
+		{ID: 5, Name: "shops", Category: "shopping", Re: regexp.MustCompile(`(?i)lidl|emag`), Priority: 50},
+	}
+	sample := []db.TxRow{
+		{ID: 1, Payee: "Lidl", Category: "uncategorized"},
+		{ID: 2, Payee: "eMAG", Category: "uncategorized"},
+		{ID: 3, Payee: "eMAG", Category: "electronics"},
+		{ID: 4, Payee: "eMAG", Category: "shopping"},
+		{ID: 5, Payee: "Kaufland", Category: "groceries"},
+	}
+	outcomes := func(cand compiledRule) map[int64]string {
+		out := map[int64]string{}
+		for _, r := range testRule(rules, sample, cand) {
+			out[r.Tx.ID] = r.Outcome
+			if (r.Outcome == ruleShadowed) != (r.Winner != nil) {
+				t.Errorf("tx #%d: outcome %s with winner %v", r.Tx.ID, r.Outcome, r.Winner)
+			}
+		}
+		return out
+	}
+	re := regexp.MustCompile(`(?i)lidl|emag`)
+
+	// A new rule loses ties to existing rules.
+	got := outcomes(compiledRule{Category: "shopping", Re: re, Priority: 10})
+	want := map[int64]string{1: ruleShadowed, 2: ruleSets, 3: ruleChanges, 4: ruleUnchanged}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("new rule: %v, want %v", got, want)
+	}
+	// An existing rule is not shadowed by itself, and wins ties with higher ids.
+	if got := outcomes(rules[1]); got[1] != ruleShadowed || got[2] != ruleSets {
+		t.Errorf("rule #5: %v", got)
+	}
+	if got := outcomes(compiledRule{ID: 3, Category: "x", Re: re, Priority: 10}); got[1] != ruleShadowed {
+		t.Errorf("rule #3 at rule #1's priority: %v", got)
+	}
+	if got := outcomes(compiledRule{Re: re, Priority: 1}); got[1] != ruleMatch || got[4] != ruleMatch {
+		t.Errorf("no category: %v", got)
+	}
+}
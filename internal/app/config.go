@@ -0,0 +1,626 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Settings are what commands fall back on when a flag is not given. They come
+// from the active profile in the config file, then its [defaults] table, then
+// the built-in values.
+type Settings struct {
+	DB         string
+	Account    string
+	Currency   string
+	WarnPct    int
+	DateFormat string // YYYY-MM-DD style, see dateLayout
+}
+
+// Setting names, as used in the config file and by `pfm config`.
+const (
+	settingDB         = "db"
+	settingAccount    = "account"
+	settingCurrency   = "currency"
+	settingWarnPct    = "warn_pct"
+	settingDateFormat = "date_format"
+)
+
+var settingNames = []string{settingDB, settingAccount, settingCurrency, settingWarnPct, settingDateFormat}
+
+// defaultWarnPct is the budget WARN threshold when none is configured.
+const defaultWarnPct = 80
+
+var builtinSettings = Settings{DB: "pfm.db", Account: "default", Currency: "RON", WarnPct: defaultWarnPct, DateFormat: "YYYY-MM-DD"}
+
+// Config is the parsed config file and the profile selected for this run.
+type Config struct {
+	Path    string
+	Values  map[string]tomlValue
+	Profile string
+	// From records where each setting came from, for `pfm config list`.
+	From map[string]string
+}
+
+// globalFlags are the options accepted before the command name.
+type globalFlags struct {
+	DB      string
+	Profile string
+	Config  string
+}
+
+// parseGlobalFlags takes --db, --profile and --config (as --name value or
+// --name=value) off the front of args.
+func parseGlobalFlags(args []string) (globalFlags, []string, error) {
+	var g globalFlags
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name, val, hasVal := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		var dst *string
+		switch name {
+		case "db":
+			dst = &g.DB
+		case "profile":
+			dst = &g.Profile
+		case "config":
+			dst = &g.Config
+		default:
+			return g, args, nil
+		}
+		if !hasVal {
+			if len(args) < 2 {
+				return g, nil, fmt.Errorf("--%s needs a value", name)
+			}
+			val, args = args[1], args[1:]
+		}
+		if val == "" {
+			return g, nil, fmt.Errorf("--%s needs a value", name)
+		}
+		*dst = val
+		args = args[1:]
+	}
+	return g, args, nil
+}
+
+// defaultConfigPath is $XDG_CONFIG_HOME/pfm/config.toml, falling back to
+// ~/.config/pfm/config.toml.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "pfm", "config.toml")
+}
+
+// loadConfig reads the config file; a missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	c := &Config{Path: path, Values: map[string]tomlValue{}, From: map[string]string{}}
+	if path == "" {
+		return c, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	defer f.Close()
+	if c.Values, err = parseTOML(f); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	for k, v := range c.Values {
+		if err := checkConfigValue(k, v); err != nil {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// save writes the change to key back to the file, editing it in place so
+// its comments and layout survive. A missing file starts out empty.
+func (c *Config) save(key string) error {
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	src, err := os.ReadFile(c.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config: %w", err)
+	}
+	var v *tomlValue
+	if val, ok := c.Values[key]; ok {
+		v = &val
+	}
+	tmp := c.Path + ".tmp"
+	if err := os.WriteFile(tmp, editTOML(src, key, v), 0o644); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	return os.Rename(tmp, c.Path)
+}
+
+// profiles lists the profile names defined in the file.
+func (c *Config) profiles() []string {
+	seen := map[string]bool{}
+	var out []string
+	for k := range c.Values {
+		if rest, ok := strings.CutPrefix(k, "profiles."); ok {
+			name, _, _ := strings.Cut(rest, ".")
+			if !seen[name] {
+				seen[name] = true
+				out = append(out, name)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (c *Config) hasProfile(name string) bool {
+	for _, p := range c.profiles() {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup is a setting of the active profile, else of [defaults].
+func (c *Config) lookup(name string) (tomlValue, string, bool) {
+	if c.Profile != "" {
+		if v, ok := c.Values["profiles."+c.Profile+"."+name]; ok {
+			return v, "profile " + c.Profile, true
+		}
+	}
+	if v, ok := c.Values["defaults."+name]; ok {
+		return v, "defaults", true
+	}
+	return tomlValue{}, "built-in", false
+}
+
+// settings resolves the settings of the active profile.
+func (c *Config) settings() Settings {
+	s := builtinSettings
+	for _, name := range settingNames {
+		v, from, ok := c.lookup(name)
+		c.From[name] = from
+		if !ok {
+			continue
+		}
+		switch name {
+		case settingDB:
+			s.DB = c.resolvePath(v.Text)
+		case settingAccount:
+			s.Account = v.Text
+		case settingCurrency:
+			s.Currency = v.Text
+		case settingWarnPct:
+			s.WarnPct, _ = strconv.Atoi(v.Text)
+		case settingDateFormat:
+			s.DateFormat = v.Text
+		}
+	}
+	return s
+}
+
+// resolvePath expands ~/ and makes a relative path relative to the config
+// file, so a config works from any directory.
+func (c *Config) resolvePath(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(p) || c.Path == "" {
+		return p
+	}
+	return filepath.Join(filepath.Dir(c.Path), p)
+}
+
+// configure applies the global flags, environment and config file to a, and
+// returns the arguments after the global flags.
+func (a *App) configure(args []string) ([]string, error) {
+	g, args, err := parseGlobalFlags(args)
+	if err != nil {
+		return nil, err
+	}
+
+	path := g.Config
+	if path == "" {
+		path = os.Getenv("PFM_CONFIG")
+	}
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Profile = g.Profile
+	if cfg.Profile == "" {
+		cfg.Profile = os.Getenv("PFM_PROFILE")
+	}
+	if cfg.Profile == "" {
+		cfg.Profile = cfg.Values["profile"].Text
+	}
+	// `pfm --profile NEW config set ...` is how a profile is created, so
+	// only the config command accepts an unknown one.
+	if cfg.Profile != "" && !cfg.hasProfile(cfg.Profile) && (len(args) == 0 || args[0] != "config") {
+		return nil, fmt.Errorf("profile %q is not defined in %s (see pfm config profiles)", cfg.Profile, cfg.Path)
+	}
+
+	s := cfg.settings()
+	switch {
+	case g.DB != "":
+		s.DB, cfg.From[settingDB] = g.DB, "--db"
+	case os.Getenv("PFM_DB") != "":
+		s.DB, cfg.From[settingDB] = os.Getenv("PFM_DB"), "PFM_DB"
+	}
+
+	layout, err := dateLayout(s.DateFormat)
+	if err != nil {
+		return nil, err
+	}
+	a.disp = display{DateLayout: layout, Currency: s.Currency}
+	a.Config = cfg
+	a.Settings = s
+	a.DBPath = s.DB
+	return args, nil
+}
+
+// display is how amounts and dates are shown, from the settings.
+type display struct {
+	DateLayout string // Go layout for transaction dates
+	Currency   string // shown after amounts
+}
+
+var builtinDisplay = display{DateLayout: "2006-01-02", Currency: builtinSettings.Currency}
+
+// date shows a transaction date in the configured date format.
+func (d display) date(t time.Time) string {
+	return t.Format(d.DateLayout)
+}
+
+// dateText is date for a date stored as YYYY-MM-DD text; any time of day
+// after it is kept, and text that is not a date is returned as is.
+func (d display) dateText(s string) string {
+	if len(s) < 10 {
+		return s
+	}
+	t, err := time.Parse("2006-01-02", s[:10])
+	if err != nil {
+		return s
+	}
+	return d.date(t) + s[10:]
+}
+
+// dateLayout converts a date format written with YYYY (or YY), MM and DD,
+// such as DD.MM.YYYY, into a Go time layout.
+func dateLayout(format string) (string, error) {
+	r := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02")
+	layout := r.Replace(format)
+	year := strings.Count(format, "YYYY") + strings.Count(strings.ReplaceAll(format, "YYYY", ""), "YY")
+	ok := year == 1 && strings.Count(format, "MM") == 1 && strings.Count(format, "DD") == 1
+	for _, c := range r.Replace(strings.NewReplacer("YYYY", "", "YY", "", "MM", "", "DD", "").Replace(format)) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			ok = false
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("invalid date format %q (use YYYY or YY, MM and DD once each, e.g. DD.MM.YYYY)", format)
+	}
+	return layout, nil
+}
+
+// Import profiles, [import.NAME] tables, describe a bank's CSV export.
+var importProfileKeys = []string{"account", "source", "delimiter", "date_format", "no_rules"}
+
+// importColumns are the CSV columns an import profile can rename.
+var importColumns = []string{"date", "payee", "amount", "memo", "category", "external_id", "tags"}
+
+// applyImportProfile fills opts from [import.NAME] and reports the profile's
+// no_rules.
+func (c *Config) applyImportProfile(name string, opts *ImportOptions) (noRules bool, err error) {
+	prefix := "import." + name + "."
+	found := false
+	for k, v := range c.Values {
+		key, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+		found = true
+		switch key {
+		case "account":
+			opts.Account = v.Text
+		case "source":
+			opts.Source = v.Text
+		case "delimiter":
+			opts.Comma = []rune(v.Text)[0]
+		case "date_format":
+			if opts.DateLayout, err = dateLayout(v.Text); err != nil {
+				return false, err
+			}
+		case "no_rules":
+			noRules = v.Text == "true"
+		default:
+			col := strings.TrimPrefix(key, "columns.")
+			if opts.Columns == nil {
+				opts.Columns = map[string]string{}
+			}
+			opts.Columns[col] = strings.ToLower(strings.TrimSpace(v.Text))
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("import profile %q is not defined in %s", name, c.Path)
+	}
+	return noRules, nil
+}
+
+// checkConfigValue validates one key of the config file and its value.
+func checkConfigValue(key string, v tomlValue) error {
+	parts := strings.Split(key, ".")
+	leaf := parts[len(parts)-1]
+	switch {
+	case key == "profile":
+		return wantString(key, v)
+	case len(parts) == 2 && parts[0] == "defaults", len(parts) == 3 && parts[0] == "profiles":
+		return checkSetting(key, leaf, v)
+	case len(parts) == 3 && parts[0] == "import" && containsString(importProfileKeys, leaf):
+		switch leaf {
+		case "no_rules":
+			if v.String || (v.Text != "true" && v.Text != "false") {
+				return fmt.Errorf("%s must be true or false", key)
+			}
+			return nil
+		case "delimiter":
+			if !v.String || len([]rune(v.Text)) != 1 {
+				return fmt.Errorf("%s must be a single character", key)
+			}
+			return nil
+		case "date_format":
+			if err := wantString(key, v); err != nil {
+				return err
+			}
+			_, err := dateLayout(v.Text)
+			return err
+		}
+		return wantString(key, v)
+	case len(parts) == 4 && parts[0] == "import" && parts[2] == "columns" && containsString(importColumns, leaf):
+		return wantString(key, v)
+	}
+	return fmt.Errorf("unknown key %s (see pfm config help)", key)
+}
+
+func checkSetting(key, name string, v tomlValue) error {
+	switch name {
+	case settingWarnPct:
+		n, err := strconv.Atoi(v.Text)
+		if v.String || err != nil || n <= 0 || n > 1000 {
+			return fmt.Errorf("%s must be a whole percent between 1 and 1000", key)
+		}
+		return nil
+	case settingDateFormat:
+		if err := wantString(key, v); err != nil {
+			return err
+		}
+		_, err := dateLayout(v.Text)
+		return err
+	case settingDB, settingAccount, settingCurrency:
+		return wantString(key, v)
+	}
+	return fmt.Errorf("unknown setting %s (expected %s)", key, strings.Join(settingNames, ", "))
+}
+
+func wantString(key string, v tomlValue) error {
+	if !v.String || strings.TrimSpace(v.Text) == "" {
+		return fmt.Errorf("%s must be a non-empty string", key)
+	}
+	return nil
+}
+
+// configKey maps a key given to `pfm config` to its place in the file: a bare
+// setting name means the active profile's setting, or [defaults] without one.
+func (c *Config) configKey(key string) string {
+	if containsString(settingNames, key) {
+		if c.Profile != "" {
+			return "profiles." + c.Profile + "." + key
+		}
+		return "defaults." + key
+	}
+	return key
+}
+
+// configValue types a value typed on the command line for key.
+func configValue(key, s string) tomlValue {
+	leaf := key[strings.LastIndexByte(key, '.')+1:]
+	if leaf == settingWarnPct || leaf == "no_rules" {
+		return tomlValue{Text: s}
+	}
+	return tomlValue{Text: s, String: true}
+}
+
+func (a *App) cmdConfig(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`pfm config - settings file and profiles
+
+Usage:
+  pfm config list                 Effective settings and every key in the file
+  pfm config get KEY
+  pfm config set KEY VALUE
+  pfm config unset KEY
+  pfm config profiles             Profiles defined in the file
+  pfm config path                 Where the file is
+
+Settings (per profile, falling back to [defaults]):
+  db            Database file; relative paths are relative to the config file
+  account       Default --account for add, import and invest
+  currency      Currency code shown after amounts (default RON)
+  warn_pct      Budget WARN threshold in percent (default 80)
+  date_format   How dates are shown, e.g. DD.MM.YYYY (default YYYY-MM-DD)
+
+KEY is a setting name (for the active profile, else [defaults]), profile
+(the profile used by default), or a full key such as profiles.business.db,
+import.ing.delimiter or import.ing.columns.date.
+
+Import profiles, used with pfm import --import-profile NAME:
+  import.NAME.account, .source, .delimiter, .date_format, .no_rules
+  import.NAME.columns.date|payee|amount|memo|category|external_id|tags
+
+Examples:
+  pfm config set date_format DD.MM.YYYY
+  pfm --profile business config set db ~/finance/business.db
+  pfm config set profile business
+  pfm --profile personal list
+  pfm config set import.ing.delimiter ";"
+  pfm config set import.ing.columns.date "Data tranzactiei"
+`)
+		return nil
+	}
+
+	c := a.Config
+	switch args[0] {
+	case "path":
+		fmt.Println(c.Path)
+		return nil
+
+	case "profiles":
+		names := c.profiles()
+		if len(names) == 0 {
+			fmt.Println("No profiles (define one with: pfm --profile NAME config set db PATH).")
+			return nil
+		}
+		t := newTable(tableCol{Title: ""}, tableCol{Title: "PROFILE"}, tableCol{Title: "DB", Min: 20})
+		for _, n := range names {
+			mark := ""
+			if n == c.Profile {
+				mark = "*"
+			}
+			db := "(defaults)"
+			if v, ok := c.Values["profiles."+n+"."+settingDB]; ok {
+				db = c.resolvePath(v.Text)
+			}
+			t.add(mark, n, db)
+		}
+		t.print()
+		return nil
+
+	case "list":
+		profile := c.Profile
+		if profile == "" {
+			profile = "(none)"
+		}
+		fmt.Printf("Config file: %s\nProfile: %s\n\n", c.Path, profile)
+		t := newTable(tableCol{Title: "SETTING"}, tableCol{Title: "VALUE", Min: 12}, tableCol{Title: "FROM"})
+		s := a.Settings
+		for _, name := range settingNames {
+			var v string
+			switch name {
+			case settingDB:
+				v = s.DB
+			case settingAccount:
+				v = s.Account
+			case settingCurrency:
+				v = s.Currency
+			case settingWarnPct:
+				v = strconv.Itoa(s.WarnPct)
+			case settingDateFormat:
+				v = s.DateFormat
+			}
+			t.add(name, v, c.From[name])
+		}
+		t.print()
+		if len(c.Values) > 0 {
+			fmt.Println()
+			keys := make([]string, 0, len(c.Values))
+			for k := range c.Values {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Printf("%s = %s\n", k, c.Values[k].literal())
+			}
+		}
+		return nil
+
+	case "get":
+		if len(args) != 2 {
+			return errors.New("usage: pfm config get KEY")
+		}
+		key := args[1]
+		if containsString(settingNames, key) {
+			s := a.Settings
+			switch key {
+			case settingDB:
+				fmt.Println(s.DB)
+			case settingAccount:
+				fmt.Println(s.Account)
+			case settingCurrency:
+				fmt.Println(s.Currency)
+			case settingWarnPct:
+				fmt.Println(s.WarnPct)
+			case settingDateFormat:
+				fmt.Println(s.DateFormat)
+			}
+			return nil
+		}
+		if key == "profile" {
+			fmt.Println(c.Profile)
+			return nil
+		}
+		v, ok := c.Values[key]
+		if !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+		fmt.Println(v.Text)
+		return nil
+
+	case "set":
+		if len(args) != 3 {
+			return errors.New("usage: pfm config set KEY VALUE")
+		}
+		key := c.configKey(args[1])
+		v := configValue(key, args[2])
+		if err := checkConfigValue(key, v); err != nil {
+			return err
+		}
+		if key == "profile" && !c.hasProfile(v.Text) {
+			return fmt.Errorf("profile %q is not defined (create it with: pfm --profile %s config set db PATH)", v.Text, v.Text)
+		}
+		if strings.HasPrefix(key, "profiles.") && !validTOMLBareKey(strings.Split(key, ".")[1]) {
+			return fmt.Errorf("invalid profile name %q (use letters, digits, - and _)", strings.Split(key, ".")[1])
+		}
+		c.Values[key] = v
+		if err := c.save(key); err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s in %s\n", key, v.literal(), c.Path)
+		return nil
+
+	case "unset":
+		if len(args) != 2 {
+			return errors.New("usage: pfm config unset KEY")
+		}
+		key := c.configKey(args[1])
+		if _, ok := c.Values[key]; !ok {
+			return fmt.Errorf("%s is not set", key)
+		}
+		delete(c.Values, key)
+		if err := c.save(key); err != nil {
+			return err
+		}
+		fmt.Printf("Unset %s in %s\n", key, c.Path)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown config subcommand: %q (try: pfm config help)", args[0])
+	}
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	"example.com/pfm/internal/db"
+)
+
+// envelope is one category in zero-based budgeting: money assigned to it,
+// plus whatever was left (or overspent) last month, minus this month's spending.
+type envelope struct {
+	Category     string
+	CarryBani    int64
+	AssignedBani int64
+	SpentBani    int64 // positive
+}
+
+func (e envelope) AvailableBani() int64 {
+	return e.CarryBani + e.AssignedBani - e.SpentBani
+}
+
+type envelopeMonth struct {
+	Month     string
+	Start     string // first month with an assignment
+	Income    int64  // income since Start
+	Assigned  int64  // net assigned since Start
+	Envelopes []envelope
+}
+
+// ToBeBudgeted is income not yet assigned to any envelope.
+func (m envelopeMonth) ToBeBudgeted() int64 {
+	return m.Income - m.Assigned
+}
+
+// resolveEnvelopes replays the assignment ledger from its first month up to
+// month, carrying each envelope's available balance (negative when
+// overspent) into the next month.
+func resolveEnvelopes(conn *sql.DB, month string) (envelopeMonth, error) {
+	out := envelopeMonth{Month: month}
+
+	totals, err := db.ListAssignmentTotals(conn, month)
+	if err != nil {
+		return out, err
+	}
+	if len(totals) == 0 {
+		return out, nil
+	}
+	out.Start = totals[0].Month
+
+	assigned := map[string]map[string]int64{}
+	for _, t := range totals {
+		if assigned[t.Category] == nil {
+			assigned[t.Category] = map[string]int64{}
+		}
+		assigned[t.Category][t.Month] += t.AssignedBani
+		out.Assigned += t.AssignedBani
+	}
+
+	// Sells, dividends and loan refunds land in categories pfm writes
+	// itself; they are not income to budget.
+	exclude := []string{categoryInvestments}
+	loans, err := db.ListLoans(conn)
+	if err != nil {
+		return out, err
+	}
+	for _, l := range loans {
+		exclude = append(exclude, l.Category)
+	}
+	out.Income, err = db.GetIncomeBetweenMonths(conn, out.Start, month, exclude)
+	if err != nil {
+		return out, err
+	}
+
+	cats := make([]string, 0, len(assigned))
+	for cat := range assigned {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+
+	for _, cat := range cats {
+		spent, err := db.GetSpentByMonth(conn, cat, out.Start, month)
+		if err != nil {
+			return out, err
+		}
+
+		var e envelope
+		var carry int64
+		for m := out.Start; m <= month; m = addMonths(m, 1) {
+			e = envelope{
+				Category:     cat,
+				CarryBani:    carry,
+				AssignedBani: assigned[cat][m],
+				SpentBani:    -spent[m],
+			}
+			carry = e.AvailableBani()
+		}
+		out.Envelopes = append(out.Envelopes, e)
+	}
+	return out, nil
+}
+
+func (a *App) cmdBudgetAssign(args []string) error {
+	fs := flag.NewFlagSet("budget assign", flag.ContinueOnError)
+
+	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	category := fs.String("category", "", "Envelope category [required]")
+	amountStr := fs.String("amount", "", "Amount in RON to assign; negative un-assigns [required]")
+	note := fs.String("note", "", "Note")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" || *category == "" || *amountStr == "" {
+		return errors.New("missing required flags: --month, --category, --amount")
+	}
+	if _, err := parseMonth(*month); err != nil {
+		return err
+	}
+
+	amountBani, err := ParseRON(*amountStr)
+	if err != nil {
+		return fmt.Errorf("invalid --amount: %w", err)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if err := db.AddAssignment(conn, *month, *category, amountBani, *note); err != nil {
+		return err
+	}
+
+	em, err := resolveEnvelopes(conn, *month)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Assigned %s to %s for %s. To be budgeted: %s\n",
+		a.disp.money(amountBani), *category, *month, a.disp.money(em.ToBeBudgeted()))
+	return nil
+}
+
+func (a *App) cmdBudgetMove(args []string) error {
+	fs := flag.NewFlagSet("budget move", flag.ContinueOnError)
+
+	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	from := fs.String("from", "", "Envelope to take money from [required]")
+	to := fs.String("to", "", "Envelope to move money to [required]")
+	amountStr := fs.String("amount", "", "Amount in RON [required]")
+	note := fs.String("note", "", "Note")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" || *from == "" || *to == "" || *amountStr == "" {
+		return errors.New("missing required flags: --month, --from, --to, --amount")
+	}
+	if *from == *to {
+		return errors.New("--from and --to must differ")
+	}
+	if _, err := parseMonth(*month); err != nil {
+		return err
+	}
+
+	amountBani, err := ParseRON(*amountStr)
+	if err != nil {
+		return fmt.Errorf("invalid --amount: %w", err)
+	}
+	if amountBani <= 0 {
+		return errors.New("--amount must be positive")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if err := db.MoveAssignment(conn, *month, *from, *to, amountBani, *note); err != nil {
+		return err
+	}
+
+	em, err := resolveEnvelopes(conn, *month)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s from %s to %s for %s.\n", a.disp.money(amountBani), *from, *to, *month)
+	for _, e := range em.Envelopes {
+		if e.Category == *from || e.Category == *to {
+			fmt.Printf("  %s available %s\n", cell(e.Category, 18), a.disp.money(e.AvailableBani()))
+		}
+	}
+	return nil
+}
+
+func (a *App) cmdBudgetEnvelopes(args []string) error {
+	fs := flag.NewFlagSet("budget envelopes", flag.ContinueOnError)
+	month := fs.String("month", "", "Month (YYYY-MM) [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *month == "" {
+		return errors.New("missing required flag: --month (YYYY-MM)")
+	}
+	if _, err := parseMonth(*month); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	em, err := resolveEnvelopes(conn, *month)
+	if err != nil {
+		return err
+	}
+	if len(em.Envelopes) == 0 {
+		fmt.Println("No envelopes yet. Assign money with: pfm budget assign ...")
+		return nil
+	}
+
+	fmt.Printf("Envelopes for %s\n\n", *month)
+	fmt.Printf("Income since %s:    %s\n", em.Start, a.disp.money(em.Income))
+	fmt.Printf("Assigned since %s:  %s\n", em.Start, a.disp.money(em.Assigned))
+	if tbb := em.ToBeBudgeted(); tbb < 0 {
+		fmt.Printf("To be budgeted:         %s (over-assigned)\n\n", a.disp.money(tbb))
+	} else {
+		fmt.Printf("To be budgeted:         %s\n\n", a.disp.money(tbb))
+	}
+
+	t := newTable(
+		tableCol{Title: "ENVELOPE", Max: 18, Min: 8},
+		tableCol{Title: "CARRY IN", Right: true},
+		tableCol{Title: "ASSIGNED", Right: true},
+		tableCol{Title: "SPENT", Right: true},
+		tableCol{Title: "AVAILABLE", Right: true},
+		tableCol{Title: ""},
+	)
+	for _, e := range em.Envelopes {
+		note := ""
+		if e.AvailableBani() < 0 {
+			note = "OVERSPENT"
+		}
+		t.add(
+			e.Category,
+			a.disp.money(e.CarryBani),
+			a.disp.money(e.AssignedBani),
+			a.disp.money(e.SpentBani),
+			a.disp.money(e.AvailableBani()),
+			note,
+		)
+	}
+	t.print()
+	return nil
+}
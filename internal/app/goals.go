@@ -0,0 +1,440 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// goalPaceWindow is how many recent months the contribution rate is averaged
+// over when projecting completion.
+const goalPaceWindow = 6
+
+// goalProgress is a goal evaluated as of a given day.
+type goalProgress struct {
+	Goal           db.GoalRow
+	SavedBani      int64
+	RemainingBani  int64
+	Pct            int
+	MonthsLeft     int   // until the target date, counting the current month; 0 when none or past
+	RequiredBani   int64 // per month to hit the target date
+	AvgMonthlyBani int64 // over the last goalPaceWindow months with history
+	ProjectedMonth string
+	Overdue        bool
+	ByMonth        map[string]int64
+}
+
+// OnTrack reports whether the recent contribution rate reaches the target in time.
+func (p goalProgress) OnTrack() bool {
+	if p.RemainingBani <= 0 {
+		return true
+	}
+	if p.Goal.TargetDate == "" || p.Overdue || p.ProjectedMonth == "" {
+		return false
+	}
+	return p.ProjectedMonth <= p.Goal.TargetDate[:7]
+}
+
+func computeGoalProgress(conn *sql.DB, g db.GoalRow, today time.Time) (goalProgress, error) {
+	p := goalProgress{Goal: g}
+
+	byMonth, err := db.GetGoalContributionsByMonth(conn, g)
+	if err != nil {
+		return p, err
+	}
+	p.ByMonth = byMonth
+
+	current := today.Format("2006-01")
+	first := current
+	for m, amount := range byMonth {
+		if m <= current {
+			p.SavedBani += amount
+		}
+		if m < first {
+			first = m
+		}
+	}
+
+	p.RemainingBani = g.TargetBani - p.SavedBani
+	if p.RemainingBani < 0 {
+		p.RemainingBani = 0
+	}
+	if g.TargetBani > 0 {
+		p.Pct = int(p.SavedBani * 100 / g.TargetBani)
+	}
+
+	if g.TargetDate != "" {
+		target := g.TargetDate[:7]
+		if target < current {
+			p.Overdue = p.RemainingBani > 0
+		} else {
+			p.MonthsLeft = monthsBetween(current, target) + 1
+			p.RequiredBani = ceilDiv(p.RemainingBani, int64(p.MonthsLeft))
+		}
+	}
+
+	window := monthsBetween(first, current) + 1
+	if window > goalPaceWindow {
+		window = goalPaceWindow
+	}
+	var recent int64
+	for i := 0; i < window; i++ {
+		recent += byMonth[addMonths(current, -i)]
+	}
+	p.AvgMonthlyBani = recent / int64(window)
+
+	switch {
+	case p.RemainingBani == 0:
+		p.ProjectedMonth = current
+	case p.AvgMonthlyBani > 0:
+		p.ProjectedMonth = addMonths(current, int(ceilDiv(p.RemainingBani, p.AvgMonthlyBani)))
+	}
+	return p, nil
+}
+
+// monthsBetween counts whole months from a to b (both YYYY-MM).
+func monthsBetween(a, b string) int {
+	ta, err1 := parseMonth(a)
+	tb, err2 := parseMonth(b)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return (tb.Year()-ta.Year())*12 + int(tb.Month()-ta.Month())
+}
+
+func ceilDiv(a, b int64) int64 {
+	if b <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
+
+// progressBar renders pct (clamped to 0..100) as a fixed-width bar.
+func progressBar(pct, width int) string {
+	if pct < 0 {
+		pct = 0
+	}
+	if pct > 100 {
+		pct = 100
+	}
+	filled := pct * width / 100
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+func (a *App) cmdGoal(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm goal <subcommand> [options]
+
+Subcommands:
+  add         Add a savings goal
+  list        List goals with progress
+  contribute  Record a contribution (negative to withdraw)
+  status      Progress, required monthly contribution and projected completion
+
+Examples:
+  pfm goal add --name emergency --target 20000 --by 2026-12-31
+  pfm goal add --name car --target 40000 --account savings
+  pfm goal add --name vacation --target 6000 --by 2026-07-01 --category savings:vacation
+  pfm goal contribute --name emergency --amount 1500
+  pfm goal status
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return a.cmdGoalAdd(args[1:])
+	case "list":
+		return a.cmdGoalList(args[1:])
+	case "contribute":
+		return a.cmdGoalContribute(args[1:])
+	case "status":
+		return a.cmdGoalStatus(args[1:])
+	default:
+		return fmt.Errorf("unknown goal subcommand: %q (try: pfm goal help)", args[0])
+	}
+}
+
+func (a *App) cmdGoalAdd(args []string) error {
+	fs := flag.NewFlagSet("goal add", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Goal name [required]")
+	targetStr := fs.String("target", "", "Target amount in RON [required]")
+	by := fs.String("by", "", "Target date (YYYY-MM-DD)")
+	account := fs.String("account", "", "Linked account: its transactions count as contributions")
+	category := fs.String("category", "", "Linked category: spending in it counts as contributions")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *targetStr == "" {
+		return errors.New("missing required flags: --name, --target")
+	}
+	if *account != "" && *category != "" {
+		return errors.New("link either --account or --category, not both")
+	}
+
+	target, err := ParseRON(*targetStr)
+	if err != nil {
+		return fmt.Errorf("invalid --target: %w", err)
+	}
+	if target <= 0 {
+		return errors.New("--target must be positive")
+	}
+	if *by != "" {
+		if _, err := time.Parse("2006-01-02", *by); err != nil {
+			return fmt.Errorf("invalid --by %q (expected YYYY-MM-DD)", *by)
+		}
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	id, err := db.AddGoal(conn, db.GoalRow{
+		Name:       *name,
+		TargetBani: target,
+		TargetDate: *by,
+		Account:    *account,
+		Category:   *category,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Goal #%d added: %s, %s\n", id, *name, a.disp.money(target))
+	return nil
+}
+
+func (a *App) cmdGoalContribute(args []string) error {
+	fs := flag.NewFlagSet("goal contribute", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Goal name [required]")
+	amountStr := fs.String("amount", "", "Amount in RON; negative to withdraw [required]")
+	date := fs.String("date", "", "Date (YYYY-MM-DD), default today")
+	note := fs.String("note", "", "Note")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *amountStr == "" {
+		return errors.New("missing required flags: --name, --amount")
+	}
+
+	amount, err := ParseRON(*amountStr)
+	if err != nil {
+		return fmt.Errorf("invalid --amount: %w", err)
+	}
+	if amount == 0 {
+		return errors.New("--amount must not be zero")
+	}
+	if *date == "" {
+		*date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", *date); err != nil {
+		return fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", *date)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	g, err := db.GetGoalByName(conn, *name)
+	if err != nil {
+		return err
+	}
+	if err := db.AddGoalContribution(conn, g.ID, *date, amount, *note); err != nil {
+		return err
+	}
+
+	p, err := computeGoalProgress(conn, g, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Contributed %s to %s. Saved %s of %s (%d%%)\n",
+		a.disp.money(amount), g.Name, a.disp.money(p.SavedBani), a.disp.money(g.TargetBani), p.Pct)
+	return nil
+}
+
+func loadGoalProgress(conn *sql.DB, today time.Time) ([]goalProgress, error) {
+	goals, err := db.ListGoals(conn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]goalProgress, 0, len(goals))
+	for _, g := range goals {
+		p, err := computeGoalProgress(conn, g, today)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func goalLink(g db.GoalRow) string {
+	switch {
+	case g.Account != "":
+		return "account:" + g.Account
+	case g.Category != "":
+		return "category:" + g.Category
+	}
+	return "-"
+}
+
+func (a *App) cmdGoalList(args []string) error {
+	fs := flag.NewFlagSet("goal list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	progress, err := loadGoalProgress(conn, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(progress) == 0 {
+		fmt.Println("No goals yet. Add one with: pfm goal add ...")
+		return nil
+	}
+
+	t := newTable(
+		tableCol{Title: "GOAL", Max: 18, Min: 8},
+		tableCol{Title: "TARGET", Right: true},
+		tableCol{Title: "SAVED", Right: true},
+		tableCol{Title: "DONE", Right: true},
+		tableCol{Title: "BY"},
+		tableCol{Title: "LINKED", Min: 8},
+	)
+	for _, p := range progress {
+		by := a.disp.dateText(p.Goal.TargetDate)
+		if by == "" {
+			by = "-"
+		}
+		t.add(
+			p.Goal.Name,
+			a.disp.money(p.Goal.TargetBani),
+			a.disp.money(p.SavedBani),
+			strconv.Itoa(p.Pct)+"%",
+			by,
+			goalLink(p.Goal),
+		)
+	}
+	t.print()
+	return nil
+}
+
+func (a *App) cmdGoalStatus(args []string) error {
+	fs := flag.NewFlagSet("goal status", flag.ContinueOnError)
+	name := fs.String("name", "", "Only this goal")
+	asOfStr := fs.String("as-of", "", "Evaluate as of this date (YYYY-MM-DD), default today")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	today := time.Now()
+	if *asOfStr != "" {
+		t, err := time.Parse("2006-01-02", *asOfStr)
+		if err != nil {
+			return fmt.Errorf("invalid --as-of %q (expected YYYY-MM-DD)", *asOfStr)
+		}
+		today = t
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	progress, err := loadGoalProgress(conn, today)
+	if err != nil {
+		return err
+	}
+	if len(progress) == 0 {
+		fmt.Println("No goals yet. Add one with: pfm goal add ...")
+		return nil
+	}
+
+	shown := 0
+	for _, p := range progress {
+		if *name != "" && p.Goal.Name != *name {
+			continue
+		}
+		if shown > 0 {
+			fmt.Println()
+		}
+		shown++
+		a.printGoalStatus(p)
+	}
+	if shown == 0 {
+		return fmt.Errorf("goal %q not found", *name)
+	}
+	return nil
+}
+
+func (a *App) printGoalStatus(p goalProgress) {
+	g := p.Goal
+	fmt.Printf("%s  %s %d%%\n", g.Name, progressBar(p.Pct, 30), p.Pct)
+	fmt.Printf("  Saved:       %s of %s (%s to go)\n", a.disp.money(p.SavedBani), a.disp.money(g.TargetBani), a.disp.money(p.RemainingBani))
+	if link := goalLink(g); link != "-" {
+		fmt.Printf("  Linked:      %s\n", link)
+	}
+
+	switch {
+	case p.RemainingBani == 0:
+		fmt.Println("  Reached.")
+		return
+	case g.TargetDate == "":
+		fmt.Println("  Target date: none")
+	case p.Overdue:
+		fmt.Printf("  Target date: %s (overdue)\n", a.disp.dateText(g.TargetDate))
+	default:
+		fmt.Printf("  Target date: %s (%d month(s) incl. this one)\n", a.disp.dateText(g.TargetDate), p.MonthsLeft)
+		fmt.Printf("  Required:    %s per month\n", a.disp.money(p.RequiredBani))
+	}
+
+	fmt.Printf("  Recent rate: %s per month\n", a.disp.money(p.AvgMonthlyBani))
+	if p.ProjectedMonth == "" {
+		fmt.Println("  Projected:   never at the recent rate")
+		return
+	}
+	verdict := ""
+	if g.TargetDate != "" {
+		verdict = "  (behind)"
+		if p.OnTrack() {
+			verdict = "  (on track)"
+		}
+	}
+	fmt.Printf("  Projected:   %s%s\n", p.ProjectedMonth, verdict)
+}
@@ -16,9 +16,94 @@ type ImportResult struct {
 	Seen     int
 	Inserted int
 	Ignored  int
+	// Categorized counts inserted rows categorized by a rule; RuleHits breaks
+	// it down by rule id.
+	Categorized int
+	RuleHits    map[int64]int
+	// LoanPayments counts inserted rows recorded as loan payments.
+	LoanPayments int
 }
 
-func ImportCSV(conn *sql.DB, path string, account string, source string) (ImportResult, error) {
+// ImportOptions controls how imported rows are stored.
+type ImportOptions struct {
+	Account string
+	Source  string
+	// Aliases map the raw bank payee to a canonical payee.
+	Aliases []compiledAlias
+	// Rules categorize rows that arrive uncategorized. Empty disables
+	// auto-categorization.
+	Rules []compiledRule
+	// Loans recognize loan payments, which are split into principal and
+	// interest and categorized under the loan.
+	Loans []compiledLoan
+
+	// CSV layout of an import profile. Comma 0 means ',', DateLayout ""
+	// means YYYY-MM-DD, and Columns maps a column pfm reads (date, payee,
+	// ...) to the header the bank uses for it.
+	Comma      rune
+	DateLayout string
+	Columns    map[string]string
+}
+
+// insertImported applies payee aliases, loans and rules to p, stores it with tags and
+// updates res.
+func insertImported(conn *sql.DB, opts ImportOptions, p db.AddTxParams, tags []string, res *ImportResult) error {
+	p.Account = opts.Account
+	p.Source = opts.Source
+	p.PayeeRaw = p.Payee
+	p.Payee = canonicalPayee(opts.Aliases, p.PayeeRaw)
+
+	var rule *compiledRule
+	var loan *compiledLoan
+	if p.AmountBani < 0 {
+		loan = matchLoan(opts.Loans, p.Payee, p.PayeeRaw, p.Memo)
+	}
+	if loan != nil {
+		p.Category = loan.Loan.Category
+		p.CategorySource = db.CategoryLoan
+	} else if p.Category == "uncategorized" {
+		p.CategorySource = db.CategoryNone
+		if cat, r := matchCategory(opts.Rules, p.Payee, p.PayeeRaw, p.Memo); r != nil {
+			p.Category = cat
+			p.CategorySource = db.CategoryRule
+			rule = r
+		}
+	} else {
+		p.CategorySource = db.CategoryImport
+	}
+
+	id, inserted, err := db.InsertTransaction(conn, p)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		res.Ignored++
+		return nil
+	}
+	for _, tag := range tags {
+		if _, err := db.TagTransactions(conn, tag, []int64{id}); err != nil {
+			return err
+		}
+	}
+
+	res.Inserted++
+	if loan != nil {
+		if _, err := recordLoanTx(conn, loan.Loan, id, p.PostedAt.Format("2006-01-02"), p.AmountBani); err != nil {
+			return err
+		}
+		res.LoanPayments++
+	}
+	if rule != nil {
+		res.Categorized++
+		if res.RuleHits == nil {
+			res.RuleHits = map[int64]int{}
+		}
+		res.RuleHits[rule.ID]++
+	}
+	return nil
+}
+
+func ImportCSV(conn *sql.DB, path string, opts ImportOptions) (ImportResult, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return ImportResult{}, err
@@ -27,6 +112,13 @@ func ImportCSV(conn *sql.DB, path string, account string, source string) (Import
 
 	r := csv.NewReader(f)
 	r.FieldsPerRecord = -1
+	if opts.Comma != 0 {
+		r.Comma = opts.Comma
+	}
+	dateLayout := "2006-01-02"
+	if opts.DateLayout != "" {
+		dateLayout = opts.DateLayout
+	}
 
 	header, err := r.Read()
 	if err != nil {
@@ -37,6 +129,13 @@ func ImportCSV(conn *sql.DB, path string, account string, source string) (Import
 	for i, h := range header {
 		col[strings.ToLower(strings.TrimSpace(h))] = i
 	}
+	for name, h := range opts.Columns {
+		i, ok := col[h]
+		if !ok {
+			return ImportResult{}, fmt.Errorf("import profile maps %s to column %q, which the file does not have", name, h)
+		}
+		col[name] = i
+	}
 
 	need := []string{"date", "payee", "amount"}
 	for _, n := range need {
@@ -71,6 +170,7 @@ func ImportCSV(conn *sql.DB, path string, account string, source string) (Import
 		category := get(row, "category")
 		memo := get(row, "memo")
 		external := get(row, "external_id")
+		tags := splitTagList(get(row, "tags"))
 
 		if category == "" {
 			category = "uncategorized"
@@ -79,7 +179,7 @@ func ImportCSV(conn *sql.DB, path string, account string, source string) (Import
 			memo = ""
 		}
 
-		postedAt, err := time.Parse("2006-01-02", dateStr)
+		postedAt, err := time.Parse(dateLayout, dateStr)
 		if err != nil {
 			return res, fmt.Errorf("row %d: invalid date %q: %w", res.Seen+1, dateStr, err)
 		}
@@ -94,25 +194,17 @@ func ImportCSV(conn *sql.DB, path string, account string, source string) (Import
 			externalID = &external
 		}
 
-		_, inserted, err := db.InsertTransaction(conn, db.AddTxParams{
+		err = insertImported(conn, opts, db.AddTxParams{
 			PostedAt:   postedAt,
 			Payee:      payee,
 			Memo:       memo,
 			AmountBani: amountBani,
 			Category:   category,
-			Account:    account,
-			Source:     source,
 			ExternalID: externalID,
-		})
+		}, tags, &res)
 		if err != nil {
 			return res, fmt.Errorf("row %d: insert: %w", res.Seen+1, err)
 		}
-
-		if inserted {
-			res.Inserted++
-		} else {
-			res.Ignored++
-		}
 	}
 
 	return res, nil
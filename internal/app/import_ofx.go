@@ -12,7 +12,7 @@ import (
 	"github.com/aclindsa/ofxgo"
 )
 
-func ImportOFX(conn *sql.DB, path string, account string, source string) (ImportResult, error) {
+func ImportOFX(conn *sql.DB, path string, opts ImportOptions) (ImportResult, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return ImportResult{}, err
@@ -65,25 +65,17 @@ func ImportOFX(conn *sql.DB, path string, account string, source string) (Import
 				return out, fmt.Errorf("row %d: invalid amount %q: %w", out.Seen+1, trn.TrnAmt.String(), err)
 			}
 
-			_, inserted, err := db.InsertTransaction(conn, db.AddTxParams{
+			err = insertImported(conn, opts, db.AddTxParams{
 				PostedAt:   postedAt,
 				Payee:      payee,
 				Memo:       memo,
 				AmountBani: amountBani,
 				Category:   "uncategorized",
-				Account:    account,
-				Source:     source,
 				ExternalID: externalID,
-			})
+			}, nil, &out)
 			if err != nil {
 				return out, fmt.Errorf("row %d: insert: %w", out.Seen+1, err)
 			}
-
-			if inserted {
-				out.Inserted++
-			} else {
-				out.Ignored++
-			}
 		}
 	}
 
@@ -115,25 +107,17 @@ func ImportOFX(conn *sql.DB, path string, account string, source string) (Import
 				return out, fmt.Errorf("row %d: invalid amount %q: %w", out.Seen+1, trn.TrnAmt.String(), err)
 			}
 
-			_, inserted, err := db.InsertTransaction(conn, db.AddTxParams{
+			err = insertImported(conn, opts, db.AddTxParams{
 				PostedAt:   postedAt,
 				Payee:      payee,
 				Memo:       memo,
 				AmountBani: amountBani,
 				Category:   "uncategorized",
-				Account:    account,
-				Source:     source,
 				ExternalID: externalID,
-			})
+			}, nil, &out)
 			if err != nil {
 				return out, fmt.Errorf("row %d: insert: %w", out.Seen+1, err)
 			}
-
-			if inserted {
-				out.Inserted++
-			} else {
-				out.Ignored++
-			}
 		}
 	}
 
@@ -0,0 +1,796 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// Lot matching methods for sells.
+const (
+	lotFIFO    = "fifo"
+	lotAverage = "average"
+)
+
+// Categories of the cash legs written to transactions.
+const (
+	categoryInvestments = "investments"
+	categoryDividends   = "dividends"
+)
+
+// lot is an open position from one buy (or, with average cost, the pooled
+// position).
+type lot struct {
+	Date     string
+	QtyMicro int64
+	CostBani int64
+}
+
+type holding struct {
+	SecurityID   int64
+	Symbol       string
+	QtyMicro     int64
+	CostBani     int64
+	RealizedBani int64
+	DividendBani int64
+	Lots         []lot
+
+	// RealizedByMonth splits RealizedBani by the YYYY-MM of the sell.
+	RealizedByMonth map[string]int64
+}
+
+// buildHoldings replays trades in order. Sells consume lots first-in
+// first-out, or at the pooled average cost; realized gain is proceeds net of
+// fees minus the cost removed.
+func buildHoldings(trades []db.TradeRow, method string) ([]*holding, error) {
+	var order []*holding
+	bySec := map[int64]*holding{}
+
+	for _, t := range trades {
+		h := bySec[t.SecurityID]
+		if h == nil {
+			h = &holding{SecurityID: t.SecurityID, Symbol: t.Symbol, RealizedByMonth: map[string]int64{}}
+			bySec[t.SecurityID] = h
+			order = append(order, h)
+		}
+
+		switch t.Kind {
+		case db.TradeBuy:
+			cost := -t.AmountBani
+			h.QtyMicro += t.QtyMicro
+			h.CostBani += cost
+			if method == lotAverage && len(h.Lots) > 0 {
+				h.Lots[0].QtyMicro += t.QtyMicro
+				h.Lots[0].CostBani += cost
+			} else {
+				h.Lots = append(h.Lots, lot{Date: t.TradeDate, QtyMicro: t.QtyMicro, CostBani: cost})
+			}
+
+		case db.TradeSell:
+			if t.QtyMicro > h.QtyMicro {
+				return nil, fmt.Errorf("%s: selling %s on %s but only %s held",
+					t.Symbol, FormatQty(t.QtyMicro), t.TradeDate, FormatQty(h.QtyMicro))
+			}
+			var removed int64
+			left := t.QtyMicro
+			for left > 0 && len(h.Lots) > 0 {
+				l := &h.Lots[0]
+				take := left
+				if take > l.QtyMicro {
+					take = l.QtyMicro
+				}
+				cost := l.CostBani
+				if take < l.QtyMicro {
+					cost = l.CostBani * take / l.QtyMicro
+				}
+				l.QtyMicro -= take
+				l.CostBani -= cost
+				removed += cost
+				left -= take
+				if l.QtyMicro == 0 {
+					h.Lots = h.Lots[1:]
+				}
+			}
+			h.QtyMicro -= t.QtyMicro
+			h.CostBani -= removed
+			h.RealizedBani += t.AmountBani - removed
+			h.RealizedByMonth[t.TradeDate[:7]] += t.AmountBani - removed
+
+		case db.TradeDividend:
+			h.DividendBani += t.AmountBani
+		}
+	}
+	return order, nil
+}
+
+// portfolioValueAsOf is the market value of all holdings on date, using the
+// latest price on or before it and cost basis where no price is known.
+func portfolioValueAsOf(conn *sql.DB, date string) (int64, error) {
+	trades, err := db.ListTrades(conn, 0, date)
+	if err != nil {
+		return 0, err
+	}
+	holdings, err := buildHoldings(trades, lotFIFO)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, h := range holdings {
+		if h.QtyMicro == 0 {
+			continue
+		}
+		price, _, ok, err := db.GetPriceAsOf(conn, h.SecurityID, date)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			total += valueOf(h.QtyMicro, price)
+		} else {
+			total += h.CostBani
+		}
+	}
+	return total, nil
+}
+
+func parseLotMethod(s string) (string, error) {
+	switch s {
+	case lotFIFO, lotAverage:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid --method %q (use fifo or average)", s)
+}
+
+func (a *App) cmdInvest(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm invest <subcommand> [options]
+
+Subcommands:
+  buy       Record a buy (cash leg goes to the account's transactions)
+  sell      Record a sell
+  dividend  Record a dividend
+  price     Set a security's price for a date
+  prices    Import prices from CSV (symbol,date,price)
+  trades    List trades
+  delete    Delete a trade and its cash leg by id
+  lots      Open lots of a security with unrealized gains
+
+Examples:
+  pfm invest buy --symbol VWCE --qty 10 --price 520.50 --fee 5 --account broker
+  pfm invest sell --symbol VWCE --qty 4 --price 560 --account broker
+  pfm invest dividend --symbol TLV --amount 120.40 --account broker
+  pfm invest prices --file prices.csv
+  pfm invest delete --id 12
+  pfm report portfolio --method average
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "buy", "sell":
+		return a.cmdInvestTrade(args[0], args[1:])
+	case "dividend":
+		return a.cmdInvestDividend(args[1:])
+	case "price":
+		return a.cmdInvestPrice(args[1:])
+	case "prices":
+		return a.cmdInvestPrices(args[1:])
+	case "trades":
+		return a.cmdInvestTrades(args[1:])
+	case "delete":
+		return a.cmdInvestDelete(args[1:])
+	case "lots":
+		return a.cmdInvestLots(args[1:])
+	default:
+		return fmt.Errorf("unknown invest subcommand: %q (try: pfm invest help)", args[0])
+	}
+}
+
+func parseTradeDate(s string) (time.Time, error) {
+	if s == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
+
+// recordTrade writes the cash leg to transactions and links the trade to it.
+func recordTrade(conn *sql.DB, t db.TradeRow, memo, category string) error {
+	date, _ := time.Parse("2006-01-02", t.TradeDate)
+	_, err := db.RecordTrade(conn, db.AddTxParams{
+		PostedAt:   date,
+		Payee:      t.Symbol,
+		Memo:       memo,
+		AmountBani: t.AmountBani,
+		Category:   category,
+		Account:    t.Account,
+		Source:     "invest",
+	}, t)
+	return err
+}
+
+// checkSell replays every trade of the security with the sell t placed at
+// its date, so a backdated sell cannot leave a later one short.
+func checkSell(conn *sql.DB, t db.TradeRow) error {
+	trades, err := db.ListTrades(conn, t.SecurityID, "9999-12-31")
+	if err != nil {
+		return err
+	}
+	i := sort.Search(len(trades), func(i int) bool { return trades[i].TradeDate > t.TradeDate })
+	trades = append(trades[:i], append([]db.TradeRow{t}, trades[i:]...)...)
+	_, err = buildHoldings(trades, lotFIFO)
+	return err
+}
+
+func (a *App) cmdInvestTrade(kind string, args []string) error {
+	fs := flag.NewFlagSet("invest "+kind, flag.ContinueOnError)
+
+	symbol := fs.String("symbol", "", "Security symbol [required]")
+	name := fs.String("name", "", "Security name (stored on first use)")
+	qtyStr := fs.String("qty", "", "Quantity, up to 6 decimals [required]")
+	priceStr := fs.String("price", "", "Price per unit in RON [required]")
+	feeStr := fs.String("fee", "0", "Fee in RON")
+	dateStr := fs.String("date", "", "Trade date (YYYY-MM-DD), default today")
+	account := fs.String("account", a.Settings.Account, "Account for the cash leg")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" || *qtyStr == "" || *priceStr == "" {
+		return errors.New("missing required flags: --symbol, --qty, --price")
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	qty, err := ParseQty(*qtyStr)
+	if err != nil {
+		return fmt.Errorf("invalid --qty: %w", err)
+	}
+	if qty == 0 {
+		return errors.New("--qty must be positive")
+	}
+	price, err := ParseRON(*priceStr)
+	if err != nil || price < 0 {
+		return fmt.Errorf("invalid --price %q", *priceStr)
+	}
+	fee, err := ParseRON(*feeStr)
+	if err != nil || fee < 0 {
+		return fmt.Errorf("invalid --fee %q", *feeStr)
+	}
+	date, err := parseTradeDate(*dateStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	var secID int64
+	if kind == db.TradeBuy {
+		secID, err = db.AddSecurity(conn, *symbol, *name)
+	} else {
+		secID, err = db.GetSecurityID(conn, *symbol)
+	}
+	if err != nil {
+		return err
+	}
+
+	gross := valueOf(qty, price)
+	t := db.TradeRow{
+		SecurityID: secID,
+		Symbol:     *symbol,
+		Account:    *account,
+		TradeDate:  date.Format("2006-01-02"),
+		Kind:       kind,
+		QtyMicro:   qty,
+		PriceBani:  price,
+		FeeBani:    fee,
+		AmountBani: -(gross + fee),
+	}
+	if kind == db.TradeSell {
+		t.AmountBani = gross - fee
+		if err := checkSell(conn, t); err != nil {
+			return err
+		}
+	}
+
+	memo := fmt.Sprintf("%s %s @ %s", kind, FormatQty(qty), a.disp.money(price))
+	if fee > 0 {
+		memo += fmt.Sprintf(" (fee %s)", a.disp.money(fee))
+	}
+	if err := recordTrade(conn, t, memo, categoryInvestments); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s on %s: %s to %s\n",
+		strings.ToUpper(kind[:1])+kind[1:], FormatQty(qty), *symbol, a.disp.dateText(t.TradeDate), a.disp.money(t.AmountBani), *account)
+	return nil
+}
+
+func (a *App) cmdInvestDividend(args []string) error {
+	fs := flag.NewFlagSet("invest dividend", flag.ContinueOnError)
+
+	symbol := fs.String("symbol", "", "Security symbol [required]")
+	amountStr := fs.String("amount", "", "Net dividend received in RON [required]")
+	dateStr := fs.String("date", "", "Payment date (YYYY-MM-DD), default today")
+	account := fs.String("account", a.Settings.Account, "Account for the cash leg")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" || *amountStr == "" {
+		return errors.New("missing required flags: --symbol, --amount")
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	amount, err := ParseRON(*amountStr)
+	if err != nil || amount <= 0 {
+		return fmt.Errorf("invalid --amount %q", *amountStr)
+	}
+	date, err := parseTradeDate(*dateStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	secID, err := db.GetSecurityID(conn, *symbol)
+	if err != nil {
+		return err
+	}
+	t := db.TradeRow{
+		SecurityID: secID,
+		Symbol:     *symbol,
+		Account:    *account,
+		TradeDate:  date.Format("2006-01-02"),
+		Kind:       db.TradeDividend,
+		AmountBani: amount,
+	}
+	if err := recordTrade(conn, t, "dividend", categoryDividends); err != nil {
+		return err
+	}
+	fmt.Printf("Dividend %s from %s on %s to %s\n", a.disp.money(amount), *symbol, a.disp.dateText(t.TradeDate), *account)
+	return nil
+}
+
+func (a *App) cmdInvestPrice(args []string) error {
+	fs := flag.NewFlagSet("invest price", flag.ContinueOnError)
+
+	symbol := fs.String("symbol", "", "Security symbol [required]")
+	priceStr := fs.String("price", "", "Price per unit in RON [required]")
+	dateStr := fs.String("date", "", "Price date (YYYY-MM-DD), default today")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" || *priceStr == "" {
+		return errors.New("missing required flags: --symbol, --price")
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	price, err := ParseRON(*priceStr)
+	if err != nil || price < 0 {
+		return fmt.Errorf("invalid --price %q", *priceStr)
+	}
+	date, err := parseTradeDate(*dateStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	secID, err := db.AddSecurity(conn, *symbol, "")
+	if err != nil {
+		return err
+	}
+	if err := db.UpsertPrice(conn, secID, date.Format("2006-01-02"), price); err != nil {
+		return err
+	}
+	fmt.Printf("Price set: %s on %s = %s\n", *symbol, a.disp.date(date), a.disp.money(price))
+	return nil
+}
+
+func (a *App) cmdInvestPrices(args []string) error {
+	fs := flag.NewFlagSet("invest prices", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV file with symbol,date,price columns [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("missing required flag: --file")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, n := range []string{"symbol", "date", "price"} {
+		if _, ok := col[n]; !ok {
+			return fmt.Errorf("missing required column %q", n)
+		}
+	}
+	get := func(row []string, name string) string {
+		i := col[name]
+		if i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	ids := map[string]int64{}
+	line, imported := 1, 0
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		symbol := strings.ToUpper(get(row, "symbol"))
+		if symbol == "" {
+			return fmt.Errorf("line %d: empty symbol", line)
+		}
+		date := get(row, "date")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("line %d: invalid date %q (expected YYYY-MM-DD)", line, date)
+		}
+		price, err := ParseRON(get(row, "price"))
+		if err != nil || price < 0 {
+			return fmt.Errorf("line %d: invalid price %q", line, get(row, "price"))
+		}
+
+		id, ok := ids[symbol]
+		if !ok {
+			id, err = db.AddSecurity(conn, symbol, "")
+			if err != nil {
+				return err
+			}
+			ids[symbol] = id
+		}
+		if err := db.UpsertPrice(conn, id, date, price); err != nil {
+			return err
+		}
+		imported++
+	}
+
+	fmt.Printf("Imported %d price(s) for %d securit(ies).\n", imported, len(ids))
+	return nil
+}
+
+func (a *App) cmdInvestTrades(args []string) error {
+	fs := flag.NewFlagSet("invest trades", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "Only this security")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	var secID int64
+	if *symbol != "" {
+		secID, err = db.GetSecurityID(conn, strings.ToUpper(*symbol))
+		if err != nil {
+			return err
+		}
+	}
+	trades, err := db.ListTrades(conn, secID, "9999-12-31")
+	if err != nil {
+		return err
+	}
+	if len(trades) == 0 {
+		fmt.Println("No trades.")
+		return nil
+	}
+
+	tbl := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "DATE"},
+		tableCol{Title: "SYMBOL", Max: 10, Min: 6},
+		tableCol{Title: "KIND"},
+		tableCol{Title: "QTY", Right: true},
+		tableCol{Title: "PRICE", Right: true},
+		tableCol{Title: "FEE", Right: true},
+		tableCol{Title: "CASH", Right: true},
+		tableCol{Title: "ACCOUNT", Max: 14, Min: 7},
+	)
+	for _, t := range trades {
+		qty, price := "-", "-"
+		if t.Kind != db.TradeDividend {
+			qty, price = FormatQty(t.QtyMicro), a.disp.money(t.PriceBani)
+		}
+		tbl.add(strconv.FormatInt(t.ID, 10), a.disp.dateText(t.TradeDate), t.Symbol, t.Kind, qty, price, a.disp.money(t.FeeBani), a.disp.money(t.AmountBani), t.Account)
+	}
+	tbl.print()
+	return nil
+}
+
+func (a *App) cmdInvestDelete(args []string) error {
+	fs := flag.NewFlagSet("invest delete", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "Trade id, as shown by invest trades [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	trades, err := db.ListTrades(conn, 0, "9999-12-31")
+	if err != nil {
+		return err
+	}
+	var found db.TradeRow
+	for _, t := range trades {
+		if t.ID == *id {
+			found = t
+		}
+	}
+	if found.ID == 0 {
+		return fmt.Errorf("trade #%d not found", *id)
+	}
+	// Deleting a buy must not leave a later sell of the security short.
+	var rest []db.TradeRow
+	for _, t := range trades {
+		if t.SecurityID == found.SecurityID && t.ID != found.ID {
+			rest = append(rest, t)
+		}
+	}
+	if _, err := buildHoldings(rest, lotFIFO); err != nil {
+		return fmt.Errorf("cannot delete trade #%d: %w", *id, err)
+	}
+	if err := db.DeleteTrade(conn, *id); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted trade #%d: %s %s on %s\n", *id, found.Kind, found.Symbol, a.disp.dateText(found.TradeDate))
+	return nil
+}
+
+func (a *App) cmdInvestLots(args []string) error {
+	fs := flag.NewFlagSet("invest lots", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "Security symbol [required]")
+	methodStr := fs.String("method", lotFIFO, "Lot method: fifo or average")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *symbol == "" {
+		return errors.New("missing required flag: --symbol")
+	}
+	method, err := parseLotMethod(*methodStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	secID, err := db.GetSecurityID(conn, strings.ToUpper(*symbol))
+	if err != nil {
+		return err
+	}
+	today := time.Now().Format("2006-01-02")
+	trades, err := db.ListTrades(conn, secID, today)
+	if err != nil {
+		return err
+	}
+	holdings, err := buildHoldings(trades, method)
+	if err != nil {
+		return err
+	}
+	if len(holdings) == 0 || len(holdings[0].Lots) == 0 {
+		fmt.Println("No open lots.")
+		return nil
+	}
+	h := holdings[0]
+
+	price, pricedAt, havePrice, err := db.GetPriceAsOf(conn, secID, today)
+	if err != nil {
+		return err
+	}
+	if havePrice {
+		fmt.Printf("%s at %s (%s), %s method\n\n", h.Symbol, a.disp.money(price), a.disp.dateText(pricedAt), method)
+	} else {
+		fmt.Printf("%s, no price yet, %s method\n\n", h.Symbol, method)
+	}
+
+	t := newTable(
+		tableCol{Title: "ACQUIRED"},
+		tableCol{Title: "QTY", Right: true},
+		tableCol{Title: "COST", Right: true},
+		tableCol{Title: "COST/UNIT", Right: true},
+		tableCol{Title: "UNREALIZED", Right: true},
+	)
+	for _, l := range h.Lots {
+		unrealized := "-"
+		if havePrice {
+			unrealized = a.disp.money(valueOf(l.QtyMicro, price) - l.CostBani)
+		}
+		t.add(a.disp.dateText(l.Date), FormatQty(l.QtyMicro), a.disp.money(l.CostBani), a.disp.money(l.CostBani*qtyScale/l.QtyMicro), unrealized)
+	}
+	t.print()
+	return nil
+}
+
+func (a *App) cmdReportPortfolio(args []string) error {
+	fs := flag.NewFlagSet("report portfolio", flag.ContinueOnError)
+	asOfStr := fs.String("as-of", "", "Value as of this date (YYYY-MM-DD), default today")
+	methodStr := fs.String("method", lotFIFO, "Lot method for cost basis: fifo or average")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	method, err := parseLotMethod(*methodStr)
+	if err != nil {
+		return err
+	}
+	asOf, err := parseTradeDate(*asOfStr)
+	if err != nil {
+		return err
+	}
+	date := asOf.Format("2006-01-02")
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	trades, err := db.ListTrades(conn, 0, date)
+	if err != nil {
+		return err
+	}
+	holdings, err := buildHoldings(trades, method)
+	if err != nil {
+		return err
+	}
+	if len(holdings) == 0 {
+		fmt.Println("No investments yet. Record a buy with: pfm invest buy ...")
+		return nil
+	}
+
+	fmt.Printf("Portfolio as of %s (%s cost basis)\n\n", date, method)
+	t := newTable(
+		tableCol{Title: "SYMBOL", Max: 10, Min: 6},
+		tableCol{Title: "QTY", Right: true},
+		tableCol{Title: "PRICE", Right: true},
+		tableCol{Title: "MARKET VALUE", Right: true},
+		tableCol{Title: "COST BASIS", Right: true},
+		tableCol{Title: "UNREALIZED", Right: true},
+		tableCol{Title: "REALIZED", Right: true},
+		tableCol{Title: "DIVIDENDS", Right: true},
+	)
+
+	var totValue, totCost, totUnreal, totReal, totDiv int64
+	stale := false
+	for _, h := range holdings {
+		price, pricedAt, ok, err := db.GetPriceAsOf(conn, h.SecurityID, date)
+		if err != nil {
+			return err
+		}
+
+		priceS, valueS, unrealS := "-", "-", "-"
+		if h.QtyMicro > 0 {
+			value := h.CostBani
+			if ok {
+				value = valueOf(h.QtyMicro, price)
+				priceS = a.disp.money(price)
+				if pricedAt != date {
+					priceS += "*"
+					stale = true
+				}
+				unrealS = a.disp.money(value - h.CostBani)
+				totUnreal += value - h.CostBani
+			}
+			valueS = a.disp.money(value)
+			totValue += value
+		}
+		totCost += h.CostBani
+		totReal += h.RealizedBani
+		totDiv += h.DividendBani
+
+		t.add(
+			h.Symbol,
+			FormatQty(h.QtyMicro),
+			priceS,
+			valueS,
+			a.disp.money(h.CostBani),
+			unrealS,
+			a.disp.money(h.RealizedBani),
+			a.disp.money(h.DividendBani),
+		)
+	}
+	t.rule()
+	t.add("TOTAL", "", "", a.disp.money(totValue), a.disp.money(totCost), a.disp.money(totUnreal), a.disp.money(totReal), a.disp.money(totDiv))
+	t.print()
+	if stale {
+		fmt.Println("\n* latest price before the report date")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"example.com/pfm/internal/db"
+)
+
+// trade is a test trade of qty whole units; cash is the trade's AmountBani.
+func trade(sec int64, date, kind string, qty, cash int64) db.TradeRow {
+	return db.TradeRow{SecurityID: sec, Symbol: "S" + string(rune('0'+sec)), TradeDate: date, Kind: kind, QtyMicro: qty * qtyScale, AmountBani: cash}
+}
+
+func TestBuildHoldings(t *testing.T) {
+	buys := []db.TradeRow{
+		trade(1, "2026-01-10", db.TradeBuy, 10, -1000),
+		trade(1, "2026-02-10", db.TradeBuy, 10, -2000),
+		trade(1, "2026-03-10", db.TradeSell, 15, 3000),
+		trade(1, "2026-03-20", db.TradeDividend, 0, 40),
+	}
+	tests := []struct {
+		name   string
+		trades []db.TradeRow
+		method string
+		want   holding
+	}{
+		{"fifo", buys, lotFIFO, holding{
+			QtyMicro: 5 * qtyScale, CostBani: 1000, RealizedBani: 1000, DividendBani: 40,
+			Lots:            []lot{{Date: "2026-02-10", QtyMicro: 5 * qtyScale, CostBani: 1000}},
+			RealizedByMonth: map[string]int64{"2026-03": 1000},
+		}},
+		{"average", buys, lotAverage, holding{
+			QtyMicro: 5 * qtyScale, CostBani: 750, RealizedBani: 750, DividendBani: 40,
+			Lots:            []lot{{Date: "2026-01-10", QtyMicro: 5 * qtyScale, CostBani: 750}},
+			RealizedByMonth: map[string]int64{"2026-03": 750},
+		}},
+		{"partial lot", []db.TradeRow{
+			trade(1, "2026-01-10", db.TradeBuy, 3, -1000),
+			trade(1, "2026-02-10", db.TradeSell, 1, 500),
+			trade(1, "2026-03-10", db.TradeSell, 1, 200),
+		}, lotFIFO, holding{
+			QtyMicro: qtyScale, CostBani: 334, RealizedBani: 34,
+			Lots:            []lot{{Date: "2026-01-10", QtyMicro: qtyScale, CostBani: 334}},
+			RealizedByMonth: map[string]int64{"2026-02": 167, "2026-03": -133},
+		}},
+		{"sold out", []db.TradeRow{
+			trade(1, "2026-01-10", db.TradeBuy, 2, -1000),
+			trade(1, "2026-02-10", db.TradeSell, 2, 900),
+		}, lotAverage, holding{
+			RealizedBani:    -100,
+			Lots:            []lot{},
+			RealizedByMonth: map[string]int64{"2026-02": -100},
+		}},
+	}
+	for _, tt := range tests {
+		hs, err := buildHoldings(tt.trades, tt.method)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(hs) != 1 {
+			t.Errorf("%s: %d holdings, want 1", tt.name, len(hs))
+			continue
+		}
+		got := *hs[0]
+		tt.want.SecurityID, tt.want.Symbol = 1, "S1"
+		if len(got.Lots) == 0 {
+			got.Lots = []lot{}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildHoldingsSecurities(t *testing.T) {
+	hs, err := buildHoldings([]db.TradeRow{
+		trade(2, "2026-01-10", db.TradeBuy, 1, -100),
+		trade(1, "2026-01-11", db.TradeBuy, 4, -400),
+		trade(2, "2026-01-12", db.TradeBuy, 1, -300),
+	}, lotFIFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hs) != 2 || hs[0].SecurityID != 2 || hs[1].SecurityID != 1 {
+		t.Fatalf("holdings not in order of first trade: %+v", hs)
+	}
+	if hs[0].QtyMicro != 2*qtyScale || hs[0].CostBani != 400 || len(hs[0].Lots) != 2 {
+		t.Errorf("security 2 = %+v, want 2 units in 2 lots costing 400", hs[0])
+	}
+}
+
+func TestBuildHoldingsOversell(t *testing.T) {
+	_, err := buildHoldings([]db.TradeRow{
+		trade(1, "2026-01-10", db.TradeBuy, 10, -1000),
+		trade(1, "2026-02-10", db.TradeSell, 5, 500),
+		trade(1, "2026-03-10", db.TradeSell, 10, 1000),
+	}, lotFIFO)
+	if err == nil || !strings.Contains(err.Error(), "selling 10 on 2026-03-10 but only 5 held") {
+		t.Errorf("error = %v, want an oversell error", err)
+	}
+}
+
+func TestParseQty(t *testing.T) {
+	good := map[string]int64{
+		"5":          5 * qtyScale,
+		" 12.5 ":     12_500_000,
+		"0.000001":   1,
+		"3.":         3 * qtyScale,
+		"1.123456":   1_123_456,
+		"007.250000": 7_250_000,
+	}
+	for in, want := range good {
+		got, err := ParseQty(in)
+		if err != nil || got != want {
+			t.Errorf("ParseQty(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", ".5", "-1", "+5", "5.-3", "5.+3", "1.1234567", "1e3", "1,5", "1.2.3", "abc"} {
+		if got, err := ParseQty(in); err == nil {
+			t.Errorf("ParseQty(%q) = %d, want an error", in, got)
+		}
+	}
+}
@@ -0,0 +1,837 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// maxPayoffMonths bounds schedules and payoff simulations; a loan whose
+// payment does not cover its interest never finishes.
+const maxPayoffMonths = 1200
+
+// parseRate parses an annual percentage such as "5.25" into basis points.
+func parseRate(s string) (int64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || f < 0 || f > 100 {
+		return 0, fmt.Errorf("invalid rate %q (expected a percent, e.g. 5.25)", s)
+	}
+	return int64(math.Round(f * 100)), nil
+}
+
+func formatRate(bp int64) string {
+	return fmt.Sprintf("%d.%02d%%", bp/100, bp%100)
+}
+
+// monthlyInterest is one month of interest on balance, rounded to the ban.
+func monthlyInterest(balanceBani, rateBP int64) int64 {
+	return (balanceBani*rateBP + 60000) / 120000
+}
+
+// interestForDays is interest on balance for days at the annual rate, on a
+// 365-day year, rounded to the ban.
+func interestForDays(balanceBani, rateBP int64, days int) int64 {
+	if days <= 0 {
+		return 0
+	}
+	return (balanceBani*rateBP*int64(days) + 1825000) / 3650000
+}
+
+// monthlyPayment is the annuity payment that repays principal over term
+// months, rounded up to the ban.
+func monthlyPayment(principalBani, rateBP int64, term int) int64 {
+	if term <= 0 {
+		return principalBani
+	}
+	if rateBP == 0 {
+		return ceilDiv(principalBani, int64(term))
+	}
+	r := float64(rateBP) / 10000 / 12
+	p := float64(principalBani) * r / (1 - math.Pow(1+r, -float64(term)))
+	return int64(math.Ceil(p))
+}
+
+// splitPayment divides a payment into the interest due and principal.
+func splitPayment(balanceBani, interestBani, amountBani int64) (principal, interest int64) {
+	interest = interestBani
+	if interest > amountBani {
+		interest = amountBani
+	}
+	principal = amountBani - interest
+	if principal > balanceBani {
+		principal = balanceBani
+	}
+	return principal, interest
+}
+
+type amortRow struct {
+	N         int
+	Date      time.Time
+	Payment   int64
+	Principal int64
+	Interest  int64
+	Balance   int64
+}
+
+// amortize schedules monthly payments (plus extra) until balance reaches zero.
+func amortize(balanceBani, rateBP, paymentBani, extraBani int64, first time.Time) []amortRow {
+	var out []amortRow
+	for n := 1; balanceBani > 0 && n <= maxPayoffMonths; n++ {
+		pay := paymentBani + extraBani
+		principal, interest := splitPayment(balanceBani, monthlyInterest(balanceBani, rateBP), pay)
+		if principal <= 0 {
+			break
+		}
+		balanceBani -= principal
+		out = append(out, amortRow{
+			N:         n,
+			Date:      first.AddDate(0, n-1, 0),
+			Payment:   principal + interest,
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balanceBani,
+		})
+	}
+	return out
+}
+
+// compiledLoan recognizes payments of a loan among imported transactions.
+type compiledLoan struct {
+	Loan db.LoanRow
+	Re   *regexp.Regexp
+}
+
+func compileLoans(rows []db.LoanRow) ([]compiledLoan, error) {
+	var out []compiledLoan
+	for _, l := range rows {
+		if l.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(l.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("loan %q has invalid pattern: %w", l.Name, err)
+		}
+		out = append(out, compiledLoan{Loan: l, Re: re})
+	}
+	return out, nil
+}
+
+func matchLoan(loans []compiledLoan, payee, payeeRaw, memo string) *compiledLoan {
+	for i := range loans {
+		if ruleMatches(loans[i].Re, payee, payeeRaw, memo) {
+			return &loans[i]
+		}
+	}
+	return nil
+}
+
+// recordLoanTx records expense transaction txID (amountBani < 0) as a
+// payment on the loan, split into principal and interest against the balance
+// on postedAt. A payment dated before others (a backdated `loan pay`, or a
+// bank export listed newest first) re-splits the payments after it.
+func recordLoanTx(conn *sql.DB, l db.LoanRow, txID int64, postedAt string, amountBani int64) (db.LoanPayment, error) {
+	p := db.LoanPayment{
+		LoanID:     l.ID,
+		PaidAt:     postedAt,
+		AmountBani: -amountBani,
+	}
+	if txID != 0 {
+		p.TransactionID = &txID
+	}
+	id, err := db.RecordLoanPayment(conn, p)
+	if err != nil {
+		return p, err
+	}
+	payments, err := resplitLoanPayments(conn, l)
+	if err != nil {
+		return p, err
+	}
+	for _, q := range payments {
+		if q.ID == id {
+			return q, nil
+		}
+	}
+	return p, nil
+}
+
+// resplitLoanPayments splits the loan's payments in date order, each against
+// the balance the earlier ones left, and stores the splits that changed.
+// Interest accrues for the days since the previous payment, or since a month
+// before the first payment date, so an extra payment soon after a regular one
+// goes almost entirely to principal.
+func resplitLoanPayments(conn *sql.DB, l db.LoanRow) ([]db.LoanPayment, error) {
+	payments, err := db.ListLoanPayments(conn, l.ID)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse("2006-01-02", l.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("loan %q has invalid start date %q", l.Name, l.StartDate)
+	}
+	prev := start.AddDate(0, -1, 0)
+	balance := l.PrincipalBani
+	for i := range payments {
+		p := &payments[i]
+		paid, err := time.Parse("2006-01-02", p.PaidAt)
+		if err != nil {
+			return nil, fmt.Errorf("loan payment #%d has invalid date %q", p.ID, p.PaidAt)
+		}
+		days := 0
+		if paid.After(prev) {
+			days = int(paid.Sub(prev).Hours() / 24)
+			prev = paid
+		}
+		principal, interest := splitPayment(balance, interestForDays(balance, l.RateBP, days), p.AmountBani)
+		balance -= principal
+		if principal == p.PrincipalBani && interest == p.InterestBani && balance == p.BalanceBani {
+			continue
+		}
+		p.PrincipalBani, p.InterestBani, p.BalanceBani = principal, interest, balance
+		if err := db.UpdateLoanPaymentSplit(conn, *p); err != nil {
+			return nil, err
+		}
+	}
+	return payments, nil
+}
+
+func (a *App) cmdLoan(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm loan <subcommand> [options]
+
+Subcommands:
+  add       Add a loan (principal, rate, term, first payment date)
+  list      List loans with balance and interest paid
+  schedule  Amortization table for a loan
+  pay       Record a payment that is not in the transactions
+  apply     Link existing transactions matching a loan's pattern as payments
+  payoff    Compare snowball and avalanche payoff strategies
+
+Examples:
+  pfm loan add --name mortgage --principal 350000 --rate 5.2 --term 360 --start 2024-02-15 --pattern "(?i)rata credit ipotecar"
+  pfm loan schedule --name mortgage --extra 500
+  pfm loan apply --dry-run
+  pfm loan payoff --extra 1000
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return a.cmdLoanAdd(args[1:])
+	case "list":
+		return a.cmdLoanList(args[1:])
+	case "schedule":
+		return a.cmdLoanSchedule(args[1:])
+	case "pay":
+		return a.cmdLoanPay(args[1:])
+	case "apply":
+		return a.cmdLoanApply(args[1:])
+	case "payoff":
+		return a.cmdLoanPayoff(args[1:])
+	default:
+		return fmt.Errorf("unknown loan subcommand: %q (try: pfm loan help)", args[0])
+	}
+}
+
+func (a *App) cmdLoanAdd(args []string) error {
+	fs := flag.NewFlagSet("loan add", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Loan name [required]")
+	principalStr := fs.String("principal", "", "Amount borrowed in RON [required]")
+	rateStr := fs.String("rate", "", "Annual interest rate in percent, e.g. 5.25 [required]")
+	term := fs.Int("term", 0, "Term in months [required]")
+	start := fs.String("start", "", "First payment date (YYYY-MM-DD) [required]")
+	paymentStr := fs.String("payment", "", "Monthly payment in RON (default: computed annuity payment)")
+	pattern := fs.String("pattern", "", "Regex on payee/memo recognizing payments on import")
+	category := fs.String("category", "", "Category for payments (default loan:<name>)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *principalStr == "" || *rateStr == "" || *term == 0 || *start == "" {
+		return errors.New("missing required flags: --name, --principal, --rate, --term, --start")
+	}
+
+	principal, err := ParseRON(*principalStr)
+	if err != nil {
+		return fmt.Errorf("invalid --principal: %w", err)
+	}
+	if principal <= 0 {
+		return errors.New("--principal must be positive")
+	}
+	rate, err := parseRate(*rateStr)
+	if err != nil {
+		return err
+	}
+	if *term < 0 || *term > maxPayoffMonths {
+		return fmt.Errorf("--term must be between 1 and %d months", maxPayoffMonths)
+	}
+	if _, err := time.Parse("2006-01-02", *start); err != nil {
+		return fmt.Errorf("invalid --start %q (expected YYYY-MM-DD)", *start)
+	}
+	if *pattern != "" {
+		if _, err := regexp.Compile(*pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	payment := monthlyPayment(principal, rate, *term)
+	if *paymentStr != "" {
+		payment, err = ParseRON(*paymentStr)
+		if err != nil {
+			return fmt.Errorf("invalid --payment: %w", err)
+		}
+		if payment <= monthlyInterest(principal, rate) {
+			return errors.New("--payment does not cover the first month's interest")
+		}
+	}
+	if *category == "" {
+		*category = "loan:" + *name
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	id, err := db.AddLoan(conn, db.LoanRow{
+		Name:          *name,
+		PrincipalBani: principal,
+		RateBP:        rate,
+		TermMonths:    *term,
+		StartDate:     *start,
+		PaymentBani:   payment,
+		Pattern:       *pattern,
+		Category:      *category,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Loan #%d added: %s, %s at %s over %d months, payment %s/month\n",
+		id, *name, a.disp.money(principal), formatRate(rate), *term, a.disp.money(payment))
+	return nil
+}
+
+func (a *App) cmdLoanList(args []string) error {
+	fs := flag.NewFlagSet("loan list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	loans, err := db.ListLoans(conn)
+	if err != nil {
+		return err
+	}
+	if len(loans) == 0 {
+		fmt.Println("No loans yet. Add one with: pfm loan add ...")
+		return nil
+	}
+
+	t := newTable(
+		tableCol{Title: "LOAN", Max: 18, Min: 8},
+		tableCol{Title: "PRINCIPAL", Right: true},
+		tableCol{Title: "RATE", Right: true},
+		tableCol{Title: "PAYMENT", Right: true},
+		tableCol{Title: "BALANCE", Right: true},
+		tableCol{Title: "INTEREST", Right: true},
+		tableCol{Title: "PAID", Right: true},
+		tableCol{Title: "PAYOFF"},
+	)
+	for _, l := range loans {
+		payments, err := db.ListLoanPayments(conn, l.ID)
+		if err != nil {
+			return err
+		}
+		balance := l.PrincipalBani
+		var interest int64
+		for _, p := range payments {
+			balance -= p.PrincipalBani
+			interest += p.InterestBani
+		}
+
+		payoff := "-"
+		if rows := amortize(balance, l.RateBP, l.PaymentBani, 0, nextPaymentDate(l, payments)); len(rows) > 0 {
+			payoff = rows[len(rows)-1].Date.Format("2006-01")
+		}
+
+		t.add(
+			l.Name,
+			a.disp.money(l.PrincipalBani),
+			formatRate(l.RateBP),
+			a.disp.money(l.PaymentBani),
+			a.disp.money(balance),
+			a.disp.money(interest),
+			strconv.Itoa(len(payments)),
+			payoff,
+		)
+	}
+	t.print()
+	return nil
+}
+
+// nextPaymentDate is the month after the last recorded payment, or the
+// loan's first payment date.
+func nextPaymentDate(l db.LoanRow, payments []db.LoanPayment) time.Time {
+	start, _ := time.Parse("2006-01-02", l.StartDate)
+	if len(payments) == 0 {
+		return start
+	}
+	last, err := time.Parse("2006-01-02", payments[len(payments)-1].PaidAt)
+	if err != nil {
+		return start
+	}
+	return time.Date(last.Year(), last.Month()+1, start.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (a *App) cmdLoanSchedule(args []string) error {
+	fs := flag.NewFlagSet("loan schedule", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Loan name [required]")
+	extraStr := fs.String("extra", "", "Extra principal per month in RON")
+	remaining := fs.Bool("remaining", false, "Schedule from the current balance instead of the start")
+	limit := fs.Int("limit", 0, "Show only the first N rows")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("missing required flag: --name")
+	}
+
+	var extra int64
+	if *extraStr != "" {
+		var err error
+		extra, err = ParseRON(*extraStr)
+		if err != nil || extra < 0 {
+			return fmt.Errorf("invalid --extra %q", *extraStr)
+		}
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	l, err := db.GetLoanByName(conn, *name)
+	if err != nil {
+		return err
+	}
+
+	balance := l.PrincipalBani
+	first, _ := time.Parse("2006-01-02", l.StartDate)
+	if *remaining {
+		payments, err := db.ListLoanPayments(conn, l.ID)
+		if err != nil {
+			return err
+		}
+		for _, p := range payments {
+			balance -= p.PrincipalBani
+		}
+		first = nextPaymentDate(l, payments)
+	}
+
+	rows := amortize(balance, l.RateBP, l.PaymentBani, extra, first)
+	if len(rows) == 0 {
+		fmt.Println("Nothing left to pay.")
+		return nil
+	}
+
+	var totalInterest int64
+	for _, r := range rows {
+		totalInterest += r.Interest
+	}
+
+	fmt.Printf("%s: %s at %s, payment %s/month", l.Name, a.disp.money(balance), formatRate(l.RateBP), a.disp.money(l.PaymentBani))
+	if extra > 0 {
+		fmt.Printf(" + %s extra", a.disp.money(extra))
+	}
+	fmt.Printf("\nPaid off %s after %d payment(s), total interest %s\n", rows[len(rows)-1].Date.Format("2006-01"), len(rows), a.disp.money(totalInterest))
+	if extra > 0 {
+		base := amortize(balance, l.RateBP, l.PaymentBani, 0, first)
+		var baseInterest int64
+		for _, r := range base {
+			baseInterest += r.Interest
+		}
+		fmt.Printf("Without extra: %s after %d payment(s), total interest %s (saves %s, %d month(s))\n",
+			base[len(base)-1].Date.Format("2006-01"), len(base), a.disp.money(baseInterest),
+			a.disp.money(baseInterest-totalInterest), len(base)-len(rows))
+	}
+	fmt.Println()
+
+	t := newTable(
+		tableCol{Title: "#", Right: true},
+		tableCol{Title: "DATE"},
+		tableCol{Title: "PAYMENT", Right: true},
+		tableCol{Title: "PRINCIPAL", Right: true},
+		tableCol{Title: "INTEREST", Right: true},
+		tableCol{Title: "BALANCE", Right: true},
+	)
+	shown := rows
+	if *limit > 0 && len(rows) > *limit {
+		shown = rows[:*limit]
+	}
+	for _, r := range shown {
+		t.add(
+			strconv.Itoa(r.N),
+			a.disp.date(r.Date),
+			a.disp.money(r.Payment),
+			a.disp.money(r.Principal),
+			a.disp.money(r.Interest),
+			a.disp.money(r.Balance),
+		)
+	}
+	t.print()
+	if more := len(rows) - len(shown); more > 0 {
+		fmt.Printf("... %d more row(s)\n", more)
+	}
+	return nil
+}
+
+func (a *App) cmdLoanPay(args []string) error {
+	fs := flag.NewFlagSet("loan pay", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Loan name [required]")
+	amountStr := fs.String("amount", "", "Amount paid in RON [required]")
+	date := fs.String("date", "", "Date (YYYY-MM-DD), default today")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *amountStr == "" {
+		return errors.New("missing required flags: --name, --amount")
+	}
+
+	amount, err := ParseRON(*amountStr)
+	if err != nil {
+		return fmt.Errorf("invalid --amount: %w", err)
+	}
+	if amount <= 0 {
+		return errors.New("--amount must be positive")
+	}
+	if *date == "" {
+		*date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", *date); err != nil {
+		return fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", *date)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	l, err := db.GetLoanByName(conn, *name)
+	if err != nil {
+		return err
+	}
+	p, err := recordLoanTx(conn, l, 0, *date, -amount)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Payment recorded: principal %s, interest %s, balance %s\n",
+		a.disp.money(p.PrincipalBani), a.disp.money(p.InterestBani), a.disp.money(p.BalanceBani))
+	return nil
+}
+
+func (a *App) cmdLoanApply(args []string) error {
+	fs := flag.NewFlagSet("loan apply", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "Show matches without recording them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	loanRows, err := db.ListLoans(conn)
+	if err != nil {
+		return err
+	}
+	loans, err := compileLoans(loanRows)
+	if err != nil {
+		return err
+	}
+	if len(loans) == 0 {
+		fmt.Println("No loans with a --pattern to match.")
+		return nil
+	}
+
+	txs, err := db.ListUnlinkedExpenses(conn)
+	if err != nil {
+		return err
+	}
+
+	matched := 0
+	for _, t := range txs {
+		cl := matchLoan(loans, t.Payee, t.PayeeRaw, t.Memo)
+		if cl == nil {
+			continue
+		}
+		matched++
+		if *dryRun {
+			fmt.Printf("  #%-5d  %s  %s  %-12s  -> %s\n", t.ID, t.PostedAt, cell(t.Payee, 18), a.disp.money(t.AmountBani), cl.Loan.Name)
+			continue
+		}
+		p, err := recordLoanTx(conn, cl.Loan, t.ID, t.PostedAt, t.AmountBani)
+		if err != nil {
+			return err
+		}
+		if err := db.UpdateTxCategory(conn, t.ID, cl.Loan.Category, db.CategoryLoan); err != nil {
+			return err
+		}
+		fmt.Printf("  #%-5d  %s  %s  %-12s  -> %s: principal %s, interest %s\n",
+			t.ID, t.PostedAt, cell(t.Payee, 18), a.disp.money(t.AmountBani), cl.Loan.Name,
+			a.disp.money(p.PrincipalBani), a.disp.money(p.InterestBani))
+	}
+
+	verb := "Linked"
+	if *dryRun {
+		verb = "Would link"
+	}
+	fmt.Printf("%s %d payment(s).\n", verb, matched)
+	return nil
+}
+
+// debt is a loan's state during a payoff simulation.
+type debt struct {
+	Name    string
+	Balance int64
+	RateBP  int64
+	Payment int64
+}
+
+type payoffResult struct {
+	Months   int // until the last debt is paid; maxPayoffMonths+1 if never
+	Interest int64
+	PaidOff  map[string]int // month number each debt was paid off in
+}
+
+// simulatePayoff pays every debt's minimum each month, then puts extra (and,
+// with rollover, the payments of debts already paid off) on debts in the
+// order given by less, re-evaluated monthly.
+func simulatePayoff(debts []debt, extra int64, rollover bool, less func(a, b debt) bool) payoffResult {
+	ds := append([]debt(nil), debts...)
+	res := payoffResult{PaidOff: map[string]int{}}
+
+	for month := 1; month <= maxPayoffMonths; month++ {
+		pool := extra
+		open := 0
+		for i := range ds {
+			if ds[i].Balance <= 0 {
+				if rollover {
+					pool += ds[i].Payment
+				}
+				continue
+			}
+			interest := monthlyInterest(ds[i].Balance, ds[i].RateBP)
+			res.Interest += interest
+			ds[i].Balance += interest
+
+			pay := ds[i].Payment
+			if pay > ds[i].Balance {
+				if rollover {
+					pool += pay - ds[i].Balance
+				}
+				pay = ds[i].Balance
+			}
+			ds[i].Balance -= pay
+			open++
+		}
+		if open == 0 {
+			res.Months = month - 1
+			return res
+		}
+
+		if less != nil && pool > 0 {
+			order := make([]int, 0, len(ds))
+			for i := range ds {
+				if ds[i].Balance > 0 {
+					order = append(order, i)
+				}
+			}
+			sort.SliceStable(order, func(x, y int) bool { return less(ds[order[x]], ds[order[y]]) })
+			for _, i := range order {
+				pay := pool
+				if pay > ds[i].Balance {
+					pay = ds[i].Balance
+				}
+				ds[i].Balance -= pay
+				pool -= pay
+				if pool == 0 {
+					break
+				}
+			}
+		}
+
+		for i := range ds {
+			if _, done := res.PaidOff[ds[i].Name]; !done && ds[i].Balance <= 0 {
+				res.PaidOff[ds[i].Name] = month
+			}
+		}
+	}
+
+	res.Months = maxPayoffMonths + 1
+	return res
+}
+
+func snowballOrder(a, b debt) bool {
+	if a.Balance != b.Balance {
+		return a.Balance < b.Balance
+	}
+	return a.RateBP > b.RateBP
+}
+
+func avalancheOrder(a, b debt) bool {
+	if a.RateBP != b.RateBP {
+		return a.RateBP > b.RateBP
+	}
+	return a.Balance < b.Balance
+}
+
+func (a *App) cmdLoanPayoff(args []string) error {
+	fs := flag.NewFlagSet("loan payoff", flag.ContinueOnError)
+	extraStr := fs.String("extra", "0", "Extra amount per month in RON on top of the scheduled payments")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	extra, err := ParseRON(*extraStr)
+	if err != nil || extra < 0 {
+		return fmt.Errorf("invalid --extra %q", *extraStr)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	loans, err := db.ListLoans(conn)
+	if err != nil {
+		return err
+	}
+	var debts []debt
+	for _, l := range loans {
+		balance, err := db.GetLoanBalance(conn, l)
+		if err != nil {
+			return err
+		}
+		if balance > 0 {
+			debts = append(debts, debt{Name: l.Name, Balance: balance, RateBP: l.RateBP, Payment: l.PaymentBani})
+		}
+	}
+	if len(debts) == 0 {
+		fmt.Println("No outstanding loans.")
+		return nil
+	}
+
+	now := time.Now()
+	monthLabel := func(n int) string {
+		if n > maxPayoffMonths {
+			return "never"
+		}
+		return addMonths(now.Format("2006-01"), n)
+	}
+
+	strategies := []struct {
+		Name   string
+		Result payoffResult
+	}{
+		{"minimum only", simulatePayoff(debts, 0, false, nil)},
+		{"snowball", simulatePayoff(debts, extra, true, snowballOrder)},
+		{"avalanche", simulatePayoff(debts, extra, true, avalancheOrder)},
+	}
+	base := strategies[0].Result
+
+	fmt.Printf("Payoff from %s with %s extra per month\n\n", now.Format("2006-01"), a.disp.money(extra))
+	st := newTable(
+		tableCol{Title: "STRATEGY"},
+		tableCol{Title: "DEBT-FREE"},
+		tableCol{Title: "MONTHS", Right: true},
+		tableCol{Title: "INTEREST", Right: true},
+		tableCol{Title: "SAVED VS MINIMUM"},
+	)
+	for _, s := range strategies {
+		saved := "-"
+		if s.Name != "minimum only" {
+			saved = fmt.Sprintf("%s, %d month(s)", a.disp.money(base.Interest-s.Result.Interest), base.Months-s.Result.Months)
+		}
+		st.add(s.Name, monthLabel(s.Result.Months), strconv.Itoa(s.Result.Months), a.disp.money(s.Result.Interest), saved)
+	}
+	st.print()
+
+	fmt.Println()
+	t := newTable(
+		tableCol{Title: "LOAN", Max: 18, Min: 8},
+		tableCol{Title: "BALANCE", Right: true},
+		tableCol{Title: "RATE", Right: true},
+		tableCol{Title: "PAYMENT", Right: true},
+		tableCol{Title: "MINIMUM"},
+		tableCol{Title: "SNOWBALL"},
+		tableCol{Title: "AVALANCHE"},
+	)
+	for _, d := range debts {
+		label := func(r payoffResult) string {
+			if n, ok := r.PaidOff[d.Name]; ok {
+				return monthLabel(n)
+			}
+			return "never"
+		}
+		t.add(
+			d.Name,
+			a.disp.money(d.Balance),
+			formatRate(d.RateBP),
+			a.disp.money(d.Payment),
+			label(strategies[0].Result),
+			label(strategies[1].Result),
+			label(strategies[2].Result),
+		)
+	}
+	t.print()
+	return nil
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+func TestMonthlyPayment(t *testing.T) {
+	tests := []struct {
+		principal, rateBP int64
+		term              int
+		want              int64
+	}{
+		{30000000, 600, 360, 179866},
+		{120000, 0, 12, 10000},
+		{100000, 0, 3, 33334},
+		{100000, 1200, 0, 100000},
+	}
+	for _, tt := range tests {
+		if got := monthlyPayment(tt.principal, tt.rateBP, tt.term); got != tt.want {
+			t.Errorf("monthlyPayment(%d, %d, %d) = %d, want %d", tt.principal, tt.rateBP, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestInterestForDays(t *testing.T) {
+	tests := []struct {
+		balance, rateBP int64
+		days            int
+		want            int64
+	}{
+		{3650000, 1000, 365, 365000},
+		{29945886, 600, 5, 24613},
+		{29945886, 600, 0, 0},
+		{29945886, 600, -3, 0},
+		{100000, 0, 30, 0},
+	}
+	for _, tt := range tests {
+		if got := interestForDays(tt.balance, tt.rateBP, tt.days); got != tt.want {
+			t.Errorf("interestForDays(%d, %d, %d) = %d, want %d", tt.balance, tt.rateBP, tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestSplitPayment(t *testing.T) {
+	tests := []struct {
+		balance, interest, amount int64
+		principal, wantInterest   int64
+	}{
+		{100000, 1000, 5000, 4000, 1000},
+		{100000, 6000, 5000, 0, 5000},
+		{3000, 100, 5000, 3000, 100},
+		{100000, 0, 5000, 5000, 0},
+	}
+	for _, tt := range tests {
+		principal, interest := splitPayment(tt.balance, tt.interest, tt.amount)
+		if principal != tt.principal || interest != tt.wantInterest {
+			t.Errorf("splitPayment(%d, %d, %d) = %d, %d; want %d, %d",
+				tt.balance, tt.interest, tt.amount, principal, interest, tt.principal, tt.wantInterest)
+		}
+	}
+}
+
+func TestAmortize(t *testing.T) {
+	first := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	day := func(m int) time.Time { return first.AddDate(0, m, 0) }
+
+	got := amortize(100000, 1200, 50000, 0, first)
+	want := []amortRow{
+		{N: 1, Date: day(0), Payment: 50000, Principal: 49000, Interest: 1000, Balance: 51000},
+		{N: 2, Date: day(1), Payment: 50000, Principal: 49490, Interest: 510, Balance: 1510},
+		{N: 3, Date: day(2), Payment: 1525, Principal: 1510, Interest: 15, Balance: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("amortize with interest:\n got %+v\nwant %+v", got, want)
+	}
+
+	if rows := amortize(120000, 0, 10000, 0, first); len(rows) != 12 || rows[11].Balance != 0 {
+		t.Errorf("amortize without interest: %d rows, want 12 ending at 0", len(rows))
+	}
+	if rows := amortize(120000, 0, 10000, 10000, first); len(rows) != 6 || rows[5].Date != day(5) {
+		t.Errorf("amortize with extra: %d rows, want 6", len(rows))
+	}
+	if rows := amortize(100000, 1200, 1000, 0, first); len(rows) != 0 {
+		t.Errorf("payment covering only interest: %d rows, want none", len(rows))
+	}
+}
+
+func TestSimulatePayoff(t *testing.T) {
+	// A rate of 1bp charges no interest on these balances, so only the order
+	// depends on it.
+	debts := []debt{
+		{Name: "a", Balance: 1000, RateBP: 0, Payment: 100},
+		{Name: "b", Balance: 3000, RateBP: 1, Payment: 100},
+	}
+	tests := []struct {
+		name     string
+		extra    int64
+		rollover bool
+		less     func(a, b debt) bool
+		want     payoffResult
+	}{
+		{"minimums", 0, false, nil, payoffResult{Months: 30, PaidOff: map[string]int{"a": 10, "b": 30}}},
+		{"snowball", 100, true, snowballOrder, payoffResult{Months: 14, PaidOff: map[string]int{"a": 5, "b": 14}}},
+		{"avalanche", 100, true, avalancheOrder, payoffResult{Months: 14, PaidOff: map[string]int{"a": 10, "b": 14}}},
+	}
+	for _, tt := range tests {
+		if got := simulatePayoff(debts, tt.extra, tt.rollover, tt.less); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+	if debts[0].Balance != 1000 {
+		t.Error("simulatePayoff changed its input")
+	}
+
+	// Interest matches the amortization schedule of the same loan.
+	got := simulatePayoff([]debt{{Name: "m", Balance: 100000, RateBP: 1200, Payment: 50000}}, 0, false, nil)
+	want := payoffResult{Months: 3, Interest: 1525, PaidOff: map[string]int{"m": 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("with interest: got %+v, want %+v", got, want)
+	}
+
+	got = simulatePayoff([]debt{{Name: "x", Balance: 100000, RateBP: 1200, Payment: 500}}, 0, false, nil)
+	if got.Months != maxPayoffMonths+1 {
+		t.Errorf("payment below interest: %d months, want %d", got.Months, maxPayoffMonths+1)
+	}
+}
+
+func TestResplitLoanPayments(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "pfm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, ""); err != nil {
+		t.Fatal(err)
+	}
+	l := db.LoanRow{Name: "m", PrincipalBani: 30000000, RateBP: 600, TermMonths: 360, StartDate: "2026-01-15", PaymentBani: 179866, Category: "loan"}
+	if l.ID, err = db.AddLoan(conn, l); err != nil {
+		t.Fatal(err)
+	}
+
+	// Recorded out of order; splits follow the dates.
+	for _, p := range []struct {
+		date   string
+		amount int64
+	}{
+		{"2026-02-20", 1000000},
+		{"2026-01-15", 179866},
+		{"2026-02-15", 179866},
+	} {
+		if _, err := recordLoanTx(conn, l, 0, p.date, -p.amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	payments, err := db.ListLoanPayments(conn, l.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type split struct {
+		date                         string
+		principal, interest, balance int64
+	}
+	var got []split
+	for _, p := range payments {
+		got = append(got, split{p.PaidAt, p.PrincipalBani, p.InterestBani, p.BalanceBani})
+	}
+	want := []split{
+		// 31 days since a month before the first payment date.
+		{"2026-01-15", 26989, 152877, 29973011},
+		{"2026-02-15", 27127, 152739, 29945884},
+		// Five days after the regular payment.
+		{"2026-02-20", 975387, 24613, 28970497},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splits:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEditLoanPaymentResplits(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "pfm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, ""); err != nil {
+		t.Fatal(err)
+	}
+	l := db.LoanRow{Name: "m", PrincipalBani: 30000000, RateBP: 600, TermMonths: 360, StartDate: "2026-01-15", PaymentBani: 179866, Category: "loan"}
+	if l.ID, err = db.AddLoan(conn, l); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, date := range []string{"2026-01-15", "2026-02-15"} {
+		posted, _ := time.Parse("2006-01-02", date)
+		id, _, err := db.InsertTransaction(conn, db.AddTxParams{PostedAt: posted, Payee: "Bank", AmountBani: -179866, Category: "loan"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := recordLoanTx(conn, l, id, date, -179866); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Moving the first payment five days later shortens the second period.
+	e := db.TxEdit{PostedAt: time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC), Payee: "Bank", AmountBani: -200000}
+	if err := db.UpdateTransaction(conn, ids[0], e); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := db.GetLoanForTransaction(conn, ids[0])
+	if err != nil || !ok || got.ID != l.ID {
+		t.Fatalf("GetLoanForTransaction = %+v, %v, %v", got, ok, err)
+	}
+	if _, err := resplitLoanPayments(conn, got); err != nil {
+		t.Fatal(err)
+	}
+	payments, err := db.ListLoanPayments(conn, l.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 2 || payments[0].PaidAt != "2026-01-20" || payments[0].AmountBani != 200000 {
+		t.Fatalf("payments = %+v, want the first moved to 2026-01-20 for 2000.00", payments)
+	}
+	// 36 days, then 26 days of interest.
+	if i0, i1 := payments[0].InterestBani, payments[1].InterestBani; i0 != interestForDays(30000000, 600, 36) || i1 != interestForDays(payments[0].BalanceBani, 600, 26) {
+		t.Errorf("interest %d, %d after the edit", i0, i1)
+	}
+
+	if err := db.UpdateTransaction(conn, ids[1], db.TxEdit{PostedAt: e.PostedAt, Payee: "Bank", AmountBani: 100}); err == nil {
+		t.Error("positive loan payment: want an error")
+	}
+	if _, ok, err := db.GetLoanForTransaction(conn, 999); ok || err != nil {
+		t.Errorf("unlinked transaction: ok %v, err %v", ok, err)
+	}
+}
@@ -51,11 +51,75 @@ func ParseRON(input string) (int64, error) {
 	return total, nil
 }
 
-func FormatRON(bani int64) string {
+// money formats an amount followed by the configured currency.
+func (d display) money(bani int64) string {
+	return formatAmount(bani) + " " + d.Currency
+}
+
+// formatAmount is money without the currency, as ParseRON reads it back.
+func formatAmount(bani int64) string {
 	sign := ""
 	if bani < 0 {
 		sign = "-"
 		bani = -bani
 	}
-	return fmt.Sprintf("%s%d.%02d RON", sign, bani/100, bani%100)
-}
\ No newline at end of file
+	return fmt.Sprintf("%s%d.%02d", sign, bani/100, bani%100)
+}
+
+// qtyScale is the number of quantity micro-units in one share or unit.
+const qtyScale = 1_000_000
+
+// ParseQty parses a security quantity with up to 6 decimals into micro-units.
+func ParseQty(input string) (int64, error) {
+	input = strings.TrimSpace(input)
+	parts := strings.SplitN(input, ".", 2)
+	frac := ""
+	if len(parts) == 2 {
+		frac = parts[1]
+	}
+	if parts[0] == "" || !allDigits(parts[0]) || !allDigits(frac) {
+		return 0, fmt.Errorf("invalid quantity: %q", input)
+	}
+	if len(frac) > 6 {
+		return 0, fmt.Errorf("too many decimal places: %q", input)
+	}
+	frac += strings.Repeat("0", 6-len(frac))
+
+	whole, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity: %q", input)
+	}
+	micro, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity: %q", input)
+	}
+	return whole*qtyScale + micro, nil
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatQty prints micro-units without trailing zeros, e.g. 12.5.
+func FormatQty(micro int64) string {
+	sign := ""
+	if micro < 0 {
+		sign = "-"
+		micro = -micro
+	}
+	s := fmt.Sprintf("%s%d", sign, micro/qtyScale)
+	if frac := micro % qtyScale; frac != 0 {
+		s += "." + strings.TrimRight(fmt.Sprintf("%06d", frac), "0")
+	}
+	return s
+}
+
+// valueOf is qty micro-units at priceBani per unit, rounded to the ban.
+func valueOf(qtyMicro, priceBani int64) int64 {
+	return (qtyMicro*priceBani + qtyScale/2) / qtyScale
+}
@@ -0,0 +1,473 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// Net worth classes that do not come from the assets table.
+const (
+	classAccounts    = "accounts"
+	classInvestments = "investments"
+	classLoans       = "loans"
+)
+
+func (a *App) cmdAsset(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm asset <subcommand> [options]
+
+Subcommands:
+  add     Add an asset or (with --liability) a liability
+  value   Record a dated valuation
+  list    List assets and liabilities with their latest value
+  import  Import valuations from CSV (name,date,value[,class,liability,note])
+
+Examples:
+  pfm asset add --name apartment --class property
+  pfm asset add --name "loan from parents" --class debt --liability
+  pfm asset value --name apartment --date 2026-01-31 --value 420000
+  pfm asset import --file valuations.csv
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return a.cmdAssetAdd(args[1:])
+	case "value":
+		return a.cmdAssetValue(args[1:])
+	case "list":
+		return a.cmdAssetList(args[1:])
+	case "import":
+		return a.cmdAssetImport(args[1:])
+	default:
+		return fmt.Errorf("unknown asset subcommand: %q (try: pfm asset help)", args[0])
+	}
+}
+
+func (a *App) cmdAssetAdd(args []string) error {
+	fs := flag.NewFlagSet("asset add", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Asset name [required]")
+	class := fs.String("class", "", "Asset class, e.g. property, vehicle, pension [required]")
+	liability := fs.Bool("liability", false, "This is something owed")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *class == "" {
+		return errors.New("missing required flags: --name, --class")
+	}
+	if *class == classAccounts || *class == classInvestments || *class == classLoans {
+		return fmt.Errorf("class %q is reserved for account balances, holdings and loans", *class)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	id, err := db.AddAsset(conn, *name, *class, *liability)
+	if err != nil {
+		return err
+	}
+	kind := "Asset"
+	if *liability {
+		kind = "Liability"
+	}
+	fmt.Printf("%s #%d: %s (%s)\n", kind, id, *name, *class)
+	return nil
+}
+
+func (a *App) cmdAssetValue(args []string) error {
+	fs := flag.NewFlagSet("asset value", flag.ContinueOnError)
+
+	name := fs.String("name", "", "Asset name [required]")
+	date := fs.String("date", "", "Valuation date (YYYY-MM-DD), default today")
+	valueStr := fs.String("value", "", "Value in RON [required]")
+	note := fs.String("note", "", "Note")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *valueStr == "" {
+		return errors.New("missing required flags: --name, --value")
+	}
+
+	value, err := ParseRON(*valueStr)
+	if err != nil {
+		return fmt.Errorf("invalid --value: %w", err)
+	}
+	if value < 0 {
+		return errors.New("--value must not be negative (use a liability for debts)")
+	}
+	if *date == "" {
+		*date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", *date); err != nil {
+		return fmt.Errorf("invalid --date %q (expected YYYY-MM-DD)", *date)
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	id, err := db.GetAssetID(conn, *name)
+	if err != nil {
+		return err
+	}
+	if err := db.UpsertValuation(conn, id, *date, value, *note); err != nil {
+		return err
+	}
+	fmt.Printf("Valuation set: %s on %s = %s\n", *name, a.disp.dateText(*date), a.disp.money(value))
+	return nil
+}
+
+func (a *App) cmdAssetList(args []string) error {
+	fs := flag.NewFlagSet("asset list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	assets, err := db.ListAssets(conn)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		fmt.Println("No assets yet. Add one with: pfm asset add ...")
+		return nil
+	}
+
+	t := newTable(
+		tableCol{Title: "NAME", Max: 24, Min: 8},
+		tableCol{Title: "CLASS", Max: 14, Min: 6},
+		tableCol{Title: "KIND"},
+		tableCol{Title: "VALUE", Right: true},
+		tableCol{Title: "AS OF"},
+	)
+	for _, as := range assets {
+		kind := "asset"
+		if as.Liability {
+			kind = "liability"
+		}
+		value, asOf := a.disp.money(as.ValueBani), a.disp.dateText(as.ValuedAt)
+		if asOf == "" {
+			value, asOf = "-", "-"
+		}
+		t.add(as.Name, as.Class, kind, value, asOf)
+	}
+	t.print()
+	return nil
+}
+
+// cmdAssetImport reads valuations from CSV. Unknown assets are created with
+// the row's class (default "other") and liability flag; a row whose class or
+// flag disagrees with an existing asset fails the whole import.
+func (a *App) cmdAssetImport(args []string) error {
+	fs := flag.NewFlagSet("asset import", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV file [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("missing required flag: --file")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, n := range []string{"name", "date", "value"} {
+		if _, ok := col[n]; !ok {
+			return fmt.Errorf("missing required column %q", n)
+		}
+	}
+	get := func(row []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	line := 1
+	var vals []db.AssetValuation
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		name, date := get(row, "name"), get(row, "date")
+		if name == "" {
+			return fmt.Errorf("line %d: empty name", line)
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("line %d: invalid date %q (expected YYYY-MM-DD)", line, date)
+		}
+		value, err := ParseRON(get(row, "value"))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid value: %w", line, err)
+		}
+		if value < 0 {
+			return fmt.Errorf("line %d: negative value (use a liability for debts)", line)
+		}
+
+		class := get(row, "class")
+		if class == "" {
+			class = "other"
+		}
+		if class == classAccounts || class == classInvestments || class == classLoans {
+			return fmt.Errorf("line %d: class %q is reserved for account balances, holdings and loans", line, class)
+		}
+		vals = append(vals, db.AssetValuation{
+			Name:      name,
+			Class:     class,
+			Liability: isTruthy(get(row, "liability")),
+			ValuedAt:  date,
+			ValueBani: value,
+			Note:      get(row, "note"),
+		})
+	}
+
+	if err := db.ImportValuations(conn, vals); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d valuation(s).\n", len(vals))
+	return nil
+}
+
+func isTruthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "yes", "y", "true", "liability":
+		return true
+	}
+	return false
+}
+
+// networthMonth is net worth at the end of a month, by class. Liabilities
+// are negative.
+type networthMonth struct {
+	Month   string
+	ByClass map[string]int64
+	Total   int64
+}
+
+// computeNetworth evaluates every month in [from, to]: transaction balances,
+// the market value of investment holdings, the latest valuation of each asset
+// on or before month end, and what is still owed on loans.
+func computeNetworth(conn *sql.DB, from, to string) ([]networthMonth, []string, error) {
+	assets, err := db.ListAssets(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, err := db.ListValuations(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	loans, err := db.ListLoans(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byAsset := map[int64][]db.Valuation{}
+	for _, v := range vals {
+		byAsset[v.AssetID] = append(byAsset[v.AssetID], v)
+	}
+
+	seen := map[string]bool{}
+	var out []networthMonth
+	for m := from; m <= to; m = addMonths(m, 1) {
+		start, _ := parseMonth(m)
+		end := start.AddDate(0, 1, -1).Format("2006-01-02")
+		nm := networthMonth{Month: m, ByClass: map[string]int64{}}
+
+		bal, err := db.GetBalanceAsOf(conn, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		nm.ByClass[classAccounts] = bal
+		seen[classAccounts] = true
+
+		invested, err := portfolioValueAsOf(conn, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		if invested != 0 {
+			nm.ByClass[classInvestments] = invested
+			seen[classInvestments] = true
+		}
+
+		for _, as := range assets {
+			var value int64
+			found := false
+			for _, v := range byAsset[as.ID] {
+				if v.ValuedAt > end {
+					break
+				}
+				value, found = v.ValueBani, true
+			}
+			if !found {
+				continue
+			}
+			if as.Liability {
+				value = -value
+			}
+			nm.ByClass[as.Class] += value
+			seen[as.Class] = true
+		}
+
+		for _, l := range loans {
+			// The money is owed from the month before the first payment.
+			if addMonths(l.StartDate[:7], -1) > m {
+				continue
+			}
+			owed, err := db.GetLoanBalanceAsOf(conn, l, end)
+			if err != nil {
+				return nil, nil, err
+			}
+			nm.ByClass[classLoans] -= owed
+			seen[classLoans] = true
+		}
+
+		for _, v := range nm.ByClass {
+			nm.Total += v
+		}
+		out = append(out, nm)
+	}
+
+	liability := map[string]bool{classLoans: true}
+	for _, as := range assets {
+		if as.Liability {
+			liability[as.Class] = true
+		}
+	}
+	classes := make([]string, 0, len(seen))
+	for c := range seen {
+		classes = append(classes, c)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		ci, cj := classes[i], classes[j]
+		if (ci == classAccounts) != (cj == classAccounts) {
+			return ci == classAccounts
+		}
+		if liability[ci] != liability[cj] {
+			return !liability[ci]
+		}
+		return ci < cj
+	})
+	return out, classes, nil
+}
+
+func (a *App) cmdReportNetworth(args []string) error {
+	fs := flag.NewFlagSet("report networth", flag.ContinueOnError)
+	now := time.Now().Format("2006-01")
+	from := fs.String("from", addMonths(now, -11), "First month (YYYY-MM)")
+	to := fs.String("to", now, "Last month (YYYY-MM)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if _, err := parseMonth(*from); err != nil {
+		return err
+	}
+	if _, err := parseMonth(*to); err != nil {
+		return err
+	}
+	if *from > *to {
+		return errors.New("--from must not be after --to")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	months, classes, err := computeNetworth(conn, *from, *to)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Net worth %s .. %s (end of month)\n\n", *from, *to)
+	cols := []tableCol{{Title: "MONTH"}}
+	for _, c := range classes {
+		cols = append(cols, tableCol{Title: strings.ToUpper(c), Right: true})
+	}
+	t := newTable(append(cols, tableCol{Title: "NET WORTH", Right: true}, tableCol{Title: "CHANGE", Right: true})...)
+
+	for i, nm := range months {
+		cells := []string{nm.Month}
+		for _, c := range classes {
+			cells = append(cells, a.disp.money(nm.ByClass[c]))
+		}
+		change := "-"
+		if i > 0 {
+			change = a.disp.money(nm.Total - months[i-1].Total)
+		}
+		t.add(append(cells, a.disp.money(nm.Total), change)...)
+	}
+	t.print()
+	return nil
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// recurringLookback is how many previous months a payee must have charged a
+// category in to count as a known recurring charge.
+const recurringLookback = 3
+
+// budgetPace says whether a monthly budget is on track as of a given day.
+type budgetPace struct {
+	ExpectedBani  int64 // share of the effective limit for the days elapsed
+	DailyLeftBani int64 // what may still be spent per remaining day
+	ProjectedBani int64 // expected month-end spending
+	PendingBani   int64 // recurring charges expected but not yet posted
+	DaysLeft      int
+}
+
+// monthProgress returns the days elapsed and the length of month as of asOf.
+// Past months are fully elapsed, future ones not started.
+func monthProgress(month string, asOf time.Time) (elapsed, days int) {
+	start, _ := parseMonth(month)
+	days = start.AddDate(0, 1, -1).Day()
+	switch cur := asOf.Format("2006-01"); {
+	case cur > month:
+		return days, days
+	case cur < month:
+		return 0, days
+	}
+	return asOf.Day(), days
+}
+
+// budgetStatus is the OK/WARN/OVER verdict shared by status and history.
+func budgetStatus(spentBani, effectiveBani int64, warnPct int) (int, string) {
+	usedPct := 0
+	if effectiveBani > 0 {
+		usedPct = int((spentBani * 100) / effectiveBani)
+	}
+	switch {
+	case spentBani > effectiveBani:
+		return usedPct, "OVER"
+	case usedPct >= warnPct:
+		return usedPct, "WARN"
+	}
+	return usedPct, "OK"
+}
+
+// paceBudget projects month-end spending as what was spent so far, plus the
+// non-recurring spending rate carried over the remaining days, plus recurring
+// charges that have not posted yet.
+func paceBudget(conn *sql.DB, b budgetLine, month string, asOf time.Time) (budgetPace, error) {
+	var p budgetPace
+
+	elapsed, days := monthProgress(month, asOf)
+	effective := b.EffectiveBani()
+	p.ExpectedBani = effective * int64(elapsed) / int64(days)
+	p.DaysLeft = days - elapsed
+
+	left := effective - b.SpentBani
+	if left < 0 {
+		left = 0
+	}
+	if p.DaysLeft > 0 {
+		p.DailyLeftBani = left / int64(p.DaysLeft)
+	}
+
+	if elapsed == days {
+		p.ProjectedBani = b.SpentBani
+		return p, nil
+	}
+
+	recurring, err := recurringCharges(conn, b.Category, month)
+	if err != nil {
+		return p, err
+	}
+
+	thisMonth, err := categoryTransactions(conn, b.Category, month, month)
+	if err != nil {
+		return p, err
+	}
+	posted := map[string]bool{}
+	var recurringSpent int64
+	for _, t := range thisMonth {
+		if _, ok := recurring[t.Payee]; ok {
+			posted[t.Payee] = true
+			recurringSpent += -t.AmountBani
+		}
+	}
+	for payee, amount := range recurring {
+		if !posted[payee] {
+			p.PendingBani += amount
+		}
+	}
+
+	discretionary := b.SpentBani - recurringSpent
+	if discretionary < 0 {
+		discretionary = 0
+	}
+	var velocity int64
+	if elapsed > 0 {
+		velocity = discretionary * int64(p.DaysLeft) / int64(elapsed)
+	}
+	p.ProjectedBani = b.SpentBani + velocity + p.PendingBani
+	return p, nil
+}
+
+// recurringCharges finds payees that charged category in each of the
+// recurringLookback months before month, with their latest monthly amount.
+func recurringCharges(conn *sql.DB, category, month string) (map[string]int64, error) {
+	from := addMonths(month, -recurringLookback)
+	txs, err := categoryTransactions(conn, category, from, addMonths(month, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	months := map[string]map[string]bool{}
+	latest := map[string]string{}
+	amount := map[string]int64{}
+	for _, t := range txs {
+		m := t.PostedAt.Format("2006-01")
+		if months[t.Payee] == nil {
+			months[t.Payee] = map[string]bool{}
+		}
+		months[t.Payee][m] = true
+		switch {
+		case m > latest[t.Payee]:
+			latest[t.Payee] = m
+			amount[t.Payee] = -t.AmountBani
+		case m == latest[t.Payee]:
+			amount[t.Payee] += -t.AmountBani
+		}
+	}
+
+	out := map[string]int64{}
+	for payee, seen := range months {
+		if len(seen) == recurringLookback {
+			out[payee] = amount[payee]
+		}
+	}
+	return out, nil
+}
+
+// categoryTransactions lists a category's expenses in [fromMonth, toMonth].
+func categoryTransactions(conn *sql.DB, category, fromMonth, toMonth string) ([]db.TxRow, error) {
+	from, err := parseMonth(fromMonth)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseMonth(toMonth)
+	if err != nil {
+		return nil, err
+	}
+	to = to.AddDate(0, 1, -1)
+
+	rows, err := db.SearchTransactions(conn, db.SearchFilter{
+		From:     &from,
+		To:       &to,
+		Category: category,
+		Limit:    100000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := rows[:0]
+	for _, r := range rows {
+		if r.AmountBani < 0 {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// printBudgetHistory shows how each budgeted category did in the n months
+// before month.
+func printBudgetHistory(conn *sql.DB, budgets []budgetLine, month string, n, warnPct int) error {
+	months := make([]string, 0, n)
+	byMonth := map[string]map[string]budgetLine{}
+	for i := n; i >= 1; i-- {
+		m := addMonths(month, -i)
+		months = append(months, m)
+
+		lines, err := resolveBudgets(conn, m)
+		if err != nil {
+			return err
+		}
+		byMonth[m] = map[string]budgetLine{}
+		for _, l := range lines {
+			byMonth[m][l.Category] = l
+		}
+	}
+
+	fmt.Printf("\nHistory (previous %d month(s))\n\n", n)
+	cols := []tableCol{{Title: "CATEGORY", Max: 18, Min: 8}}
+	for _, m := range months {
+		cols = append(cols, tableCol{Title: m})
+	}
+	t := newTable(append(cols, tableCol{Title: "WITHIN", Right: true})...)
+
+	for _, b := range budgets {
+		cells := []string{b.Category}
+		within, budgeted := 0, 0
+		for _, m := range months {
+			l, ok := byMonth[m][b.Category]
+			if !ok {
+				cells = append(cells, "-")
+				continue
+			}
+			budgeted++
+			used, status := budgetStatus(l.SpentBani, l.EffectiveBani(), warnPct)
+			if status != "OVER" {
+				within++
+			}
+			cells = append(cells, fmt.Sprintf("%d%% %s", used, status))
+		}
+		t.add(append(cells, fmt.Sprintf("%d/%d", within, budgeted))...)
+	}
+	t.print()
+	return nil
+}
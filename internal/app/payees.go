@@ -0,0 +1,276 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"example.com/pfm/internal/db"
+)
+
+type compiledAlias struct {
+	ID    int64
+	Payee string
+	Re    *regexp.Regexp
+}
+
+func compileAliases(rows []db.PayeeAlias) ([]compiledAlias, error) {
+	out := make([]compiledAlias, 0, len(rows))
+	for _, a := range rows {
+		re, err := regexp.Compile(a.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("alias %d (%s) bad regex: %w", a.ID, a.Name, err)
+		}
+		out = append(out, compiledAlias{ID: a.ID, Payee: a.Name, Re: re})
+	}
+	return out, nil
+}
+
+// canonicalPayee maps raw bank text to its canonical payee. Text no alias
+// matches is returned unchanged.
+func canonicalPayee(aliases []compiledAlias, raw string) string {
+	if payee, ok := matchAlias(aliases, raw); ok {
+		return payee
+	}
+	return raw
+}
+
+// matchAlias is the payee of the first alias matching raw bank text.
+func matchAlias(aliases []compiledAlias, raw string) (string, bool) {
+	for _, a := range aliases {
+		if a.Re.MatchString(raw) {
+			return a.Payee, true
+		}
+	}
+	return "", false
+}
+
+func (a *App) cmdPayee(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm payee <subcommand> [options]
+
+Subcommands:
+  add      Add a canonical payee (optionally with an alias pattern)
+  alias    Add an alias pattern (regex on the raw bank text) to a payee
+  unalias  Remove an alias
+  list     List payees and their aliases
+  apply    Re-apply aliases to existing transactions they match
+
+Examples:
+  pfm payee add --name Lidl --pattern "(?i)\blidl\b"
+  pfm payee alias --name Lidl --pattern "(?i)^pos lidl"
+  pfm payee apply --dry-run
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "add", "alias":
+		return a.cmdPayeeAlias(args[0], args[1:])
+	case "unalias":
+		return a.cmdPayeeUnalias(args[1:])
+	case "list":
+		return a.cmdPayeeList(args[1:])
+	case "apply":
+		return a.cmdPayeeApply(args[1:])
+	default:
+		return fmt.Errorf("unknown payee subcommand: %q (try: pfm payee help)", args[0])
+	}
+}
+
+func (a *App) cmdPayeeAlias(sub string, args []string) error {
+	fs := flag.NewFlagSet("payee "+sub, flag.ContinueOnError)
+
+	name := fs.String("name", "", "Canonical payee name [required]")
+	pattern := fs.String("pattern", "", "Regex matched against the raw bank payee")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("missing required flag: --name")
+	}
+	if sub == "alias" && *pattern == "" {
+		return errors.New("missing required flag: --pattern")
+	}
+	if *pattern != "" {
+		if _, err := regexp.Compile(*pattern); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	payeeID, err := db.AddPayee(conn, *name)
+	if err != nil {
+		return err
+	}
+	if *pattern == "" {
+		fmt.Printf("Added payee #%d: %s\n", payeeID, *name)
+		return nil
+	}
+
+	aliasID, err := db.AddPayeeAlias(conn, payeeID, *pattern)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added alias #%d: %s -> %s\n", aliasID, *pattern, *name)
+	fmt.Println("Run `pfm payee apply` to update existing transactions.")
+	return nil
+}
+
+func (a *App) cmdPayeeUnalias(args []string) error {
+	fs := flag.NewFlagSet("payee unalias", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "Alias id [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if err := db.DeletePayeeAlias(conn, *id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed alias #%d\n", *id)
+	return nil
+}
+
+func (a *App) cmdPayeeList(args []string) error {
+	fs := flag.NewFlagSet("payee list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	payees, err := db.ListPayees(conn)
+	if err != nil {
+		return err
+	}
+	if len(payees) == 0 {
+		fmt.Println("No payees.")
+		return nil
+	}
+	aliases, err := db.ListPayeeAliases(conn)
+	if err != nil {
+		return err
+	}
+
+	// Each alias gets its own line under the payee.
+	t := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "PAYEE", Max: 24, Min: 8},
+		tableCol{Title: "TX", Right: true},
+		tableCol{Title: "ALIASES", Min: 12},
+	)
+	for _, p := range payees {
+		cells := []string{strconv.FormatInt(p.ID, 10), p.Name, strconv.FormatInt(p.TxCount, 10)}
+		first := true
+		for _, al := range aliases {
+			if al.PayeeID != p.ID {
+				continue
+			}
+			if !first {
+				cells = []string{"", "", ""}
+			}
+			t.add(append(cells, fmt.Sprintf("#%d %s", al.ID, al.Pattern))...)
+			first = false
+		}
+		if first {
+			t.add(cells...)
+		}
+	}
+	t.print()
+	return nil
+}
+
+func (a *App) cmdPayeeApply(args []string) error {
+	fs := flag.NewFlagSet("payee apply", flag.ContinueOnError)
+	dry := fs.Bool("dry-run", false, "Show changes without writing to DB")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	aliasRows, err := db.ListPayeeAliases(conn)
+	if err != nil {
+		return err
+	}
+	aliases, err := compileAliases(aliasRows)
+	if err != nil {
+		return err
+	}
+
+	txs, err := db.ListTxPayees(conn)
+	if err != nil {
+		return err
+	}
+
+	// Only rows an alias matches change, and never a payee edited by hand.
+	changed, manual := 0, 0
+	for _, t := range txs {
+		canon, ok := matchAlias(aliases, t.PayeeRaw)
+		if !ok || canon == "" || canon == t.Payee {
+			continue
+		}
+		if t.Manual {
+			manual++
+			continue
+		}
+		changed++
+		if *dry {
+			fmt.Printf("[DRY] #%d %q -> %q\n", t.ID, t.Payee, canon)
+			continue
+		}
+		if err := db.UpdateTxPayee(conn, t.ID, canon); err != nil {
+			return err
+		}
+	}
+
+	if *dry {
+		fmt.Printf("Would update %d transaction(s).\n", changed)
+	} else {
+		fmt.Printf("Updated %d transaction(s).\n", changed)
+	}
+	if manual > 0 {
+		fmt.Printf("Kept %d payee(s) edited by hand.\n", manual)
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"example.com/pfm/internal/db"
+)
+
+// The query language used by `search` and the TUI filter bar:
+//
+//	payee:~uber amount<-50 (cat:transport OR cat:travel) -tag:reimbursed date:2026-Q1
+//
+// Terms next to each other must all match; OR between terms (or groups)
+// accepts either; a leading - or NOT negates. AND binds tighter than OR and
+// parentheses group. A term is field:value (exact, case-insensitive),
+// field:~value (contains), a comparison for amount and date, or a bare word
+// or "quoted phrase", which is looked up in the full-text index of payee and
+// memo (word prefixes, ignoring diacritics). @name expands a saved search.
+
+// queryFields maps the names accepted in queries to db fields.
+var queryFields = map[string]string{
+	"text":     db.FieldText,
+	"payee":    db.FieldPayee,
+	"memo":     db.FieldMemo,
+	"cat":      db.FieldCategory,
+	"category": db.FieldCategory,
+	"acct":     db.FieldAccount,
+	"account":  db.FieldAccount,
+	"tag":      db.FieldTag,
+	"date":     db.FieldDate,
+	"amount":   db.FieldAmount,
+	// Older filter bar keys.
+	"month": db.FieldDate,
+	"from":  db.FieldDate,
+	"to":    db.FieldDate,
+	"min":   db.FieldAmount,
+	"max":   db.FieldAmount,
+}
+
+// maxSavedDepth stops saved searches that refer to each other.
+const maxSavedDepth = 8
+
+type queryToken struct {
+	text   string
+	quoted bool // a bare quoted phrase, never a field term or keyword
+	pos    int
+}
+
+// lexQuery splits s into parentheses and terms. Double quotes keep spaces
+// inside a value (payee:"mega image") or a phrase ("mega image").
+func lexQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(' || r == ')':
+			toks = append(toks, queryToken{text: string(r), pos: i})
+			i++
+			continue
+		}
+		start := i
+		var b strings.Builder
+		inQuote, quoted := false, r == '"'
+		for ; i < len(rs); i++ {
+			r := rs[i]
+			if r == '"' {
+				inQuote = !inQuote
+				continue
+			}
+			if !inQuote && (unicode.IsSpace(r) || r == '(' || r == ')') {
+				break
+			}
+			b.WriteRune(r)
+		}
+		if inQuote {
+			return nil, fmt.Errorf("query: unterminated quote at %d", start+1)
+		}
+		toks = append(toks, queryToken{text: b.String(), quoted: quoted && rs[i-1] == '"', pos: start})
+	}
+	return toks, nil
+}
+
+type queryParser struct {
+	toks  []queryToken
+	pos   int
+	saved func(name string) (string, error)
+	depth int
+}
+
+// parseQuery parses a query. saved looks up @name references; nil means they
+// are not allowed. An empty query is nil.
+func parseQuery(s string, saved func(name string) (string, error)) (*db.Query, error) {
+	return parseQueryDepth(s, saved, 0)
+}
+
+func parseQueryDepth(s string, saved func(name string) (string, error), depth int) (*db.Query, error) {
+	toks, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, nil
+	}
+	p := &queryParser{toks: toks, saved: saved, depth: depth}
+	q, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("query: unexpected %q", p.toks[p.pos].text)
+	}
+	return q, nil
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.toks) {
+		return queryToken{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+// keyword reports whether the next token is the unquoted keyword kw.
+func (p *queryParser) keyword(kw string) bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && t.text == kw
+}
+
+func (p *queryParser) or() (*db.Query, error) {
+	q, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	args := []*db.Query{q}
+	for p.keyword("OR") {
+		p.pos++
+		q, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, q)
+	}
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	return &db.Query{Op: db.QueryOr, Args: args}, nil
+}
+
+func (p *queryParser) and() (*db.Query, error) {
+	var args []*db.Query
+	for {
+		t, ok := p.peek()
+		if !ok || p.keyword("OR") || (t.text == ")" && !t.quoted) {
+			break
+		}
+		if p.keyword("AND") {
+			p.pos++
+			continue
+		}
+		q, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, q)
+	}
+	if len(args) == 0 {
+		if t, ok := p.peek(); ok {
+			return nil, fmt.Errorf("query: expected a term before %q", t.text)
+		}
+		return nil, fmt.Errorf("query: expected a term at the end")
+	}
+	return db.And(args...), nil
+}
+
+func (p *queryParser) not() (*db.Query, error) {
+	t, _ := p.peek()
+	negate := false
+	switch {
+	case p.keyword("NOT"):
+		p.pos++
+		negate = true
+	case !t.quoted && len(t.text) > 1 && t.text[0] == '-' && !isDigit(t.text[1]):
+		p.toks[p.pos].text = t.text[1:]
+		negate = true
+	case !t.quoted && t.text == "-":
+		p.pos++
+		negate = true
+	}
+	q, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if negate {
+		return &db.Query{Op: db.QueryNot, Args: []*db.Query{q}}, nil
+	}
+	return q, nil
+}
+
+func (p *queryParser) primary() (*db.Query, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("query: expected a term at the end")
+	}
+	if !t.quoted && t.text == "(" {
+		p.pos++
+		q, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c.quoted || c.text != ")" {
+			return nil, fmt.Errorf("query: missing ) for ( at %d", t.pos+1)
+		}
+		p.pos++
+		return q, nil
+	}
+	if !t.quoted && t.text == ")" {
+		return nil, fmt.Errorf("query: unexpected )")
+	}
+	p.pos++
+	if t.quoted {
+		return &db.Query{Op: db.QueryTerm, Field: db.FieldText, Cmp: "match", Value: `"` + t.text + `"`}, nil
+	}
+	if strings.HasPrefix(t.text, "@") && len(t.text) > 1 {
+		return p.savedSearch(t.text[1:])
+	}
+	return parseTerm(t.text)
+}
+
+func (p *queryParser) savedSearch(name string) (*db.Query, error) {
+	if p.saved == nil {
+		return nil, fmt.Errorf("query: saved searches are not available here (@%s)", name)
+	}
+	if p.depth >= maxSavedDepth {
+		return nil, fmt.Errorf("query: saved searches nest too deeply at @%s", name)
+	}
+	s, err := p.saved(name)
+	if err != nil {
+		return nil, err
+	}
+	q, err := parseQueryDepth(s, p.saved, p.depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("saved search %q: %w", name, err)
+	}
+	if q == nil {
+		return nil, fmt.Errorf("saved search %q is empty", name)
+	}
+	return q, nil
+}
+
+// validSearchName reports whether name can follow @ in a query.
+func validSearchName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// queryOps are the operators after a field name, longest first.
+var queryOps = []string{":~", "<=", ">=", ":", "~", "<", ">", "="}
+
+// parseTerm parses one term. A word that does not start with a field name
+// and an operator is searched for as text; name: with an unknown name is an
+// error, so a mistyped field does not silently become a text search.
+func parseTerm(s string) (*db.Query, error) {
+	i := 0
+	for i < len(s) && (s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z') {
+		i++
+	}
+	name, rest := strings.ToLower(s[:i]), s[i:]
+	op := ""
+	for _, o := range queryOps {
+		if strings.HasPrefix(rest, o) {
+			op = o
+			break
+		}
+	}
+	field, ok := queryFields[name]
+	if op == "" || !ok && op != ":" && op != ":~" {
+		return &db.Query{Op: db.QueryTerm, Field: db.FieldText, Cmp: "match", Value: s}, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("query: unknown field %q in %q (quote it to search for the text)", name, s)
+	}
+	val := rest[len(op):]
+	if val == "" {
+		return nil, fmt.Errorf("query: missing value in %q", s)
+	}
+
+	switch name {
+	case "month":
+		if _, err := parseMonth(val); err != nil {
+			return nil, err
+		}
+		op = ":"
+	case "from":
+		op = ">="
+	case "to":
+		op = "<="
+	case "min":
+		op = ">="
+	case "max":
+		op = "<="
+	}
+
+	switch field {
+	case db.FieldDate:
+		return dateTerm(op, val)
+	case db.FieldAmount:
+		return amountTerm(op, val)
+	}
+	switch op {
+	case ":":
+		return &db.Query{Op: db.QueryTerm, Field: field, Cmp: "=", Value: val}, nil
+	case ":~", "~":
+		return &db.Query{Op: db.QueryTerm, Field: field, Cmp: "~", Value: val}, nil
+	}
+	return nil, fmt.Errorf("query: %s does not support %s (use %s: or %s:~)", name, op, name, name)
+}
+
+// cmpTerm is a comparison term; cmp ":" and "=" mean equality.
+func cmpTerm(field, cmp string, v any) *db.Query {
+	if cmp == ":" {
+		cmp = "="
+	}
+	return &db.Query{Op: db.QueryTerm, Field: field, Cmp: cmp, Value: v}
+}
+
+// rangeTerm matches lo <= field <= hi; either bound may be nil.
+func rangeTerm(field string, lo, hi any) *db.Query {
+	var lq, hq *db.Query
+	if lo != nil {
+		lq = cmpTerm(field, ">=", lo)
+	}
+	if hi != nil {
+		hq = cmpTerm(field, "<=", hi)
+	}
+	return db.And(lq, hq)
+}
+
+// amountTerm parses amount:X, amount:A..B and amount<X style terms. Amounts
+// are RON; expenses are negative.
+func amountTerm(op, val string) (*db.Query, error) {
+	if op == ":~" || op == "~" {
+		return nil, fmt.Errorf("query: amount does not support %s", op)
+	}
+	if op == ":" {
+		if lo, hi, ok := strings.Cut(val, ".."); ok {
+			var bounds [2]any
+			for i, s := range []string{lo, hi} {
+				if s == "" {
+					continue
+				}
+				v, err := ParseRON(s)
+				if err != nil {
+					return nil, fmt.Errorf("query: invalid amount %q: %w", s, err)
+				}
+				bounds[i] = v
+			}
+			q := rangeTerm(db.FieldAmount, bounds[0], bounds[1])
+			if q == nil {
+				return nil, fmt.Errorf("query: empty amount range %q", val)
+			}
+			return q, nil
+		}
+	}
+	v, err := ParseRON(val)
+	if err != nil {
+		return nil, fmt.Errorf("query: invalid amount %q: %w", val, err)
+	}
+	return cmpTerm(db.FieldAmount, op, v), nil
+}
+
+// datePeriod is the first and last day of a YYYY, YYYY-Qn, YYYY-MM or
+// YYYY-MM-DD period.
+func datePeriod(s string) (time.Time, time.Time, error) {
+	invalid := fmt.Errorf("query: invalid date %q (expected YYYY, YYYY-Qn, YYYY-MM or YYYY-MM-DD)", s)
+	switch len(s) {
+	case 4:
+		y, err := strconv.Atoi(s)
+		if err != nil {
+			return time.Time{}, time.Time{}, invalid
+		}
+		start := time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1), nil
+	case 7:
+		if s[5] == 'Q' || s[5] == 'q' {
+			y, err := strconv.Atoi(s[:4])
+			if err != nil || s[4] != '-' || s[6] < '1' || s[6] > '4' {
+				return time.Time{}, time.Time{}, invalid
+			}
+			start := time.Date(y, time.Month(3*int(s[6]-'1')+1), 1, 0, 0, 0, 0, time.UTC)
+			return start, start.AddDate(0, 3, -1), nil
+		}
+		start, err := time.Parse("2006-01", s)
+		if err != nil {
+			return time.Time{}, time.Time{}, invalid
+		}
+		return start, start.AddDate(0, 1, -1), nil
+	case 10:
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return time.Time{}, time.Time{}, invalid
+		}
+		return d, d, nil
+	}
+	return time.Time{}, time.Time{}, invalid
+}
+
+// dateTerm parses date:P, date:A..B and date<P style terms, where each side
+// is a period: date<2026-02 is before February, date<=2026-02 up to its end.
+func dateTerm(op, val string) (*db.Query, error) {
+	day := func(t time.Time) any { return t.Format("2006-01-02") }
+	switch op {
+	case ":~", "~":
+		return nil, fmt.Errorf("query: date does not support %s", op)
+	case ":", "=":
+		lo, hi, isRange := strings.Cut(val, "..")
+		if !isRange {
+			hi = lo
+		}
+		var from, to any
+		if lo != "" {
+			start, _, err := datePeriod(lo)
+			if err != nil {
+				return nil, err
+			}
+			from = day(start)
+		}
+		if hi != "" {
+			_, end, err := datePeriod(hi)
+			if err != nil {
+				return nil, err
+			}
+			to = day(end)
+		}
+		if from == nil && to == nil {
+			return nil, fmt.Errorf("query: empty date range %q", val)
+		}
+		return rangeTerm(db.FieldDate, from, to), nil
+	}
+	start, end, err := datePeriod(val)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case "<", ">=":
+		return cmpTerm(db.FieldDate, op, day(start)), nil
+	default: // "<=", ">"
+		return cmpTerm(db.FieldDate, op, day(end)), nil
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"example.com/pfm/internal/db"
+)
+
+// queryString renders q compactly, e.g. and(amount<-5000, not(tag=x)).
+func queryString(q *db.Query) string {
+	if q == nil {
+		return "<nil>"
+	}
+	if q.Op == db.QueryTerm {
+		return fmt.Sprintf("%s%s%v", q.Field, q.Cmp, q.Value)
+	}
+	args := make([]string, len(q.Args))
+	for i, a := range q.Args {
+		args[i] = queryString(a)
+	}
+	return q.Op + "(" + strings.Join(args, ", ") + ")"
+}
+
+func TestLexQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  uber  ", []string{"uber"}},
+		{"(a OR b)c", []string{"(", "a", "OR", "b", ")", "c"}},
+		{`payee:"mega image" x`, []string{"payee:mega image", "x"}},
+		{`"mega image"`, []string{"mega image"}},
+		{"Ștefan", []string{"Ștefan"}},
+	}
+	for _, tt := range tests {
+		toks, err := lexQuery(tt.in)
+		if err != nil {
+			t.Errorf("lexQuery(%q): %v", tt.in, err)
+			continue
+		}
+		var got []string
+		for _, tok := range toks {
+			got = append(got, tok.text)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("lexQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLexQueryQuoted(t *testing.T) {
+	toks, err := lexQuery(`"OR" payee:"x"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !toks[0].quoted || toks[1].quoted {
+		t.Errorf("quoted = %v, %v; want true, false", toks[0].quoted, toks[1].quoted)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "<nil>"},
+		{"uber", "textmatchuber"},
+		{`"mega image"`, `textmatch"mega image"`},
+		{"payee:~uber", "payee~uber"},
+		{"cat:transport", "category=transport"},
+		{"amount<-50", "amount<-5000"},
+		{"amount>=12.5", "amount>=1250"},
+		{"amount:-100..-50", "and(amount>=-10000, amount<=-5000)"},
+		{"amount:..0", "amount<=0"},
+		{"min:10", "amount>=1000"},
+		{"-tag:x", "not(tag=x)"},
+		{"NOT tag:x", "not(tag=x)"},
+		{"- tag:x", "not(tag=x)"},
+		{"-50", "textmatch-50"},
+		{"date:2026", "and(date>=2026-01-01, date<=2026-12-31)"},
+		{"date:2026-Q1", "and(date>=2026-01-01, date<=2026-03-31)"},
+		{"date:2026-q4", "and(date>=2026-10-01, date<=2026-12-31)"},
+		{"date:2026-02", "and(date>=2026-02-01, date<=2026-02-28)"},
+		{"date:2026-02-10", "and(date>=2026-02-10, date<=2026-02-10)"},
+		{"date:2026-01..2026-Q2", "and(date>=2026-01-01, date<=2026-06-30)"},
+		{"date:2026-03..", "date>=2026-03-01"},
+		{"date<2026-02", "date<2026-02-01"},
+		{"date<=2026-02", "date<=2026-02-28"},
+		{"date>2026-Q1", "date>2026-03-31"},
+		{"month:2026-05", "and(date>=2026-05-01, date<=2026-05-31)"},
+		{"uber amount<0", "and(textmatchuber, amount<0)"},
+		{"a AND b", "and(textmatcha, textmatchb)"},
+		{"a OR b c", "or(textmatcha, and(textmatchb, textmatchc))"},
+		{"(a OR b) c", "and(or(textmatcha, textmatchb), textmatchc)"},
+		{"((a OR (b -c)) OR d)", "or(or(textmatcha, and(textmatchb, not(textmatchc))), textmatchd)"},
+		{"-(cat:a OR cat:b)", "not(or(category=a, category=b))"},
+		{`"OR"`, `textmatch"OR"`},
+		{`"http://x"`, `textmatch"http://x"`},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.in, nil)
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", tt.in, err)
+			continue
+		}
+		if got := queryString(q); got != tt.want {
+			t.Errorf("parseQuery(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`payee:"mega`, "unterminated quote"},
+		{`"mega`, "unterminated quote"},
+		{"(a OR b", "missing )"},
+		{"a )", `unexpected ")"`},
+		{"a OR", "expected a term at the end"},
+		{"OR a", `expected a term before "OR"`},
+		{"NOT", "expected a term at the end"},
+		{"payy:uber", `unknown field "payy"`},
+		{"http://x", `unknown field "http"`},
+		{"payee:", "missing value"},
+		{"amount:abc", "invalid amount"},
+		{"amount~5", "amount does not support ~"},
+		{"amount:..", "empty amount range"},
+		{"date:2026-Q5", "invalid date"},
+		{"date:26", "invalid date"},
+		{"date:..", "empty date range"},
+		{"date~2026", "date does not support ~"},
+		{"cat<a", "cat does not support <"},
+		{"@food", "saved searches are not available"},
+	}
+	for _, tt := range tests {
+		_, err := parseQuery(tt.in, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseQuery(%q) error = %v, want it to contain %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestParseQuerySaved(t *testing.T) {
+	searches := map[string]string{
+		"food":  "cat:groceries OR cat:restaurants",
+		"big":   "amount<-500",
+		"both":  "@food @big",
+		"self":  "uber @self",
+		"ping":  "@pong",
+		"pong":  "@ping",
+		"empty": "",
+		"bad":   "amount:x",
+	}
+	saved := func(name string) (string, error) {
+		s, ok := searches[name]
+		if !ok {
+			return "", errors.New("no saved search " + name)
+		}
+		return s, nil
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"@food", "or(category=groceries, category=restaurants)"},
+		{"@both -tag:x", "and(and(or(category=groceries, category=restaurants), amount<-50000), not(tag=x))"},
+		{"-@big", "not(amount<-50000)"},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.in, saved)
+		if err != nil {
+			t.Errorf("parseQuery(%q): %v", tt.in, err)
+			continue
+		}
+		if got := queryString(q); got != tt.want {
+			t.Errorf("parseQuery(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	errs := []struct {
+		in   string
+		want string
+	}{
+		{"@self", "nest too deeply"},
+		{"@ping", "nest too deeply"},
+		{"@empty", `saved search "empty" is empty`},
+		{"@bad", `saved search "bad": query: invalid amount`},
+		{"@nope", "no saved search nope"},
+	}
+	for _, tt := range errs {
+		_, err := parseQuery(tt.in, saved)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseQuery(%q) error = %v, want it to contain %q", tt.in, err, tt.want)
+		}
+	}
+}
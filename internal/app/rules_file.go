@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"example.com/pfm/internal/db"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleFile is the on-disk format used by `pfm rule export` and `pfm rule import`.
+// The format (JSON or YAML) is chosen by file extension.
+type ruleFile struct {
+	Rules []ruleSpec `json:"rules" yaml:"rules"`
+}
+
+type ruleSpec struct {
+	Name     string `json:"name" yaml:"name"`
+	Pattern  string `json:"pattern" yaml:"pattern"`
+	Category string `json:"category" yaml:"category"`
+	Priority *int64 `json:"priority" yaml:"priority"`
+	Enabled  *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func writeRuleFile(path string, rows []db.RuleRow) error {
+	var rf ruleFile
+	for _, r := range rows {
+		priority := r.Priority
+		spec := ruleSpec{Name: r.Name, Pattern: r.Pattern, Category: r.Category, Priority: &priority}
+		if !r.Enabled {
+			disabled := false
+			spec.Enabled = &disabled
+		}
+		rf.Rules = append(rf.Rules, spec)
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if isYAMLPath(path) {
+		b, err = yaml.Marshal(rf)
+	} else {
+		b, err = json.MarshalIndent(rf, "", "  ")
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("encode rules: %w", err)
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func readRuleFile(path string) ([]db.RuleRow, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf ruleFile
+	if isYAMLPath(path) {
+		err = yaml.Unmarshal(b, &rf)
+	} else {
+		err = json.Unmarshal(b, &rf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	out := make([]db.RuleRow, 0, len(rf.Rules))
+	for i, s := range rf.Rules {
+		if s.Name == "" || s.Pattern == "" || s.Category == "" {
+			return nil, fmt.Errorf("rule %d: name, pattern and category are required", i+1)
+		}
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return nil, fmt.Errorf("rule %d (%s) bad regex: %w", i+1, s.Name, err)
+		}
+		// A missing priority gets the default; an explicit 0 is kept.
+		priority := int64(100)
+		if s.Priority != nil {
+			priority = *s.Priority
+		}
+		out = append(out, db.RuleRow{
+			Name:     s.Name,
+			Pattern:  s.Pattern,
+			Category: s.Category,
+			Priority: priority,
+			Enabled:  s.Enabled == nil || *s.Enabled,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,226 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"example.com/pfm/internal/db"
+)
+
+// cmdList and cmdSearch are the same command: list is search without a
+// query, newest first.
+func (a *App) cmdList(args []string) error {
+	return a.searchCommand("list", args)
+}
+
+func (a *App) cmdSearch(args []string) error {
+	return a.searchCommand("search", args)
+}
+
+func (a *App) searchCommand(name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	ff := addFilterFlags(fs)
+	limit := fs.Int("limit", 200, "Max rows to show")
+	offset := fs.Int("offset", 0, "Skip this many rows (with --limit, pages through the results)")
+	sortBy := fs.String("sort", "", "Order by date|amount|payee|category|account (default: best matches first for text, else date)")
+	asc := fs.Bool("asc", false, "Sort ascending (default descending)")
+	groupBy := fs.String("group-by", "", "Aggregate by category|payee|account|month|week")
+	sum := fs.Bool("sum", false, "Aggregate all matches into counts, totals, averages and medians")
+	save := fs.String("save", "", "Save the query under this name (recall it with @name)")
+	listSaved := fs.Bool("saved", false, "List saved searches")
+	forget := fs.String("forget", "", "Delete a saved search")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	queryText := strings.TrimSpace(strings.Join(fs.Args(), " "))
+
+	if *sortBy != "" && !db.ValidSort(*sortBy) {
+		return fmt.Errorf("invalid --sort %q (expected %s)", *sortBy, strings.Join(db.SortColumns, "|"))
+	}
+	if *groupBy != "" && !db.ValidGroup(*groupBy) {
+		return fmt.Errorf("invalid --group-by %q (expected %s)", *groupBy, strings.Join(db.GroupColumns, "|"))
+	}
+	if *offset < 0 {
+		return errors.New("--offset must not be negative")
+	}
+
+	f, err := ff.build(*limit)
+	if err != nil {
+		return err
+	}
+	f.Offset = *offset
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if *listSaved {
+		saved, err := db.ListSavedSearches(conn)
+		if err != nil {
+			return err
+		}
+		if len(saved) == 0 {
+			fmt.Println("No saved searches.")
+			return nil
+		}
+		t := newTable(tableCol{Title: "NAME"}, tableCol{Title: "QUERY", Min: 20})
+		for _, s := range saved {
+			t.add("@"+s.Name, s.Query)
+		}
+		t.print()
+		return nil
+	}
+	if *forget != "" {
+		name := strings.TrimPrefix(*forget, "@")
+		if err := db.DeleteSavedSearch(conn, name); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted saved search @%s\n", name)
+		return nil
+	}
+
+	if f.Query, err = parseQuery(queryText, func(name string) (string, error) {
+		return db.GetSavedSearch(conn, name)
+	}); err != nil {
+		return err
+	}
+	if *save != "" {
+		name := strings.TrimPrefix(*save, "@")
+		if !validSearchName(name) {
+			return fmt.Errorf("invalid saved search name %q (use letters, digits, - and _)", name)
+		}
+		if f.Query == nil {
+			return errors.New("--save needs a query")
+		}
+		if queryText == "@"+name {
+			return fmt.Errorf("saved search @%s cannot refer to itself", name)
+		}
+		if err := db.SaveSearch(conn, name, queryText); err != nil {
+			return err
+		}
+		fmt.Printf("Saved search @%s\n", name)
+	}
+
+	if *groupBy != "" || *sum {
+		groups, err := db.SummarizeTransactions(conn, f, *groupBy)
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			fmt.Println("No transactions found.")
+			return nil
+		}
+		a.printGroups(groups, *groupBy)
+		return nil
+	}
+
+	// Searches for text list the best matches first unless told otherwise.
+	switch {
+	case *sortBy != "":
+		f.Sort = *sortBy
+	case f.HasText():
+		f.Sort = db.SortRelevance
+	}
+	f.Asc = *asc
+
+	rows, err := db.SearchTransactions(conn, f)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		if f.Offset > 0 {
+			fmt.Printf("No transactions after the first %d.\n", f.Offset)
+		} else {
+			fmt.Println("No transactions found.")
+		}
+		return nil
+	}
+	total := len(rows)
+	if f.Offset > 0 || len(rows) == f.Limit {
+		if total, err = db.CountTransactions(conn, f); err != nil {
+			return err
+		}
+	}
+
+	a.printTransactions(rows, f.Offset, total)
+	return nil
+}
+
+// printTransactions prints the table shared by list and search, with the
+// net total of the rows shown. offset and total place the rows within all
+// the matches.
+func (a *App) printTransactions(rows []db.TxRow, offset, total int) {
+	t := newTable(
+		tableCol{Title: "ID"},
+		tableCol{Title: "DATE"},
+		tableCol{Title: "ACCOUNT", Max: 10, Min: 7},
+		tableCol{Title: "PAYEE", Max: 40, Min: 12},
+		tableCol{Title: "AMOUNT", Right: true},
+		tableCol{Title: "CATEGORY", Min: 8},
+	)
+	var net int64
+	for _, r := range rows {
+		net += r.AmountBani
+		t.add(
+			strconv.FormatInt(r.ID, 10),
+			a.disp.date(r.PostedAt),
+			r.Account,
+			r.Payee,
+			a.disp.money(r.AmountBani),
+			categoryWithTags(r),
+		)
+	}
+	t.print()
+
+	if offset == 0 && len(rows) == total {
+		fmt.Printf("\nShown: %d   Net total: %s\n", len(rows), a.disp.money(net))
+		return
+	}
+	fmt.Printf("\nShown: %d-%d of %d   Net total of shown: %s\n", offset+1, offset+len(rows), total, a.disp.money(net))
+}
+
+// printGroups prints the aggregated table of search --group-by / --sum.
+func (a *App) printGroups(groups []db.TxGroup, groupBy string) {
+	var cols []tableCol
+	if groupBy != "" {
+		cols = append(cols, tableCol{Title: strings.ToUpper(groupBy), Max: 40, Min: 12})
+	}
+	cols = append(cols,
+		tableCol{Title: "COUNT", Right: true},
+		tableCol{Title: "TOTAL", Right: true},
+		tableCol{Title: "AVG", Right: true},
+		tableCol{Title: "MEDIAN", Right: true},
+	)
+	t := newTable(cols...)
+	var count int
+	var net int64
+	for _, g := range groups {
+		count += g.Count
+		net += g.TotalBani
+		cells := []string{strconv.Itoa(g.Count), a.disp.money(g.TotalBani), a.disp.money(g.AvgBani), a.disp.money(g.MedianBani)}
+		if groupBy != "" {
+			key := g.Key
+			if key == "" {
+				key = "(none)"
+			}
+			cells = append([]string{key}, cells...)
+		}
+		t.add(cells...)
+	}
+	t.print()
+
+	if groupBy != "" {
+		fmt.Printf("\nGroups: %d   Transactions: %d   Net total: %s\n", len(groups), count, a.disp.money(net))
+	}
+}
@@ -0,0 +1,325 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"example.com/pfm/internal/db"
+)
+
+// classifier is a multinomial naive Bayes model over normalized payee/memo
+// tokens plus an amount bucket. It is trained from already categorized
+// transactions and runs entirely offline.
+type classifier struct {
+	docs      int
+	catDocs   map[string]int
+	catTokens map[string]int
+	tokens    map[string]map[string]int
+	vocab     map[string]bool
+}
+
+// noiseTokens carry no information about the merchant.
+var noiseTokens = map[string]bool{
+	"pos": true, "srl": true, "sa": true, "ro": true, "ron": true, "com": true,
+	"www": true, "plata": true, "card": true, "the": true, "and": true,
+}
+
+var diacritics = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "a", "Â", "a", "Î", "i", "Ș", "s", "Ş", "s", "Ț", "t", "Ţ", "t",
+)
+
+// normalizeTokens lowercases, strips diacritics and digits, and drops short
+// or noisy words, so "POS LIDL 0342 BUCUREȘTI" becomes [lidl bucuresti].
+func normalizeTokens(s string) []string {
+	s = strings.ToLower(diacritics.Replace(s))
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	})
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len(f) < 2 || noiseTokens[f] {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// amountBucket groups amounts by sign and order of magnitude.
+func amountBucket(bani int64) string {
+	sign := "in"
+	if bani < 0 {
+		sign = "out"
+		bani = -bani
+	}
+	mag := 0
+	for lei := bani / 100; lei >= 10; lei /= 10 {
+		mag++
+	}
+	return fmt.Sprintf("amt:%s:%d", sign, mag)
+}
+
+func features(payee, memo string, amountBani int64) []string {
+	toks := normalizeTokens(payee + " " + memo)
+	return append(toks, amountBucket(amountBani))
+}
+
+func trainClassifier(samples []db.TxForCategorize) *classifier {
+	c := &classifier{
+		catDocs:   map[string]int{},
+		catTokens: map[string]int{},
+		tokens:    map[string]map[string]int{},
+		vocab:     map[string]bool{},
+	}
+	for _, s := range samples {
+		c.learn(s)
+	}
+	return c
+}
+
+// learn adds one categorized transaction to the model.
+func (c *classifier) learn(s db.TxForCategorize) {
+	c.docs++
+	c.catDocs[s.Category]++
+	if c.tokens[s.Category] == nil {
+		c.tokens[s.Category] = map[string]int{}
+	}
+	for _, tok := range features(s.Payee, s.Memo, s.AmountBani) {
+		c.tokens[s.Category][tok]++
+		c.catTokens[s.Category]++
+		c.vocab[tok] = true
+	}
+}
+
+type scoredCategory struct {
+	Category string
+	Prob     float64
+}
+
+// rank returns every known category with its posterior probability, most
+// likely first.
+func (c *classifier) rank(payee, memo string, amountBani int64) []scoredCategory {
+	if c.docs == 0 {
+		return nil
+	}
+
+	feats := features(payee, memo, amountBani)
+	vocab := float64(len(c.vocab))
+
+	cats := make([]string, 0, len(c.catDocs))
+	for cat := range c.catDocs {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+
+	scores := make([]float64, len(cats))
+	best := 0
+	for i, cat := range cats {
+		score := math.Log(float64(c.catDocs[cat]) / float64(c.docs))
+		denom := float64(c.catTokens[cat]) + vocab
+		for _, tok := range feats {
+			score += math.Log((float64(c.tokens[cat][tok]) + 1) / denom)
+		}
+		scores[i] = score
+		if score > scores[best] {
+			best = i
+		}
+	}
+
+	var sum float64
+	for _, sc := range scores {
+		sum += math.Exp(sc - scores[best])
+	}
+	out := make([]scoredCategory, len(cats))
+	for i, cat := range cats {
+		out[i] = scoredCategory{Category: cat, Prob: math.Exp(scores[i]-scores[best]) / sum}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Prob > out[j].Prob })
+	return out
+}
+
+// suggest returns the most likely category and its posterior probability.
+func (c *classifier) suggest(payee, memo string, amountBani int64) (string, float64) {
+	r := c.rank(payee, memo, amountBani)
+	if len(r) == 0 {
+		return "", 0
+	}
+	return r[0].Category, r[0].Prob
+}
+
+// payeeKeyword is the first significant word of payee: its normalized token,
+// which proposals are grouped by, and the word as written (lowercased), which
+// rule patterns are built from so that they match the payee itself.
+func payeeKeyword(payee string) (tok, word string, ok bool) {
+	for _, w := range strings.FieldsFunc(payee, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if toks := normalizeTokens(w); len(toks) > 0 {
+			return toks[0], strings.ToLower(w), true
+		}
+	}
+	return "", "", false
+}
+
+// tokenPattern is the rule pattern proposed for a payee word: the word
+// between non-letters, case-insensitive, as payeeKeyword splits it. \b only
+// knows ASCII word characters, so it would not match next to "ș".
+func tokenPattern(word string) string {
+	return `(?i)(?:^|\PL)` + regexp.QuoteMeta(word) + `(?:$|\PL)`
+}
+
+type ruleProposal struct {
+	Token    string
+	Word     string // as written in the first payee seen
+	Category string
+	Support  int
+	Share    float64
+}
+
+// proposeRules clusters categorized transactions by the first significant
+// payee token and proposes a rule for clusters that consistently land in one
+// category and are not already handled by an existing rule.
+func proposeRules(samples []db.TxForCategorize, rules []compiledRule, minSupport int) []ruleProposal {
+	byToken := map[string]map[string]int{}
+	words := map[string]string{}
+	covered := map[string]bool{}
+	for _, s := range samples {
+		key, word, ok := payeeKeyword(s.Payee)
+		if !ok {
+			continue
+		}
+		if byToken[key] == nil {
+			byToken[key] = map[string]int{}
+			words[key] = word
+		}
+		byToken[key][s.Category]++
+		if cat, _ := matchCategory(rules, s.Payee, s.PayeeRaw, s.Memo); cat != "" {
+			covered[key] = true
+		}
+	}
+
+	var out []ruleProposal
+	for tok, cats := range byToken {
+		if covered[tok] {
+			continue
+		}
+		total, bestCat, bestN := 0, "", 0
+		for cat, n := range cats {
+			total += n
+			if n > bestN || (n == bestN && cat < bestCat) {
+				bestCat, bestN = cat, n
+			}
+		}
+		share := float64(bestN) / float64(total)
+		if total < minSupport || share < 0.9 {
+			continue
+		}
+		out = append(out, ruleProposal{Token: tok, Word: words[tok], Category: bestCat, Support: total, Share: share})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Support != out[j].Support {
+			return out[i].Support > out[j].Support
+		}
+		return out[i].Token < out[j].Token
+	})
+	return out
+}
+
+type suggestOptions struct {
+	Accept    bool
+	Threshold float64
+	Propose   bool
+	DryRun    bool
+}
+
+func (a *App) categorizeSuggest(conn *sql.DB, txs []db.TxForCategorize, opts suggestOptions) error {
+	samples, err := db.ListCategorizedTx(conn)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		fmt.Println("No categorized transactions to learn from yet.")
+		return nil
+	}
+
+	c := trainClassifier(samples)
+	fmt.Printf("Trained on %d categorized transaction(s), %d categories.\n\n", c.docs, len(c.catDocs))
+
+	if len(txs) == 0 {
+		fmt.Println("No uncategorized transactions found.")
+	} else {
+		tbl := newTable(
+			tableCol{Title: "ID", Right: true},
+			tableCol{Title: "DATE"},
+			tableCol{Title: "PAYEE", Max: 30, Min: 10},
+			tableCol{Title: "SUGGESTED", Max: 18, Min: 8},
+			tableCol{Title: "CONF", Right: true},
+			tableCol{Title: "ACTION"},
+		)
+
+		accepted := 0
+		for _, t := range txs {
+			cat, conf := c.suggest(t.Payee, t.Memo, t.AmountBani)
+			if cat == t.Category {
+				continue
+			}
+
+			action := ""
+			if opts.Accept {
+				action = "below threshold"
+				if conf >= opts.Threshold {
+					action = "accepted"
+					if opts.DryRun {
+						action = "would accept"
+					} else if err := db.UpdateTxCategory(conn, t.ID, cat, db.CategorySuggest); err != nil {
+						return err
+					}
+					accepted++
+				}
+			}
+
+			tbl.add(strconv.FormatInt(t.ID, 10), a.disp.dateText(t.PostedAt), t.Payee, cat, fmt.Sprintf("%.0f%%", conf*100), action)
+		}
+		tbl.print()
+
+		if opts.Accept {
+			verb := "Accepted"
+			if opts.DryRun {
+				verb = "Would accept"
+			}
+			fmt.Printf("\n%s %d suggestion(s) at >= %.0f%% confidence.\n", verb, accepted, opts.Threshold*100)
+		}
+	}
+
+	if !opts.Propose {
+		return nil
+	}
+
+	ruleRows, err := db.ListRules(conn)
+	if err != nil {
+		return err
+	}
+	rules, err := compileRules(ruleRows)
+	if err != nil {
+		return err
+	}
+
+	proposals := proposeRules(samples, rules, 3)
+	fmt.Println("\nProposed rules:")
+	if len(proposals) == 0 {
+		fmt.Println("  (none)")
+		return nil
+	}
+	for _, p := range proposals {
+		pattern := tokenPattern(p.Word)
+		fmt.Printf("  pfm rule add --name %s --pattern '%s' --category %s   # %d tx, %.0f%% consistent\n",
+			p.Token, pattern, p.Category, p.Support, p.Share*100)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"regexp"
+	"testing"
+
+	"example.com/pfm/internal/db"
+)
+
+func TestPayeeKeyword(t *testing.T) {
+	tests := []struct {
+		payee, tok, word string
+	}{
+		{"POS LIDL 0342 BUCUREȘTI", "lidl", "lidl"},
+		{"Cofetaria Ștefan", "cofetaria", "cofetaria"},
+		{"ȘTEFAN SRL", "stefan", "ștefan"},
+		{"PLATA 1234 Țuică", "tuica", "țuică"},
+	}
+	for _, tt := range tests {
+		tok, word, ok := payeeKeyword(tt.payee)
+		if !ok || tok != tt.tok || word != tt.word {
+			t.Errorf("payeeKeyword(%q) = %q, %q, %v; want %q, %q", tt.payee, tok, word, ok, tt.tok, tt.word)
+		}
+	}
+	if _, _, ok := payeeKeyword("POS 1234 SRL"); ok {
+		t.Error("payeeKeyword of noise only: want none")
+	}
+}
+
+func TestTokenPattern(t *testing.T) {
+	tests := []struct {
+		word, text string
+		want       bool
+	}{
+		{"ștefan", "Cofetaria Ștefan", true},
+		{"ștefan", "ȘTEFAN SRL", true},
+		{"ștefan", "Ștefan-Vodă 12", true},
+		{"ștefan", "Ștefania", false},
+		{"ștefan", "Stefan", false},
+		{"lidl", "POS LIDL 0342", true},
+		{"lidl", "LIDL0342", true},
+		{"lidl", "LIDLX", false},
+		{"țuică", "BAR ȚUICĂ", true},
+		{"a.b", "axb", false},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(tokenPattern(tt.word))
+		if got := re.MatchString(tt.text); got != tt.want {
+			t.Errorf("tokenPattern(%q) on %q = %v, want %v", tt.word, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestProposeRulesMatchOwnPayees(t *testing.T) {
+	var samples []db.TxForCategorize
+	for _, p := range []string{"ȘTEFAN SRL", "Ștefan cel Mare", "ștefan 12"} {
+		samples = append(samples, db.TxForCategorize{Payee: p, Category: "groceries"})
+	}
+	props := proposeRules(samples, nil, 3)
+	if len(props) != 1 || props[0].Token != "stefan" || props[0].Category != "groceries" {
+		t.Fatalf("proposeRules = %+v, want one stefan -> groceries", props)
+	}
+	re := regexp.MustCompile(tokenPattern(props[0].Word))
+	for _, s := range samples {
+		if !re.MatchString(s.Payee) {
+			t.Errorf("proposed pattern %s does not match %q", re, s.Payee)
+		}
+	}
+}
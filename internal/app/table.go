@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// Table text is measured in terminal cells rather than bytes, so payees with
+// diacritics, CJK or emoji keep columns aligned, and is cut on grapheme
+// boundaries so it stays valid UTF-8.
+
+const ellipsis = "…"
+
+// textWidth is the number of terminal cells s takes.
+func textWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// trunc shortens s to at most n cells, ending with an ellipsis when it cuts.
+func trunc(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(s, n, ellipsis)
+}
+
+// padRight left-aligns s in n cells.
+func padRight(s string, n int) string {
+	if w := textWidth(s); w < n {
+		return s + strings.Repeat(" ", n-w)
+	}
+	return s
+}
+
+// padLeft right-aligns s in n cells.
+func padLeft(s string, n int) string {
+	if w := textWidth(s); w < n {
+		return strings.Repeat(" ", n-w) + s
+	}
+	return s
+}
+
+// cell is s truncated and padded to exactly n cells, the width-aware
+// equivalent of %-*s for fixed-width columns.
+func cell(s string, n int) string {
+	return padRight(trunc(s, n), n)
+}
+
+// terminalWidth is the width of the terminal on stdout, $COLUMNS when stdout
+// is not a terminal, or 0 (no limit) when neither is known.
+func terminalWidth() int {
+	if term.IsTerminal(os.Stdout.Fd()) {
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			return w
+		}
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// tableCol describes one column of a table.
+type tableCol struct {
+	Title string
+	Right bool // right-align, for amounts and counts
+	Max   int  // cells wider than this are truncated; 0 means no cap
+	Min   int  // the column may shrink to this width to fit the terminal; 0 means it never shrinks
+}
+
+// table renders rows of text as aligned columns under a title row and a
+// dashed rule, the layout used by every listing command.
+type table struct {
+	cols []tableCol
+	rows [][]string
+}
+
+func newTable(cols ...tableCol) *table {
+	return &table{cols: cols}
+}
+
+// add appends a row; missing cells are left empty.
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// rule appends a dashed line, such as the one above a totals row.
+func (t *table) rule() {
+	t.rows = append(t.rows, nil)
+}
+
+// widths sizes each column to its widest cell, then shrinks the shrinkable
+// columns, widest first, until the table fits in width (0: no limit).
+func (t *table) widths(width int) []int {
+	ws := make([]int, len(t.cols))
+	for i, c := range t.cols {
+		ws[i] = textWidth(c.Title)
+		for _, r := range t.rows {
+			if i < len(r) {
+				if w := textWidth(r[i]); w > ws[i] {
+					ws[i] = w
+				}
+			}
+		}
+		if c.Max > 0 && ws[i] > c.Max {
+			ws[i] = c.Max
+		}
+	}
+	if width <= 0 {
+		return ws
+	}
+
+	total := 2 * (len(ws) - 1)
+	for _, w := range ws {
+		total += w
+	}
+	for total > width {
+		widest := -1
+		for i, c := range t.cols {
+			if c.Min > 0 && ws[i] > c.Min && (widest < 0 || ws[i] > ws[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		ws[widest]--
+		total--
+	}
+	return ws
+}
+
+func (t *table) render(w io.Writer, width int) {
+	ws := t.widths(width)
+	line := func(cells []string, fill func(int) string) {
+		parts := make([]string, len(t.cols))
+		for i, c := range t.cols {
+			text := ""
+			if fill != nil {
+				text = fill(ws[i])
+			} else if i < len(cells) {
+				text = trunc(cells[i], ws[i])
+			}
+			if c.Right {
+				parts[i] = padLeft(text, ws[i])
+			} else {
+				parts[i] = padRight(text, ws[i])
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(parts, "  "), " "))
+	}
+
+	titles := make([]string, len(t.cols))
+	for i, c := range t.cols {
+		titles[i] = c.Title
+	}
+	dashes := func(n int) string { return strings.Repeat("-", n) }
+	line(titles, nil)
+	line(nil, dashes)
+	for _, r := range t.rows {
+		if r == nil {
+			line(nil, dashes)
+			continue
+		}
+		line(r, nil)
+	}
+}
+
+// print writes the table to stdout, fitted to the terminal.
+func (t *table) print() {
+	t.render(os.Stdout, terminalWidth())
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// filterFlags registers the SearchFilter fields as flags, for commands that
+// select transactions the same way `pfm search` does.
+type filterFlags struct {
+	month    *string
+	fromStr  *string
+	toStr    *string
+	category *string
+	text     *string
+	account  *string
+	tag      *string
+	minStr   *string
+	maxStr   *string
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	return &filterFlags{
+		month:    fs.String("month", "", "Filter by month (YYYY-MM)"),
+		fromStr:  fs.String("from", "", "Start date (YYYY-MM-DD)"),
+		toStr:    fs.String("to", "", "End date (YYYY-MM-DD)"),
+		category: fs.String("category", "", "Filter by category"),
+		text:     fs.String("text", "", "Full-text search in payee/memo (word prefixes, \"phrases\")"),
+		account:  fs.String("account", "", "Filter by account"),
+		tag:      fs.String("tag", "", "Filter by tag"),
+		minStr:   fs.String("min", "", "Min amount in RON (inclusive, e.g. -200 or 0)"),
+		maxStr:   fs.String("max", "", "Max amount in RON (inclusive, e.g. -10 or 5000)"),
+	}
+}
+
+// empty reports whether no filter flag was given.
+func (ff *filterFlags) empty() bool {
+	for _, v := range []*string{ff.month, ff.fromStr, ff.toStr, ff.category, ff.text, ff.account, ff.tag, ff.minStr, ff.maxStr} {
+		if *v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (ff *filterFlags) build(limit int) (db.SearchFilter, error) {
+	f := db.SearchFilter{
+		Month:    *ff.month,
+		Category: *ff.category,
+		Text:     *ff.text,
+		Account:  *ff.account,
+		Tag:      *ff.tag,
+		Limit:    limit,
+	}
+
+	if *ff.fromStr != "" {
+		t, err := time.Parse("2006-01-02", *ff.fromStr)
+		if err != nil {
+			return f, fmt.Errorf("invalid --from (expected YYYY-MM-DD): %w", err)
+		}
+		f.From = &t
+	}
+	if *ff.toStr != "" {
+		t, err := time.Parse("2006-01-02", *ff.toStr)
+		if err != nil {
+			return f, fmt.Errorf("invalid --to (expected YYYY-MM-DD): %w", err)
+		}
+		f.To = &t
+	}
+	if *ff.minStr != "" {
+		v, err := ParseRON(*ff.minStr)
+		if err != nil {
+			return f, fmt.Errorf("invalid --min: %w", err)
+		}
+		f.MinBani = &v
+	}
+	if *ff.maxStr != "" {
+		v, err := ParseRON(*ff.maxStr)
+		if err != nil {
+			return f, fmt.Errorf("invalid --max: %w", err)
+		}
+		f.MaxBani = &v
+	}
+	return f, nil
+}
+
+// parseIDs parses a comma-separated id list such as "3,7,12".
+func parseIDs(s string) ([]int64, error) {
+	var out []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+// splitTagList splits an import cell like "kid;vacation-2026" into tags.
+func splitTagList(s string) []string {
+	var out []string
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '|' || r == ',' }) {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// categoryWithTags renders a category followed by the transaction's tags.
+func categoryWithTags(r db.TxRow) string {
+	if len(r.Tags) == 0 {
+		return r.Category
+	}
+	return r.Category + " [" + strings.Join(r.Tags, ", ") + "]"
+}
+
+func (a *App) cmdTag(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm tag <subcommand> [options]
+
+Subcommands:
+  add      Tag transactions selected by --id or search filters
+  remove   Remove a tag from transactions selected by --id or search filters
+  list     List tags with transaction counts
+
+Examples:
+  pfm tag add --tag vacation-2026 --id 41,42,57
+  pfm tag add --tag reimbursable --month 2026-01 --text uber
+  pfm tag remove --tag kid --id 12
+  pfm tag list
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		return a.cmdTagApply(args[1:], true)
+	case "remove":
+		return a.cmdTagApply(args[1:], false)
+	case "list":
+		return a.cmdTagList(args[1:])
+	default:
+		return fmt.Errorf("unknown tag subcommand: %q (try: pfm tag help)", args[0])
+	}
+}
+
+func (a *App) cmdTagApply(args []string, add bool) error {
+	name := "tag remove"
+	if add {
+		name = "tag add"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	idsStr := fs.String("id", "", "Comma-separated transaction ids")
+	limit := fs.Int("limit", 10000, "Max transactions to select with filters")
+	ff := addFilterFlags(fs)
+	fs.Lookup("tag").Usage = "Tag name [required]"
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// --tag names the tag to apply here, so it must not also act as a filter.
+	tag := *ff.tag
+	*ff.tag = ""
+	if tag == "" {
+		return errors.New("missing required flag: --tag")
+	}
+	tag, err := db.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	if *idsStr == "" && ff.empty() {
+		return errors.New("select transactions with --id or at least one filter (--month, --text, ...)")
+	}
+
+	ids, err := parseIDs(*idsStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if !ff.empty() {
+		filter, err := ff.build(*limit)
+		if err != nil {
+			return err
+		}
+		rows, err := db.SearchTransactions(conn, filter)
+		if err != nil {
+			return err
+		}
+		for _, r := range rows {
+			ids = append(ids, r.ID)
+		}
+	}
+
+	if add {
+		n, err := db.TagTransactions(conn, tag, ids)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Tagged %d transaction(s) with %q (%d selected).\n", n, tag, len(ids))
+		return nil
+	}
+
+	n, err := db.UntagTransactions(conn, tag, ids)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %q from %d transaction(s) (%d selected).\n", tag, n, len(ids))
+	return nil
+}
+
+func (a *App) cmdTagList(args []string) error {
+	fs := flag.NewFlagSet("tag list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	tags, err := db.ListTags(conn)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags.")
+		return nil
+	}
+
+	tbl := newTable(tableCol{Title: "TAG", Max: 24, Min: 8}, tableCol{Title: "TRANSACTIONS", Right: true})
+	for _, t := range tags {
+		tbl.add(t.Name, strconv.FormatInt(t.TxCount, 10))
+	}
+	tbl.print()
+	return nil
+}
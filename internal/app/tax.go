@@ -0,0 +1,382 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+)
+
+// bucketCapitalGains is filled from realized gains on trades rather than
+// from mapped transactions.
+const bucketCapitalGains = "capital-gains"
+
+func (a *App) cmdTax(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		fmt.Print(`Usage:
+  pfm tax <subcommand> [options]
+
+Subcommands:
+  map       Send a category or tag to a tax bucket
+  unmap     Remove a mapping by id
+  mappings  List mappings
+
+Income buckets sum amounts as they are; --deduction buckets sum expenses
+as positive amounts. A tag mapping wins over a category mapping. The
+capital-gains bucket is filled from realized gains on trades.
+
+Examples:
+  pfm tax map --bucket salary --category income:salary
+  pfm tax map --bucket rent --category income:rent
+  pfm tax map --bucket dividends --category dividends
+  pfm tax map --bucket freelance --tag pfa
+  pfm tax map --bucket deductible --category health:pension --deduction
+  pfm report tax --year 2025 --csv tax-2025.csv
+`)
+		return nil
+	}
+
+	switch args[0] {
+	case "map":
+		return a.cmdTaxMap(args[1:])
+	case "unmap":
+		return a.cmdTaxUnmap(args[1:])
+	case "mappings":
+		return a.cmdTaxMappings(args[1:])
+	default:
+		return fmt.Errorf("unknown tax subcommand: %q (try: pfm tax help)", args[0])
+	}
+}
+
+func (a *App) cmdTaxMap(args []string) error {
+	fs := flag.NewFlagSet("tax map", flag.ContinueOnError)
+
+	bucket := fs.String("bucket", "", "Tax bucket, e.g. salary, rent, freelance [required]")
+	category := fs.String("category", "", "Category to map")
+	tag := fs.String("tag", "", "Tag to map")
+	deduction := fs.Bool("deduction", false, "Bucket holds deductible expenses")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *bucket == "" || (*category == "") == (*tag == "") {
+		return errors.New("missing required flags: --bucket and one of --category, --tag")
+	}
+	if *bucket == bucketCapitalGains {
+		return fmt.Errorf("bucket %q is computed from trades", bucketCapitalGains)
+	}
+
+	m := db.TaxMapping{Bucket: *bucket, Kind: db.TaxIncome, MatchType: "category", Value: *category}
+	if *tag != "" {
+		m.MatchType, m.Value = "tag", *tag
+	}
+	if *deduction {
+		m.Kind = db.TaxDeduction
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	id, err := db.UpsertTaxMapping(conn, m)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Mapping #%d: %s %q -> %s (%s)\n", id, m.MatchType, m.Value, m.Bucket, m.Kind)
+	return nil
+}
+
+func (a *App) cmdTaxUnmap(args []string) error {
+	fs := flag.NewFlagSet("tax unmap", flag.ContinueOnError)
+	id := fs.Int64("id", 0, "Mapping id [required]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == 0 {
+		return errors.New("missing required flag: --id")
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	if err := db.DeleteTaxMapping(conn, *id); err != nil {
+		return err
+	}
+	fmt.Printf("Mapping #%d removed\n", *id)
+	return nil
+}
+
+func (a *App) cmdTaxMappings(args []string) error {
+	fs := flag.NewFlagSet("tax mappings", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	mappings, err := db.ListTaxMappings(conn)
+	if err != nil {
+		return err
+	}
+	if len(mappings) == 0 {
+		fmt.Println("No tax mappings yet. Add one with: pfm tax map ...")
+		return nil
+	}
+
+	t := newTable(
+		tableCol{Title: "ID", Right: true},
+		tableCol{Title: "BUCKET", Max: 24, Min: 8},
+		tableCol{Title: "KIND"},
+		tableCol{Title: "MATCH"},
+		tableCol{Title: "VALUE", Min: 8},
+	)
+	for _, m := range mappings {
+		t.add(strconv.FormatInt(m.ID, 10), m.Bucket, m.Kind, m.MatchType, m.Value)
+	}
+	t.print()
+	return nil
+}
+
+// taxBucket is one bucket's yearly total, with per-month detail. Deduction
+// totals are positive amounts spent.
+type taxBucket struct {
+	Name      string
+	Kind      string
+	TotalBani int64
+	Count     int64
+	ByMonth   map[string]int64
+	CountBy   map[string]int64
+}
+
+func (b *taxBucket) add(month string, bani, count int64) {
+	b.TotalBani += bani
+	b.Count += count
+	b.ByMonth[month] += bani
+	b.CountBy[month] += count
+}
+
+// computeTaxYear totals mapped transactions per bucket and adds realized
+// capital gains from trades sold during the year. Income buckets come first.
+func computeTaxYear(conn *sql.DB, year int, method string) ([]*taxBucket, error) {
+	mappings, err := db.ListTaxMappings(conn)
+	if err != nil {
+		return nil, err
+	}
+	kinds := map[string]string{}
+	for _, m := range mappings {
+		kinds[m.Bucket] = m.Kind
+	}
+
+	buckets := map[string]*taxBucket{}
+	get := func(name, kind string) *taxBucket {
+		b := buckets[name]
+		if b == nil {
+			b = &taxBucket{Name: name, Kind: kind, ByMonth: map[string]int64{}, CountBy: map[string]int64{}}
+			buckets[name] = b
+		}
+		return b
+	}
+
+	totals, err := db.GetTaxTotalsForYear(conn, year)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range totals {
+		kind := kinds[t.Bucket]
+		bani := t.TotalBani
+		if kind == db.TaxDeduction {
+			bani = -bani
+		}
+		get(t.Bucket, kind).add(t.Month, bani, t.Count)
+	}
+
+	trades, err := db.ListTrades(conn, 0, fmt.Sprintf("%04d-12-31", year))
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := buildHoldings(trades, method)
+	if err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("%04d-", year)
+	sells := map[string]int64{}
+	for _, t := range trades {
+		if t.Kind == db.TradeSell && strings.HasPrefix(t.TradeDate, prefix) {
+			sells[t.TradeDate[:7]]++
+		}
+	}
+	for _, h := range holdings {
+		for month, gain := range h.RealizedByMonth {
+			if strings.HasPrefix(month, prefix) {
+				get(bucketCapitalGains, db.TaxIncome).add(month, gain, 0)
+			}
+		}
+	}
+	if b := buckets[bucketCapitalGains]; b != nil {
+		for month, n := range sells {
+			b.Count += n
+			b.CountBy[month] += n
+		}
+	}
+
+	out := make([]*taxBucket, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind == db.TaxIncome
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+func (a *App) cmdReportTax(args []string) error {
+	fs := flag.NewFlagSet("report tax", flag.ContinueOnError)
+	yearFlag := fs.Int("year", 0, "Tax year, default last year")
+	csvPath := fs.String("csv", "", "Also write per-month totals to this CSV file")
+	methodStr := fs.String("method", lotAverage, "Lot method for capital gains: average or fifo")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	year := *yearFlag
+	if year == 0 {
+		year = time.Now().Year() - 1
+	}
+	if year < 1900 || year > 9999 {
+		return fmt.Errorf("invalid --year %d", year)
+	}
+	method, err := parseLotMethod(*methodStr)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(a.DBPath)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.Migrate(conn, a.SchemaPath); err != nil {
+		return err
+	}
+
+	buckets, err := computeTaxYear(conn, year, method)
+	if err != nil {
+		return err
+	}
+	if len(buckets) == 0 {
+		fmt.Printf("Nothing to report for %d. Map categories or tags with: pfm tax map ...\n", year)
+		return nil
+	}
+
+	fmt.Printf("Tax year %d\n\n", year)
+	t := newTable(
+		tableCol{Title: "BUCKET", Max: 24, Min: 8},
+		tableCol{Title: "KIND"},
+		tableCol{Title: "COUNT", Right: true},
+		tableCol{Title: "TOTAL", Right: true},
+	)
+	var income, deductions int64
+	for _, b := range buckets {
+		t.add(b.Name, b.Kind, strconv.FormatInt(b.Count, 10), a.disp.money(b.TotalBani))
+		if b.Kind == db.TaxDeduction {
+			deductions += b.TotalBani
+		} else {
+			income += b.TotalBani
+		}
+	}
+	t.rule()
+	t.add("Total income", "", "", a.disp.money(income))
+	t.add("Total deductible", "", "", a.disp.money(deductions))
+	t.print()
+
+	fmt.Println()
+	cols := []tableCol{{Title: "MONTH"}}
+	for _, b := range buckets {
+		cols = append(cols, tableCol{Title: b.Name, Right: true})
+	}
+	mt := newTable(cols...)
+	for m := 1; m <= 12; m++ {
+		month := fmt.Sprintf("%04d-%02d", year, m)
+		cells := []string{month}
+		for _, b := range buckets {
+			v := "-"
+			if b.CountBy[month] > 0 || b.ByMonth[month] != 0 {
+				v = a.disp.money(b.ByMonth[month])
+			}
+			cells = append(cells, v)
+		}
+		mt.add(cells...)
+	}
+	mt.print()
+
+	if *csvPath != "" {
+		if err := writeTaxCSV(*csvPath, year, buckets); err != nil {
+			return err
+		}
+		fmt.Printf("\nWrote %s\n", *csvPath)
+	}
+	return nil
+}
+
+// writeTaxCSV writes one row per bucket and month with activity, amounts as
+// plain decimals in RON.
+func writeTaxCSV(path string, year int, buckets []*taxBucket) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create csv: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"year", "month", "bucket", "kind", "amount", "count"})
+	for m := 1; m <= 12; m++ {
+		month := fmt.Sprintf("%04d-%02d", year, m)
+		for _, b := range buckets {
+			if b.CountBy[month] == 0 && b.ByMonth[month] == 0 {
+				continue
+			}
+			w.Write([]string{
+				strconv.Itoa(year), month, b.Name, b.Kind,
+				formatAmount(b.ByMonth[month]),
+				strconv.FormatInt(b.CountBy[month], 10),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return f.Close()
+}
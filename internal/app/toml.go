@@ -0,0 +1,263 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The config file is TOML, parsed with BurntSushi/toml. pfm uses tables
+// (dotted names allowed) holding strings, integers and booleans, and reads
+// them into a flat map keyed by the dotted path ("profiles.business.db").
+// Changes are written back with editTOML, which keeps the rest of the file
+// as the user wrote it.
+
+// tomlValue is a parsed value; strings keep their text, other values their
+// literal (42, true).
+type tomlValue struct {
+	Text   string
+	String bool
+}
+
+func (v tomlValue) literal() string {
+	if v.String {
+		return tomlQuote(v.Text)
+	}
+	return v.Text
+}
+
+// tomlQuote writes s as a TOML basic string.
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// parseTOML reads a config file into a flat map of dotted keys.
+func parseTOML(r io.Reader) (map[string]tomlValue, error) {
+	var doc map[string]any
+	if _, err := toml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	out := map[string]tomlValue{}
+	if err := flattenTOML(out, "", doc); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func flattenTOML(out map[string]tomlValue, prefix string, m map[string]any) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenTOML(out, key, v); err != nil {
+				return err
+			}
+		case string:
+			out[key] = tomlValue{Text: v, String: true}
+		case int64:
+			out[key] = tomlValue{Text: strconv.FormatInt(v, 10)}
+		case bool:
+			out[key] = tomlValue{Text: strconv.FormatBool(v)}
+		default:
+			return fmt.Errorf("%s: unsupported value (use a \"string\", an integer or true/false)", key)
+		}
+	}
+	return nil
+}
+
+// parseTOMLKey parses a possibly dotted key of bare or quoted parts into its
+// dotted form.
+func parseTOMLKey(s string) (string, error) {
+	var parts []string
+	for _, p := range strings.Split(strings.TrimSpace(s), ".") {
+		p = strings.TrimSpace(p)
+		if len(p) >= 2 && (p[0] == '"' || p[0] == '\'') {
+			u, err := tomlUnquote(p)
+			if err != nil {
+				return "", fmt.Errorf("invalid key %q", s)
+			}
+			p = u
+		}
+		if !validTOMLBareKey(p) {
+			return "", fmt.Errorf("invalid key %q (use letters, digits, - and _)", s)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// tomlUnquote reads the TOML string literal s.
+func tomlUnquote(s string) (string, error) {
+	var v struct{ S string }
+	if _, err := toml.Decode("S = "+s, &v); err != nil {
+		return "", err
+	}
+	return v.S, nil
+}
+
+func validTOMLBareKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// closingQuote is the index of the quote closing the basic string s starts.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// stripComment drops a trailing # comment from a line with no strings left.
+func stripComment(s string) string {
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// editTOML sets key to v in the config text src, or removes it when v is
+// nil, leaving every other line (comments included) as it was. A key that
+// is not in src yet goes after the last key of its table; a missing table is
+// added at the end.
+func editTOML(src []byte, key string, v *tomlValue) []byte {
+	text := string(src)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	lines := strings.SplitAfter(text, "\n")
+	lines = lines[:len(lines)-1]
+
+	table, name := "", key
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+
+	// insertAt is the line after the last key of the key's table, or -1
+	// while that table has not been seen. Top-level keys go before the first
+	// table when there are none yet.
+	cur, insertAt, firstTable := "", -1, len(lines)
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if firstTable == len(lines) {
+				firstTable = i
+			}
+			cur, _ = parseTOMLKey(strings.Trim(stripComment(line), "[]"))
+			if cur == table && insertAt < 0 {
+				insertAt = i + 1
+			}
+			continue
+		}
+		k, val, ok := strings.Cut(raw, "=")
+		if !ok {
+			continue
+		}
+		full, err := parseTOMLKey(k)
+		if err != nil {
+			continue
+		}
+		if cur != "" {
+			full = cur + "." + full
+		}
+		if full != key {
+			if cur == table {
+				insertAt = i + 1
+			}
+			continue
+		}
+		if v == nil {
+			return []byte(strings.Join(append(lines[:i:i], lines[i+1:]...), ""))
+		}
+		lines[i] = strings.TrimRight(k, " \t") + " = " + v.literal() + trailingComment(strings.TrimSpace(val)) + "\n"
+		return []byte(strings.Join(lines, ""))
+	}
+	if v == nil {
+		return []byte(text)
+	}
+
+	entry := name + " = " + v.literal() + "\n"
+	if table == "" && insertAt < 0 {
+		insertAt = firstTable
+		if insertAt < len(lines) {
+			entry += "\n"
+		}
+	}
+	if insertAt < 0 {
+		if text != "" {
+			text += "\n"
+		}
+		return []byte(text + "[" + table + "]\n" + entry)
+	}
+	out := append(lines[:insertAt:insertAt], entry)
+	return []byte(strings.Join(append(out, lines[insertAt:]...), ""))
+}
+
+// trailingComment is the comment after the value s, with the space before
+// it, if there is one.
+func trailingComment(s string) string {
+	rest := s
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if end := closingQuote(s); end >= 0 {
+			rest = s[end+1:]
+		}
+	case strings.HasPrefix(s, "'"):
+		if end := strings.Index(s[1:], "'"); end >= 0 {
+			rest = s[end+2:]
+		}
+	}
+	i := strings.IndexByte(rest, '#')
+	if i < 0 {
+		return ""
+	}
+	return rest[len(strings.TrimRight(rest[:i], " \t")):]
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `# pfm config
+profile = "home"
+
+[defaults]
+currency = "RON"  # lei
+warn_pct = 1_000
+no_color = true
+path = 'C:\pfm\db'
+quote = "say \"hi\" # not a comment"
+
+[profiles."home"]
+db = "~/home.db"
+
+[ import.ing.columns ] # bank export
+date = "Data"
+`
+	got, err := parseTOML(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]tomlValue{
+		"profile":                 {Text: "home", String: true},
+		"defaults.currency":       {Text: "RON", String: true},
+		"defaults.warn_pct":       {Text: "1000"},
+		"defaults.no_color":       {Text: "true"},
+		"defaults.path":           {Text: `C:\pfm\db`, String: true},
+		"defaults.quote":          {Text: `say "hi" # not a comment`, String: true},
+		"profiles.home.db":        {Text: "~/home.db", String: true},
+		"import.ing.columns.date": {Text: "Data", String: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"[defaults", "line 1"},
+		{"\ncurrency", "line 2"},
+		{"a = \"x", "line 1"},
+		{"a =", "line 1"},
+		{"a.b = 1\n[a]\nb = 2", "line 3"},
+		{"bad key = 1", "line 1"},
+		{`a = "\x41"`, "line 1"},
+		{"a = 1.5", "a: unsupported value"},
+		{"[x]\na = [1]", "x.a: unsupported value"},
+		{"[[arr]]\na = 1", "arr: unsupported value"},
+	}
+	for _, tt := range tests {
+		_, err := parseTOML(strings.NewReader(tt.in))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseTOML(%q) error = %v, want it to contain %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestTOMLLiteral(t *testing.T) {
+	for _, s := range []string{
+		"plain",
+		`C:\data\"w".db`,
+		"Ștefan #1.db",
+		"tab\there\nnew line\r",
+		"bell\a vt\v del\x7f nul\x00",
+		"'single'",
+	} {
+		v := tomlValue{Text: s, String: true}
+		got, err := parseTOML(strings.NewReader("k = " + v.literal()))
+		if err != nil {
+			t.Errorf("%q written as %s does not parse: %v", s, v.literal(), err)
+			continue
+		}
+		if got["k"] != v {
+			t.Errorf("%q written as %s reads back as %q", s, v.literal(), got["k"].Text)
+		}
+	}
+	if got := (tomlValue{Text: "a\ab", String: true}).literal(); got != `"a\u0007b"` {
+		t.Errorf("literal of a bell = %s, want a \\u escape", got)
+	}
+	if got := (tomlValue{Text: "90"}).literal(); got != "90" {
+		t.Errorf("literal of 90 = %s", got)
+	}
+}
+
+func TestEditTOML(t *testing.T) {
+	src := `# pfm config
+
+[defaults]
+currency = "RON"  # lei
+# keep this
+warn_pct = 80
+
+[profiles.home]
+db = "home.db"
+`
+	str := func(s string) *tomlValue { return &tomlValue{Text: s, String: true} }
+	tests := []struct {
+		name string
+		src  string
+		key  string
+		v    *tomlValue
+		want string
+	}{
+		{"replace keeps comment", src, "defaults.currency", str("EUR"), `# pfm config
+
+[defaults]
+currency = "EUR"  # lei
+# keep this
+warn_pct = 80
+
+[profiles.home]
+db = "home.db"
+`},
+		{"add to table", src, "defaults.date_format", str("DD.MM.YYYY"), `# pfm config
+
+[defaults]
+currency = "RON"  # lei
+# keep this
+warn_pct = 80
+date_format = "DD.MM.YYYY"
+
+[profiles.home]
+db = "home.db"
+`},
+		{"add table", src, "profiles.work.db", str("w.db"), src + `
+[profiles.work]
+db = "w.db"
+`},
+		{"add top-level", src, "profile", str("home"), `# pfm config
+
+profile = "home"
+
+[defaults]
+currency = "RON"  # lei
+# keep this
+warn_pct = 80
+
+[profiles.home]
+db = "home.db"
+`},
+		{"remove", src, "defaults.warn_pct", nil, `# pfm config
+
+[defaults]
+currency = "RON"  # lei
+# keep this
+
+[profiles.home]
+db = "home.db"
+`},
+		{"remove missing", src, "defaults.nope", nil, src},
+		{"dotted key", "[profiles]\nhome.db = \"a\"\n", "profiles.home.db", str("b"), "[profiles]\nhome.db = \"b\"\n"},
+		{"no final newline", "[defaults]\ncurrency = \"RON\"", "defaults.warn_pct", &tomlValue{Text: "90"}, "[defaults]\ncurrency = \"RON\"\nwarn_pct = 90\n"},
+		{"empty file", "", "defaults.currency", str("EUR"), "[defaults]\ncurrency = \"EUR\"\n"},
+		{"empty file top-level", "", "profile", str("home"), "profile = \"home\"\n"},
+	}
+	for _, tt := range tests {
+		got := string(editTOML([]byte(tt.src), tt.key, tt.v))
+		if got != tt.want {
+			t.Errorf("%s: editTOML =\n%s\nwant\n%s", tt.name, got, tt.want)
+			continue
+		}
+		// The edited text parses to the original values plus the change.
+		before, err := parseTOML(strings.NewReader(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.v == nil {
+			delete(before, tt.key)
+		} else {
+			before[tt.key] = *tt.v
+		}
+		after, err := parseTOML(strings.NewReader(got))
+		if err != nil {
+			t.Errorf("%s: edited text does not parse: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(after, before) {
+			t.Errorf("%s: edited values =\n%v\nwant\n%v", tt.name, after, before)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -10,21 +11,80 @@ import (
 )
 
 type tuiModel struct {
-	rows []db.TxRow
+	conn  *sql.DB
+	disp  display
+	rows  []db.TxRow
+	goals []goalProgress
 
 	cursor int
+	top    int // first row on screen
 	filter string
 
+	// Paging state of the transactions tab; see tui_pager.go. rows is a
+	// window of the filtered list starting at offset.
+	baseFilter db.SearchFilter
+	txFilter   db.SearchFilter
+	pageSize   int
+	offset     int
+	total      int
+	atStart    bool
+	atEnd      bool
+
 	typingFilter bool
-	filterInput   string
+	filterInput  string
 
 	showDetails bool
 	width       int
 	height      int
+
+	// Sort, columns and theme; see tui_style.go.
+	prefs        tuiPrefs
+	theme        tuiTheme
+	columnCursor int
+	overCache    map[string]map[string]bool
+
+	// Tabs other than transactions; see tui_tabs.go.
+	tab          int
+	month        string
+	warnPct      int // budget WARN threshold, from the settings
+	dash         tuiDashboard
+	budgetRows   []tuiBudgetRow
+	budgetCursor int
+	report       tuiReport
+	rules        []db.RuleRow
+	ruleSample   []db.TxRow
+	ruleCursor   int
+	showRuleTest bool
+
+	// Categorization queue; see tui_inbox.go.
+	inbox      []db.TxForCategorize
+	inboxPos   int
+	inboxRules []compiledRule
+	inboxModel *classifier
+
+	// Editing state; see tui_edit.go.
+	mode         int
+	selected     map[int64]bool
+	pending      []int64
+	categories   []string
+	pickerInput  string
+	pickerCursor int
+	form         tuiFormState
+	status       string
 }
 
-func newTUIModel(rows []db.TxRow) tuiModel {
-	return tuiModel{rows: rows}
+// newTUIModel opens on tab for month. The transactions tab pages through
+// base, pageSize rows at a time.
+func newTUIModel(conn *sql.DB, disp display, base db.SearchFilter, pageSize int, month string, tab, warnPct int) tuiModel {
+	m := tuiModel{conn: conn, disp: disp, baseFilter: base, pageSize: pageSize, month: month, warnPct: warnPct, selected: map[int64]bool{}, overCache: map[string]map[string]bool{}}
+	prefs, err := loadTUIPrefs(conn)
+	if err != nil {
+		m.status = err.Error()
+	}
+	m.prefs, m.theme = prefs, themeByName(prefs.Theme)
+	m.baseFilter.Sort, m.baseFilter.Asc = prefs.Sort, prefs.Asc
+	m = m.applyFilter("")
+	return m.switchTab(tab)
 }
 
 func (m tuiModel) Init() tea.Cmd { return nil }
@@ -40,13 +100,24 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		k := msg.String()
 
+		switch m.mode {
+		case tuiPicker:
+			return m.updatePicker(msg)
+		case tuiForm:
+			return m.updateForm(msg)
+		case tuiConfirmDelete:
+			return m.updateConfirmDelete(msg)
+		case tuiColumns:
+			return m.updateColumns(msg)
+		}
+		m.status = ""
+
 		if m.typingFilter {
 			switch k {
 			case "enter":
-				m.filter = strings.TrimSpace(m.filterInput)
 				m.typingFilter = false
+				m = m.applyFilter(strings.TrimSpace(m.filterInput))
 				m.filterInput = ""
-				m.cursor = 0
 				return m, nil
 			case "esc":
 				m.typingFilter = false
@@ -65,44 +136,160 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Digits pick a suggestion in the inbox instead of switching tabs.
+		if m.tab == tabInbox && len(k) == 1 && k[0] >= '1' && k[0] <= '9' {
+			return m.updateInbox(k)
+		}
+
 		switch k {
 		case "q", "ctrl+c":
 			return m, tea.Quit
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
+		case "tab":
+			return m.switchTab((m.tab + 1) % len(tuiTabNames)), nil
+		case "shift+tab":
+			return m.switchTab((m.tab + len(tuiTabNames) - 1) % len(tuiTabNames)), nil
+		case "1", "2", "3", "4", "5", "6", "7":
+			return m.switchTab(int(k[0] - '1')), nil
+		case "T":
+			return m.nextTheme(), nil
+		case "g":
+			if m.tab == tabGoals {
+				return m.switchTab(tabTransactions), nil
 			}
-			return m, nil
-		case "down", "j":
-			if m.cursor < len(m.filtered())-1 {
-				m.cursor++
-			}
-			return m, nil
-		case "enter":
-			m.showDetails = !m.showDetails
-			return m, nil
-		case "/":
-			m.typingFilter = true
-			m.filterInput = ""
-			return m, nil
-		case "esc":
-			m.filter = ""
-			m.cursor = 0
-			return m, nil
+			return m.switchTab(tabGoals), nil
+		case "[", "left":
+			m.month = addMonths(m.month, -1)
+			return m.load(), nil
+		case "]", "right":
+			m.month = addMonths(m.month, 1)
+			return m.load(), nil
+		}
+
+		switch m.tab {
+		case tabTransactions:
+			return m.updateTransactions(k)
+		case tabBudgets:
+			return m.updateBudgets(k)
+		case tabRules:
+			return m.updateRules(k)
+		case tabInbox:
+			return m.updateInbox(k)
 		}
 	}
 
 	return m, nil
 }
 
+func (m tuiModel) updateTransactions(k string) (tea.Model, tea.Cmd) {
+	switch k {
+	case "up", "k":
+		return m.moveCursor(-1), nil
+	case "down", "j":
+		return m.moveCursor(1), nil
+	case "pgup", "ctrl+b":
+		return m.moveCursor(-m.visibleRows()), nil
+	case "pgdown", "ctrl+f", "ctrl+d":
+		return m.moveCursor(m.visibleRows()), nil
+	case "home":
+		return m.jumpStart().scroll(), nil
+	case "end", "G":
+		return m.jumpEnd().scroll(), nil
+	case "enter":
+		m.showDetails = !m.showDetails
+		return m, nil
+	case "/":
+		m.typingFilter = true
+		m.filterInput = m.filter
+		return m, nil
+	case "esc":
+		return m.applyFilter(""), nil
+	case " ":
+		if m.cursor < len(m.rows) {
+			id := m.rows[m.cursor].ID
+			if m.selected[id] {
+				delete(m.selected, id)
+			} else {
+				m.selected[id] = true
+			}
+			return m.moveCursor(1), nil
+		}
+		return m, nil
+	case "a":
+		for _, r := range m.rows {
+			m.selected[r.ID] = true
+		}
+		return m, nil
+	case "u":
+		m.selected = map[int64]bool{}
+		return m, nil
+	case "s":
+		return m.setSort(m.nextSort(), m.prefs.Asc), nil
+	case "S":
+		return m.setSort(m.prefs.Sort, !m.prefs.Asc), nil
+	case "v":
+		m.mode = tuiColumns
+		return m, nil
+	case "c":
+		return m.openPicker(m.targets()), nil
+	case "e":
+		return m.openTxForm(), nil
+	case "d":
+		if ids := m.targets(); len(ids) > 0 {
+			m.pending = ids
+			m.mode = tuiConfirmDelete
+		}
+		return m, nil
+	}
+	return m, nil
+}
+
+// tuiHelp is the key help shown under the tab bar.
+var tuiHelp = map[int]string{
+	tabDashboard:    "←/→ month  tab/1-7 switch  T theme  q quit",
+	tabTransactions: "↑/↓ move  enter details  / filter  esc clear  s/S sort  v columns  c category  e edit  d delete  space select  a all  u none",
+	tabBudgets:      "←/→ month  ↑/↓ move  e edit limit  n new budget",
+	tabReports:      "←/→ month  tab/1-7 switch  q quit",
+	tabRules:        "↑/↓ move  n new  e edit  t test  x enable/disable  K/J move up/down",
+	tabGoals:        "g back to transactions",
+	tabInbox:        "1-9 take suggestion  c/enter pick category  r rule from this  s/↓ skip  ↑ back  tab switch",
+}
+
 func (m tuiModel) View() string {
-	header := "pfm tui  |  ↑/↓ move  enter details  / filter  esc clear  q quit\n"
+	header := "pfm tui  " + m.tabBar() + "\n" + tuiHelp[m.tab] + "\n"
+	if m.status != "" {
+		header += m.status + "\n"
+	}
+	switch m.mode {
+	case tuiPicker:
+		return header + "\n" + m.pickerView()
+	case tuiForm:
+		return header + "\n" + m.formView()
+	case tuiColumns:
+		return header + "\n" + m.columnsView()
+	}
+	switch m.tab {
+	case tabDashboard:
+		return header + "\n" + m.dashboardView()
+	case tabBudgets:
+		return header + "\n" + m.budgetsView()
+	case tabReports:
+		return header + "\n" + m.reportsView()
+	case tabRules:
+		return header + "\n" + m.rulesView()
+	case tabGoals:
+		return header + "\n" + m.goalsView()
+	case tabInbox:
+		return header + "\n" + m.inboxView()
+	}
 	if m.typingFilter {
 		return header + "\nFilter: " + m.filterInput + "█\n"
 	}
 
-	rows := m.filtered()
+	rows := m.rows
 	if len(rows) == 0 {
+		if m.filter != "" {
+			return header + fmt.Sprintf("Filter: %q\n", m.filter) + "\nNo matching transactions.\n"
+		}
 		return header + "\nNo matching transactions.\n"
 	}
 
@@ -116,21 +303,27 @@ func (m tuiModel) View() string {
 	var b strings.Builder
 	b.WriteString(header)
 	if m.filter != "" {
-		b.WriteString(fmt.Sprintf("Filter: %q\n\n", m.filter))
-	} else {
+		b.WriteString(fmt.Sprintf("Filter: %q", m.filter))
+		if m.txFilter.Sort == db.SortRelevance {
+			b.WriteString(" (best matches first)")
+		}
 		b.WriteString("\n")
 	}
+	b.WriteString(fmt.Sprintf("Row %d of %d\n", m.offset+m.cursor+1, m.total))
+	if len(m.selected) > 0 {
+		b.WriteString(fmt.Sprintf("Selected: %d\n", len(m.selected)))
+	}
+	b.WriteString("\n")
 
-	b.WriteString(fmt.Sprintf("%-10s  %-18s  %-12s  %s\n", "DATE", "PAYEE", "AMOUNT", "CATEGORY"))
-	b.WriteString("----------  ------------------  ------------  --------\n")
+	cols := m.txColumns()
+	b.WriteString(m.txHeader(cols))
 
-	maxLines := m.height - 8
-	if maxLines < 5 {
-		maxLines = 5
+	maxLines := m.visibleRows()
+	start := m.top
+	if start > m.cursor {
+		start = m.cursor
 	}
-
-	start := 0
-	if m.cursor >= maxLines {
+	if m.cursor >= start+maxLines {
 		start = m.cursor - maxLines + 1
 	}
 	end := start + maxLines
@@ -140,31 +333,39 @@ func (m tuiModel) View() string {
 
 	for i := start; i < end; i++ {
 		r := rows[i]
-		prefix := "  "
+		prefix := []byte("  ")
 		if i == m.cursor {
-			prefix = "> "
-		}
-		payee := trunc(r.Payee, 18)
-		b.WriteString(fmt.Sprintf("%s%-10s  %-18s  %-12s  %s\n",
-			prefix,
-			r.PostedAt.Format("2006-01-02"),
-			payee,
-			FormatRON(r.AmountBani),
-			r.Category,
-		))
+			prefix[0] = '>'
+		}
+		if m.selected[r.ID] {
+			prefix[1] = '*'
+		}
+		over := m.overBudget(r.PostedAt.Format("2006-01"))[r.Category]
+		b.WriteString(m.txLine(cols, r, string(prefix), over))
+	}
+
+	if m.mode == tuiConfirmDelete {
+		b.WriteString(fmt.Sprintf("\nDelete %d transaction(s)? y/n\n", len(m.pending)))
+		return b.String()
 	}
 
 	if m.showDetails {
 		r := rows[m.cursor]
 		b.WriteString("\n--- details ---\n")
 		b.WriteString(fmt.Sprintf("ID: %d\n", r.ID))
-		b.WriteString(fmt.Sprintf("Date: %s\n", r.PostedAt.Format("2006-01-02")))
+		b.WriteString(fmt.Sprintf("Date: %s\n", m.disp.date(r.PostedAt)))
 		b.WriteString(fmt.Sprintf("Payee: %s\n", r.Payee))
+		if r.PayeeRaw != "" && r.PayeeRaw != r.Payee {
+			b.WriteString(fmt.Sprintf("Bank payee: %s\n", r.PayeeRaw))
+		}
 		if strings.TrimSpace(r.Memo) != "" {
 			b.WriteString(fmt.Sprintf("Memo: %s\n", r.Memo))
 		}
-		b.WriteString(fmt.Sprintf("Amount: %s\n", FormatRON(r.AmountBani)))
+		b.WriteString(fmt.Sprintf("Amount: %s\n", m.disp.money(r.AmountBani)))
 		b.WriteString(fmt.Sprintf("Category: %s\n", r.Category))
+		if len(r.Tags) > 0 {
+			b.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(r.Tags, ", ")))
+		}
 		b.WriteString(fmt.Sprintf("Account: %s\n", r.Account))
 		b.WriteString(fmt.Sprintf("Source: %s\n", r.Source))
 	}
@@ -172,17 +373,36 @@ func (m tuiModel) View() string {
 	return b.String()
 }
 
-func (m tuiModel) filtered() []db.TxRow {
-	if strings.TrimSpace(m.filter) == "" {
-		return m.rows
+func (m tuiModel) goalsView() string {
+	if len(m.goals) == 0 {
+		return "No goals yet. Add one with: pfm goal add ...\n"
 	}
-	f := strings.ToLower(strings.TrimSpace(m.filter))
-	out := make([]db.TxRow, 0, len(m.rows))
-	for _, r := range m.rows {
-		hay := strings.ToLower(r.Payee + " " + r.Memo + " " + r.Category + " " + r.Account)
-		if strings.Contains(hay, f) {
-			out = append(out, r)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-18s  %-22s  %-5s  %-12s  %-12s  %s\n", "GOAL", "PROGRESS", "DONE", "TO GO", "NEEDED/MO", "PROJECTED"))
+	b.WriteString("------------------  ----------------------  -----  ------------  ------------  ---------\n")
+	for _, p := range m.goals {
+		needed := "-"
+		if p.RequiredBani > 0 {
+			needed = m.disp.money(p.RequiredBani)
 		}
+		projected := p.ProjectedMonth
+		switch {
+		case p.RemainingBani == 0:
+			projected = "reached"
+		case projected == "":
+			projected = "never"
+		case p.Goal.TargetDate != "" && !p.OnTrack():
+			projected += " (behind)"
+		}
+		b.WriteString(fmt.Sprintf("%s  %s  %4d%%  %-12s  %-12s  %s\n",
+			cell(p.Goal.Name, 18),
+			progressBar(p.Pct, 20),
+			p.Pct,
+			m.disp.money(p.RemainingBani),
+			needed,
+			projected,
+		))
 	}
-	return out
+	return b.String()
 }
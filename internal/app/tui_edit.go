@@ -0,0 +1,384 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TUI modes. Browsing is the transaction list; the others take over the
+// keyboard until they are confirmed or cancelled with esc.
+const (
+	tuiBrowse = iota
+	tuiPicker
+	tuiForm
+	tuiConfirmDelete
+	tuiColumns
+)
+
+// pickerRows is the number of category suggestions shown.
+const pickerRows = 10
+
+// editText applies a key to a one-line text input. ok is false for keys that
+// do not edit text.
+func editText(s string, msg tea.KeyMsg) (string, bool) {
+	switch msg.Type {
+	case tea.KeyRunes:
+		return s + string(msg.Runes), true
+	case tea.KeySpace:
+		return s + " ", true
+	case tea.KeyBackspace:
+		r := []rune(s)
+		if len(r) > 0 {
+			r = r[:len(r)-1]
+		}
+		return string(r), true
+	}
+	return s, false
+}
+
+// targets are the selected transactions, or the one under the cursor when
+// nothing is selected.
+func (m tuiModel) targets() []int64 {
+	if len(m.selected) > 0 {
+		ids := make([]int64, 0, len(m.selected))
+		for id := range m.selected {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+	if m.cursor >= len(m.rows) {
+		return nil
+	}
+	return []int64{m.rows[m.cursor].ID}
+}
+
+// refresh re-reads changed transactions from the database.
+func (m tuiModel) refresh(ids []int64) tuiModel {
+	m.overCache = map[string]map[string]bool{}
+	changed := map[int64]bool{}
+	for _, id := range ids {
+		changed[id] = true
+	}
+	for i, r := range m.rows {
+		if !changed[r.ID] {
+			continue
+		}
+		fresh, err := db.GetTransaction(m.conn, r.ID)
+		if err != nil {
+			m.status = err.Error()
+			return m
+		}
+		m.rows[i] = fresh
+	}
+	return m
+}
+
+// openPicker chooses a category for ids.
+func (m tuiModel) openPicker(ids []int64) tuiModel {
+	if len(ids) == 0 {
+		return m
+	}
+	cats, err := db.ListCategories(m.conn)
+	if err != nil {
+		m.status = err.Error()
+		return m
+	}
+	m.mode = tuiPicker
+	m.pending = ids
+	m.categories = cats
+	m.pickerInput = ""
+	m.pickerCursor = 0
+	return m
+}
+
+// pickerMatches lists categories containing the typed text, prefix matches
+// first. Text that is not an existing category is offered last, as a new one.
+func (m tuiModel) pickerMatches() []string {
+	in := strings.ToLower(strings.TrimSpace(m.pickerInput))
+	var prefix, other []string
+	exact := false
+	for _, c := range m.categories {
+		lc := strings.ToLower(c)
+		switch {
+		case lc == in:
+			exact = true
+			prefix = append(prefix, c)
+		case strings.HasPrefix(lc, in):
+			prefix = append(prefix, c)
+		case strings.Contains(lc, in):
+			other = append(other, c)
+		}
+	}
+	out := append(prefix, other...)
+	if in != "" && !exact {
+		out = append(out, strings.TrimSpace(m.pickerInput))
+	}
+	return out
+}
+
+func (m tuiModel) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	matches := m.pickerMatches()
+	switch msg.String() {
+	case "esc":
+		m.mode = tuiBrowse
+		return m, nil
+	case "up", "ctrl+p":
+		if m.pickerCursor > 0 {
+			m.pickerCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.pickerCursor < len(matches)-1 {
+			m.pickerCursor++
+		}
+		return m, nil
+	case "tab":
+		if m.pickerCursor < len(matches) {
+			m.pickerInput = matches[m.pickerCursor]
+			m.pickerCursor = 0
+		}
+		return m, nil
+	case "enter":
+		if m.pickerCursor >= len(matches) {
+			return m, nil
+		}
+		return m.applyCategory(matches[m.pickerCursor]), nil
+	}
+	if s, ok := editText(m.pickerInput, msg); ok {
+		m.pickerInput = s
+		m.pickerCursor = 0
+	}
+	return m, nil
+}
+
+// applyCategory sets a manual category on the pending transactions and
+// clears the selection. In the inbox they leave the queue.
+func (m tuiModel) applyCategory(category string) tuiModel {
+	m.mode = tuiBrowse
+	for _, id := range m.pending {
+		if err := db.UpdateTxCategory(m.conn, id, category, db.CategoryManual); err != nil {
+			m.status = err.Error()
+			return m
+		}
+	}
+	m = m.refresh(m.pending)
+	if m.tab == tabInbox {
+		m = m.inboxDone(m.pending, category)
+	}
+	if m.status == "" {
+		m.status = fmt.Sprintf("Category %q set on %d transaction(s)", category, len(m.pending))
+	}
+	m.selected = map[int64]bool{}
+	return m
+}
+
+func (m tuiModel) pickerView() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Category for %d transaction(s): %s█\n\n", len(m.pending), m.pickerInput))
+
+	matches := m.pickerMatches()
+	if len(matches) == 0 {
+		b.WriteString("  (no categories yet; type a new one)\n")
+	}
+	start := 0
+	if m.pickerCursor >= pickerRows {
+		start = m.pickerCursor - pickerRows + 1
+	}
+	for i := start; i < len(matches) && i < start+pickerRows; i++ {
+		prefix := "  "
+		if i == m.pickerCursor {
+			prefix = "> "
+		}
+		label := matches[i]
+		if !containsString(m.categories, label) {
+			label += "  (new)"
+		}
+		b.WriteString(prefix + label + "\n")
+	}
+	b.WriteString("\n↑/↓ choose  tab complete  enter apply  esc cancel\n")
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// tuiFormState is a form of one-line text fields. save validates and writes the
+// values; on error the form stays open. hint, if set, adds a line computed
+// from the values as they are typed.
+type tuiFormState struct {
+	title  string
+	labels []string
+	values []string
+	field  int
+	err    string
+	save   func(m tuiModel, values []string) (tuiModel, error)
+	hint   func(m tuiModel, values []string) string
+}
+
+func (m tuiModel) openForm(f tuiFormState) tuiModel {
+	m.mode = tuiForm
+	m.form = f
+	return m
+}
+
+// openTxForm edits the transaction under the cursor.
+func (m tuiModel) openTxForm() tuiModel {
+	if m.cursor >= len(m.rows) {
+		return m
+	}
+	r := m.rows[m.cursor]
+	return m.openForm(tuiFormState{
+		title:  fmt.Sprintf("Edit transaction #%d", r.ID),
+		labels: []string{"Date", "Payee", "Memo", "Amount"},
+		values: []string{
+			r.PostedAt.Format("2006-01-02"),
+			r.Payee,
+			r.Memo,
+			formatAmount(r.AmountBani),
+		},
+		save: func(m tuiModel, v []string) (tuiModel, error) {
+			e, err := parseTxForm(v)
+			if err != nil {
+				return m, err
+			}
+			if err := db.UpdateTransaction(m.conn, r.ID, e); err != nil {
+				return m, err
+			}
+			if l, ok, err := db.GetLoanForTransaction(m.conn, r.ID); err != nil {
+				return m, err
+			} else if ok {
+				if _, err := resplitLoanPayments(m.conn, l); err != nil {
+					return m, err
+				}
+			}
+			m = m.refresh([]int64{r.ID})
+			if m.status == "" {
+				m.status = fmt.Sprintf("Transaction #%d saved", r.ID)
+			}
+			return m, nil
+		},
+	})
+}
+
+func parseTxForm(v []string) (db.TxEdit, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(v[0]))
+	if err != nil {
+		return db.TxEdit{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", v[0])
+	}
+	payee := strings.TrimSpace(v[1])
+	if payee == "" {
+		return db.TxEdit{}, errors.New("payee must not be empty")
+	}
+	amount, err := ParseRON(v[3])
+	if err != nil {
+		return db.TxEdit{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	return db.TxEdit{PostedAt: date, Payee: payee, Memo: strings.TrimSpace(v[2]), AmountBani: amount}, nil
+}
+
+func (m tuiModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(m.form.values)
+	switch msg.String() {
+	case "esc":
+		m.mode = tuiBrowse
+		return m, nil
+	case "tab", "down":
+		m.form.field = (m.form.field + 1) % n
+		return m, nil
+	case "shift+tab", "up":
+		m.form.field = (m.form.field + n - 1) % n
+		return m, nil
+	case "enter":
+		saved, err := m.form.save(m, m.form.values)
+		if err != nil {
+			m.form.err = err.Error()
+			return m, nil
+		}
+		saved.mode = tuiBrowse
+		return saved, nil
+	}
+	if s, ok := editText(m.form.values[m.form.field], msg); ok {
+		m.form.values[m.form.field] = s
+		m.form.err = ""
+	}
+	return m, nil
+}
+
+func (m tuiModel) formView() string {
+	width := 0
+	for _, l := range m.form.labels {
+		if len(l) > width {
+			width = len(l)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(m.form.title + "\n\n")
+	for i, label := range m.form.labels {
+		prefix := "  "
+		cursor := ""
+		if i == m.form.field {
+			prefix = "> "
+			cursor = "█"
+		}
+		b.WriteString(fmt.Sprintf("%s%-*s %s%s\n", prefix, width+1, label+":", m.form.values[i], cursor))
+	}
+	if m.form.hint != nil {
+		if h := m.form.hint(m, m.form.values); h != "" {
+			b.WriteString("\n" + h + "\n")
+		}
+	}
+	if m.form.err != "" {
+		b.WriteString("\nError: " + m.form.err + "\n")
+	}
+	b.WriteString("\ntab/↑/↓ field  enter save  esc cancel\n")
+	return b.String()
+}
+
+func (m tuiModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		deleted := map[int64]bool{}
+		for _, id := range m.pending {
+			if err := db.DeleteTransaction(m.conn, id); err != nil {
+				m.status = err.Error()
+				break
+			}
+			deleted[id] = true
+		}
+		kept := m.rows[:0:0]
+		for _, r := range m.rows {
+			if !deleted[r.ID] {
+				kept = append(kept, r)
+			}
+		}
+		m.rows = kept
+		m.total -= len(deleted)
+		m.overCache = map[string]map[string]bool{}
+		m.selected = map[int64]bool{}
+		m.mode = tuiBrowse
+		m = m.moveCursor(0)
+		if m.status == "" {
+			m.status = fmt.Sprintf("Deleted %d transaction(s)", len(deleted))
+		}
+		return m, nil
+	case "n", "N", "esc":
+		m.mode = tuiBrowse
+		return m, nil
+	}
+	return m, nil
+}
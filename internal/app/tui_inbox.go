@@ -0,0 +1,253 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"example.com/pfm/internal/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The inbox tab walks uncategorized transactions one at a time. Categories
+// assigned here teach the suggestion model as you go, so later transactions
+// from the same payee get better suggestions.
+const (
+	inboxSuggestions = 5 // at most this many numbered suggestions
+	inboxUpNext      = 5 // queued transactions shown under the current one
+	inboxMinPct      = 1 // history suggestions below this confidence are hidden
+)
+
+type inboxSuggestion struct {
+	Category string
+	Reason   string
+	RuleID   int64 // set when the suggestion comes from a rule
+}
+
+// loadInbox reads the queue, the rules and, once per session, the history
+// model trained on every categorized transaction.
+func loadInbox(m tuiModel) (tuiModel, error) {
+	var err error
+	if m.inbox, err = db.ListTxForCategorize(m.conn, db.CategorizeFilter{Month: m.baseFilter.Month}); err != nil {
+		return m, err
+	}
+	if m.rules, err = db.ListRules(m.conn); err != nil {
+		return m, err
+	}
+	if m.inboxRules, err = compileRules(m.rules); err != nil {
+		return m, err
+	}
+	if m.ruleSample == nil {
+		if m.ruleSample, err = db.SearchTransactions(m.conn, db.SearchFilter{Limit: tuiRuleSample}); err != nil {
+			return m, err
+		}
+	}
+	if m.inboxModel == nil {
+		samples, err := db.ListCategorizedTx(m.conn)
+		if err != nil {
+			return m, err
+		}
+		m.inboxModel = trainClassifier(samples)
+	}
+	if m.inboxPos >= len(m.inbox) {
+		m.inboxPos = len(m.inbox) - 1
+	}
+	if m.inboxPos < 0 {
+		m.inboxPos = 0
+	}
+	return m, nil
+}
+
+// inboxCurrent is the transaction on screen, if the inbox is not empty.
+func (m tuiModel) inboxCurrent() (db.TxForCategorize, bool) {
+	if m.inboxPos >= len(m.inbox) {
+		return db.TxForCategorize{}, false
+	}
+	return m.inbox[m.inboxPos], true
+}
+
+// suggestions lists the rule that matches t, if any, then the most likely
+// categories from history, without duplicates.
+func (m tuiModel) suggestions(t db.TxForCategorize) []inboxSuggestion {
+	var out []inboxSuggestion
+	seen := map[string]bool{}
+	if cat, r := matchCategory(m.inboxRules, t.Payee, t.PayeeRaw, t.Memo); r != nil {
+		out = append(out, inboxSuggestion{Category: cat, Reason: fmt.Sprintf("rule #%d %s", r.ID, r.Name), RuleID: r.ID})
+		seen[cat] = true
+	}
+	if m.inboxModel != nil {
+		for _, s := range m.inboxModel.rank(t.Payee, t.Memo, t.AmountBani) {
+			pct := int(s.Prob*100 + 0.5)
+			if len(out) == inboxSuggestions || pct < inboxMinPct {
+				break
+			}
+			if seen[s.Category] {
+				continue
+			}
+			out = append(out, inboxSuggestion{Category: s.Category, Reason: fmt.Sprintf("history %d%%", pct)})
+			seen[s.Category] = true
+		}
+	}
+	return out
+}
+
+func (m tuiModel) updateInbox(k string) (tea.Model, tea.Cmd) {
+	t, ok := m.inboxCurrent()
+	if !ok {
+		return m, nil
+	}
+	switch k {
+	case "down", "j", "s":
+		if m.inboxPos < len(m.inbox)-1 {
+			m.inboxPos++
+		}
+	case "up", "k":
+		if m.inboxPos > 0 {
+			m.inboxPos--
+		}
+	case "c", "enter":
+		return m.openPicker([]int64{t.ID}), nil
+	case "r":
+		return m.openInboxRuleForm(t), nil
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		sugg := m.suggestions(t)
+		n := int(k[0] - '1')
+		if n >= len(sugg) {
+			return m, nil
+		}
+		s := sugg[n]
+		source := db.CategoryManual
+		if s.RuleID != 0 {
+			source = db.CategoryRule
+			if err := db.RecordRuleHits(m.conn, map[int64]int{s.RuleID: 1}); err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+		}
+		if err := db.UpdateTxCategory(m.conn, t.ID, s.Category, source); err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m = m.inboxDone([]int64{t.ID}, s.Category)
+		m.status = fmt.Sprintf("%s -> %s", t.Payee, s.Category)
+	}
+	return m, nil
+}
+
+// inboxDone drops categorized transactions from the queue and learns from
+// them. The next transaction moves up under the cursor.
+func (m tuiModel) inboxDone(ids []int64, category string) tuiModel {
+	done := map[int64]bool{}
+	for _, id := range ids {
+		done[id] = true
+	}
+	kept := m.inbox[:0:0]
+	for i, t := range m.inbox {
+		if !done[t.ID] {
+			kept = append(kept, t)
+			continue
+		}
+		if i < m.inboxPos {
+			m.inboxPos--
+		}
+		if m.inboxModel != nil {
+			t.Category = category
+			m.inboxModel.learn(t)
+		}
+	}
+	m.inbox = kept
+	if m.inboxPos >= len(m.inbox) && m.inboxPos > 0 {
+		m.inboxPos = len(m.inbox) - 1
+	}
+	return m
+}
+
+// openInboxRuleForm opens the rule form pre-filled from t: the first
+// significant payee word as a whole-word pattern and the top suggestion as
+// the category. Saving it categorizes the queued transactions it matches.
+func (m tuiModel) openInboxRuleForm(t db.TxForCategorize) tuiModel {
+	r := db.RuleRow{Priority: m.nextRulePriority()}
+	if tok, word, ok := payeeKeyword(t.Payee); ok {
+		r.Name, r.Pattern = tok, tokenPattern(word)
+	} else {
+		r.Name, r.Pattern = strings.ToLower(strings.TrimSpace(t.Payee)), `(?i)`+regexp.QuoteMeta(strings.TrimSpace(t.Payee))
+	}
+	if sugg := m.suggestions(t); len(sugg) > 0 {
+		r.Category = sugg[0].Category
+	}
+	return m.openRuleForm(r)
+}
+
+// inboxApplyRule categorizes the queued transactions on which rule id wins,
+// the way `categorize` would.
+func (m tuiModel) inboxApplyRule(id int64) (tuiModel, int, error) {
+	rows, err := db.ListRules(m.conn)
+	if err != nil {
+		return m, 0, err
+	}
+	rules, err := compileRules(rows)
+	if err != nil {
+		return m, 0, err
+	}
+	var ids []int64
+	category := ""
+	for _, t := range m.inbox {
+		cat, r := matchCategory(rules, t.Payee, t.PayeeRaw, t.Memo)
+		if r == nil || r.ID != id {
+			continue
+		}
+		if err := db.UpdateTxCategory(m.conn, t.ID, cat, db.CategoryRule); err != nil {
+			return m, len(ids), err
+		}
+		ids = append(ids, t.ID)
+		category = cat
+	}
+	if len(ids) == 0 {
+		return m, 0, nil
+	}
+	if err := db.RecordRuleHits(m.conn, map[int64]int{id: len(ids)}); err != nil {
+		return m, len(ids), err
+	}
+	return m.inboxDone(ids, category), len(ids), nil
+}
+
+func (m tuiModel) inboxView() string {
+	t, ok := m.inboxCurrent()
+	if !ok {
+		if m.baseFilter.Month != "" {
+			return "Inbox zero: no uncategorized transactions in " + m.baseFilter.Month + ".\n"
+		}
+		return "Inbox zero: no uncategorized transactions.\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Uncategorized %d of %d\n\n", m.inboxPos+1, len(m.inbox)))
+	b.WriteString(fmt.Sprintf("  Date:    %s\n", m.disp.dateText(t.PostedAt)))
+	b.WriteString(fmt.Sprintf("  Payee:   %s\n", t.Payee))
+	if strings.TrimSpace(t.Memo) != "" {
+		b.WriteString(fmt.Sprintf("  Memo:    %s\n", t.Memo))
+	}
+	b.WriteString(fmt.Sprintf("  Amount:  %s\n", m.disp.money(t.AmountBani)))
+
+	b.WriteString("\nSuggestions\n")
+	sugg := m.suggestions(t)
+	if len(sugg) == 0 {
+		b.WriteString("  (none yet; press c to pick a category)\n")
+	}
+	for i, s := range sugg {
+		b.WriteString(fmt.Sprintf("  %d  %s  %s\n", i+1, cell(s.Category, 18), s.Reason))
+	}
+
+	if next := m.inbox[m.inboxPos+1:]; len(next) > 0 {
+		b.WriteString("\nUp next\n")
+		for i, n := range next {
+			if i == inboxUpNext {
+				b.WriteString(fmt.Sprintf("  ... %d more\n", len(next)-inboxUpNext))
+				break
+			}
+			b.WriteString(fmt.Sprintf("  %-10s  %s  %s\n", m.disp.dateText(n.PostedAt), cell(n.Payee, 18), m.disp.money(n.AmountBani)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"example.com/pfm/internal/db"
+)
+
+// The transactions tab holds a window of at most tuiMaxPages pages of the
+// filtered list and pages in more, by keyset, when the cursor comes within
+// tuiPrefetch rows of either edge.
+const (
+	tuiMaxPages = 5
+	tuiPrefetch = 50
+)
+
+// parseTUIFilter turns the filter bar, a query in the `search` language,
+// into a SearchFilter on top of base.
+func parseTUIFilter(s string, base db.SearchFilter, saved func(string) (string, error)) (db.SearchFilter, error) {
+	q, err := parseQuery(s, saved)
+	if err != nil {
+		return base, err
+	}
+	f := base
+	f.Query = db.And(base.Query, q)
+	return f, nil
+}
+
+// applyFilter re-queries the transactions tab from the top with the filter
+// bar text.
+func (m tuiModel) applyFilter(text string) tuiModel {
+	f, err := parseTUIFilter(text, m.baseFilter, func(name string) (string, error) {
+		return db.GetSavedSearch(m.conn, name)
+	})
+	if err != nil {
+		m.status = err.Error()
+		return m
+	}
+	// Text searches list the best matches first; s and S go back to the
+	// column sort.
+	if f.HasText() {
+		f.Sort, f.Asc = db.SortRelevance, false
+	}
+	m.filter = text
+	m.txFilter = f
+	total, err := db.CountTransactions(m.conn, f)
+	if err != nil {
+		m.status = err.Error()
+		return m
+	}
+	m.total = total
+	return m.jumpStart()
+}
+
+// fetch reads one page of the current filter with the given paging fields.
+func (m tuiModel) fetch(after, before *db.TxKey, oldest bool) ([]db.TxRow, error) {
+	f := m.txFilter
+	f.Limit = m.pageSize
+	f.After, f.Before, f.Oldest = after, before, oldest
+	return db.SearchTransactions(m.conn, f)
+}
+
+func (m tuiModel) jumpStart() tuiModel {
+	rows, err := m.fetch(nil, nil, false)
+	if err != nil {
+		m.status = err.Error()
+		return m
+	}
+	m.rows, m.offset, m.cursor, m.top = rows, 0, 0, 0
+	m.atStart, m.atEnd = true, len(rows) < m.pageSize
+	return m
+}
+
+func (m tuiModel) jumpEnd() tuiModel {
+	if !m.atEnd {
+		rows, err := m.fetch(nil, nil, true)
+		if err != nil {
+			m.status = err.Error()
+			return m
+		}
+		m.rows = rows
+		m.offset = m.total - len(rows)
+		m.atStart, m.atEnd = len(rows) < m.pageSize, true
+	}
+	m.cursor = len(m.rows) - 1
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.top = m.cursor - m.visibleRows() + 1
+	return m
+}
+
+// ensureLoaded pages in older or newer rows when the cursor nears an edge
+// of the window, dropping rows from the far edge to bound memory.
+func (m tuiModel) ensureLoaded() tuiModel {
+	maxRows := tuiMaxPages * m.pageSize
+
+	if !m.atEnd && len(m.rows) > 0 && m.cursor >= len(m.rows)-tuiPrefetch {
+		key := m.rows[len(m.rows)-1].Key()
+		rows, err := m.fetch(&key, nil, false)
+		if err != nil {
+			m.status = err.Error()
+			return m
+		}
+		m.atEnd = len(rows) < m.pageSize
+		m.rows = append(m.rows, rows...)
+		if drop := len(m.rows) - maxRows; drop > 0 {
+			m.rows = append([]db.TxRow(nil), m.rows[drop:]...)
+			m.offset += drop
+			m.cursor -= drop
+			m.top -= drop
+			m.atStart = false
+		}
+	}
+
+	if !m.atStart && len(m.rows) > 0 && m.cursor < tuiPrefetch {
+		key := m.rows[0].Key()
+		rows, err := m.fetch(nil, &key, false)
+		if err != nil {
+			m.status = err.Error()
+			return m
+		}
+		m.atStart = len(rows) < m.pageSize
+		m.rows = append(rows, m.rows...)
+		m.offset -= len(rows)
+		m.cursor += len(rows)
+		m.top += len(rows)
+		if len(m.rows) > maxRows {
+			m.rows = m.rows[:maxRows]
+			m.atEnd = false
+		}
+	}
+	return m
+}
+
+// moveCursor moves by delta rows, clamped to the list, loading as needed.
+func (m tuiModel) moveCursor(delta int) tuiModel {
+	m.cursor += delta
+	for m.cursor < 0 && !m.atStart && m.status == "" {
+		m = m.ensureLoaded()
+	}
+	for m.cursor >= len(m.rows) && !m.atEnd && m.status == "" {
+		m = m.ensureLoaded()
+	}
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	return m.ensureLoaded().scroll()
+}
+
+// scroll keeps the cursor on screen, moving the view as little as possible.
+func (m tuiModel) scroll() tuiModel {
+	n := m.visibleRows()
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+n {
+		m.top = m.cursor - n + 1
+	}
+	if m.top < 0 {
+		m.top = 0
+	}
+	return m
+}
+
+// visibleRows is how many transaction rows fit on screen.
+func (m tuiModel) visibleRows() int {
+	n := m.height - 11
+	if n < 5 {
+		n = 5
+	}
+	return n
+}
@@ -0,0 +1,373 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"example.com/pfm/internal/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Optional columns of the transactions tab, in display order.
+const (
+	colAccount = "account"
+	colMemo    = "memo"
+	colTags    = "tags"
+)
+
+var tuiOptionalColumns = []string{colAccount, colMemo, colTags}
+
+// TUI preferences are kept in the settings table so they survive restarts.
+const (
+	settingTUISort    = "tui.sort"    // "<column> asc|desc"
+	settingTUIColumns = "tui.columns" // comma-separated optional columns
+	settingTUITheme   = "tui.theme"
+)
+
+type tuiPrefs struct {
+	Sort    string
+	Asc     bool
+	Columns []string
+	Theme   string
+}
+
+func loadTUIPrefs(conn *sql.DB) (tuiPrefs, error) {
+	p := tuiPrefs{Sort: db.SortDate, Theme: tuiThemes[0].Name}
+
+	sortPref, err := db.GetSetting(conn, settingTUISort, "")
+	if err != nil {
+		return p, err
+	}
+	if col, dir, _ := strings.Cut(sortPref, " "); db.ValidSort(col) {
+		p.Sort, p.Asc = col, dir == "asc"
+	}
+
+	cols, err := db.GetSetting(conn, settingTUIColumns, "")
+	if err != nil {
+		return p, err
+	}
+	for _, c := range strings.Split(cols, ",") {
+		if containsString(tuiOptionalColumns, c) {
+			p.Columns = append(p.Columns, c)
+		}
+	}
+
+	if p.Theme, err = db.GetSetting(conn, settingTUITheme, p.Theme); err != nil {
+		return p, err
+	}
+	return p, nil
+}
+
+func saveTUIPrefs(conn *sql.DB, p tuiPrefs) error {
+	dir := "desc"
+	if p.Asc {
+		dir = "asc"
+	}
+	if err := db.SetSetting(conn, settingTUISort, p.Sort+" "+dir); err != nil {
+		return err
+	}
+	if err := db.SetSetting(conn, settingTUIColumns, strings.Join(p.Columns, ",")); err != nil {
+		return err
+	}
+	return db.SetSetting(conn, settingTUITheme, p.Theme)
+}
+
+// tuiTheme colours amounts by sign and budget categories by status.
+type tuiTheme struct {
+	Name    string
+	Expense lipgloss.Style
+	Income  lipgloss.Style
+	Over    lipgloss.Style
+	Warn    lipgloss.Style
+	Header  lipgloss.Style
+}
+
+var tuiThemes = []tuiTheme{
+	{
+		Name:    "dark",
+		Expense: lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+		Income:  lipgloss.NewStyle().Foreground(lipgloss.Color("10")),
+		Over:    lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Background(lipgloss.Color("1")),
+		Warn:    lipgloss.NewStyle().Foreground(lipgloss.Color("11")),
+		Header:  lipgloss.NewStyle().Bold(true),
+	},
+	{
+		Name:    "light",
+		Expense: lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		Income:  lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		Over:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true).Underline(true),
+		Warn:    lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		Header:  lipgloss.NewStyle().Bold(true),
+	},
+	{Name: "plain"},
+}
+
+func themeByName(name string) tuiTheme {
+	for _, t := range tuiThemes {
+		if t.Name == name {
+			return t
+		}
+	}
+	return tuiThemes[0]
+}
+
+// amountStyle is the style of an amount: expenses and income differ.
+func (t tuiTheme) amountStyle(bani int64) lipgloss.Style {
+	switch {
+	case bani < 0:
+		return t.Expense
+	case bani > 0:
+		return t.Income
+	}
+	return lipgloss.NewStyle()
+}
+
+// statusStyle is the style of a budget status (OK, WARN, OVER).
+func (t tuiTheme) statusStyle(status string) lipgloss.Style {
+	switch status {
+	case "OVER":
+		return t.Over
+	case "WARN":
+		return t.Warn
+	}
+	return lipgloss.NewStyle()
+}
+
+// tuiColumn is one column of the transactions table.
+type tuiColumn struct {
+	Name  string
+	Title string
+	Width int
+	Right bool
+}
+
+// tuiDefaultWidth is assumed until the terminal reports its size.
+const tuiDefaultWidth = 100
+
+// txColumns lays the transactions table out for the terminal width: fixed
+// columns keep their width and payee (and memo, when shown) share the rest.
+func (m tuiModel) txColumns() []tuiColumn {
+	show := map[string]bool{}
+	for _, c := range m.prefs.Columns {
+		show[c] = true
+	}
+
+	cols := []tuiColumn{{Name: db.SortDate, Title: "DATE", Width: 10}, {Name: db.SortPayee, Title: "PAYEE"}}
+	if show[colMemo] {
+		cols = append(cols, tuiColumn{Name: colMemo, Title: "MEMO"})
+	}
+	cols = append(cols, tuiColumn{Name: db.SortAmount, Title: "AMOUNT", Width: 14, Right: true}, tuiColumn{Name: db.SortCategory, Title: "CATEGORY", Width: 16})
+	if show[colAccount] {
+		cols = append(cols, tuiColumn{Name: colAccount, Title: "ACCOUNT", Width: 12})
+	}
+	if show[colTags] {
+		cols = append(cols, tuiColumn{Name: colTags, Title: "TAGS", Width: 16})
+	}
+
+	width := m.width
+	if width <= 0 {
+		width = tuiDefaultWidth
+	}
+	free := width - 2 - 2*(len(cols)-1) // cursor prefix and column gaps
+	for _, c := range cols {
+		free -= c.Width
+	}
+
+	payee, memo := free, 0
+	if show[colMemo] {
+		payee = free * 3 / 5
+		memo = free - payee
+		if memo < 10 {
+			memo = 10
+		}
+	}
+	if payee < 18 {
+		payee = 18
+	}
+	for i := range cols {
+		switch cols[i].Name {
+		case db.SortPayee:
+			cols[i].Width = payee
+		case colMemo:
+			cols[i].Width = memo
+		}
+	}
+	return cols
+}
+
+// txHeader is the table header, marking the sort column (none when sorted
+// by relevance).
+func (m tuiModel) txHeader(cols []tuiColumn) string {
+	arrow := " ▼"
+	if m.txFilter.Asc {
+		arrow = " ▲"
+	}
+	titles := make([]string, len(cols))
+	rules := make([]string, len(cols))
+	for i, c := range cols {
+		title := c.Title
+		if c.Name == m.txFilter.Sort {
+			title += arrow
+		}
+		if c.Right {
+			titles[i] = padLeft(title, c.Width)
+		} else {
+			titles[i] = padRight(title, c.Width)
+		}
+		rules[i] = strings.Repeat("-", c.Width)
+	}
+	return m.theme.Header.Render("  "+strings.Join(titles, "  ")) + "\n  " + strings.Join(rules, "  ") + "\n"
+}
+
+// txLine renders one transaction with the columns' widths. The amount is
+// coloured by sign and the category when its budget is over for the month.
+func (m tuiModel) txLine(cols []tuiColumn, r db.TxRow, prefix string, over bool) string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		var text string
+		style := lipgloss.NewStyle()
+		switch c.Name {
+		case db.SortDate:
+			text = m.disp.date(r.PostedAt)
+		case db.SortPayee:
+			text = r.Payee
+		case colMemo:
+			text = r.Memo
+		case db.SortAmount:
+			text = m.disp.money(r.AmountBani)
+			style = m.theme.amountStyle(r.AmountBani)
+		case db.SortCategory:
+			text = r.Category
+			if over {
+				style = m.theme.Over
+			}
+		case colAccount:
+			text = r.Account
+		case colTags:
+			text = strings.Join(r.Tags, ",")
+		}
+		text = trunc(text, c.Width)
+		if c.Right {
+			text = padLeft(text, c.Width)
+		} else {
+			text = padRight(text, c.Width)
+		}
+		cells[i] = style.Render(text)
+	}
+	return prefix + strings.Join(cells, "  ") + "\n"
+}
+
+// overBudget reports the categories over budget in month, cached until the
+// next change to transactions.
+func (m tuiModel) overBudget(month string) map[string]bool {
+	if over, ok := m.overCache[month]; ok {
+		return over
+	}
+	over := map[string]bool{}
+	lines, err := resolveBudgets(m.conn, month)
+	if err == nil {
+		for _, l := range lines {
+			if _, status := budgetStatus(l.SpentBani, l.EffectiveBani(), m.warnPct); status == "OVER" {
+				over[l.Category] = true
+			}
+		}
+	}
+	m.overCache[month] = over
+	return over
+}
+
+// setSort changes the list order of the transactions tab and saves it.
+func (m tuiModel) setSort(col string, asc bool) tuiModel {
+	m.prefs.Sort, m.prefs.Asc = col, asc
+	m.baseFilter.Sort, m.baseFilter.Asc = col, asc
+	m.txFilter.Sort, m.txFilter.Asc = col, asc
+	m = m.savePrefs().jumpStart()
+	dir := "descending"
+	if asc {
+		dir = "ascending"
+	}
+	if m.status == "" {
+		m.status = fmt.Sprintf("Sorted by %s, %s", col, dir)
+	}
+	return m
+}
+
+// nextSort is the sort column after the current one.
+func (m tuiModel) nextSort() string {
+	for i, c := range db.SortColumns {
+		if c == m.prefs.Sort {
+			return db.SortColumns[(i+1)%len(db.SortColumns)]
+		}
+	}
+	return db.SortDate
+}
+
+func (m tuiModel) nextTheme() tuiModel {
+	for i, t := range tuiThemes {
+		if t.Name == m.theme.Name {
+			m.theme = tuiThemes[(i+1)%len(tuiThemes)]
+			break
+		}
+	}
+	m.prefs.Theme = m.theme.Name
+	m = m.savePrefs()
+	if m.status == "" {
+		m.status = "Theme: " + m.theme.Name
+	}
+	return m
+}
+
+func (m tuiModel) savePrefs() tuiModel {
+	if err := saveTUIPrefs(m.conn, m.prefs); err != nil {
+		m.status = err.Error()
+	}
+	return m
+}
+
+// updateColumns toggles optional columns of the transactions tab.
+func (m tuiModel) updateColumns(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "v", "q":
+		m.mode = tuiBrowse
+	case "up", "k":
+		if m.columnCursor > 0 {
+			m.columnCursor--
+		}
+	case "down", "j":
+		if m.columnCursor < len(tuiOptionalColumns)-1 {
+			m.columnCursor++
+		}
+	case " ", "enter":
+		col := tuiOptionalColumns[m.columnCursor]
+		var cols []string
+		for _, c := range tuiOptionalColumns {
+			if (c == col) != containsString(m.prefs.Columns, c) {
+				cols = append(cols, c)
+			}
+		}
+		m.prefs.Columns = cols
+		m = m.savePrefs()
+	}
+	return m, nil
+}
+
+func (m tuiModel) columnsView() string {
+	var b strings.Builder
+	b.WriteString("Optional columns\n\n")
+	for i, c := range tuiOptionalColumns {
+		prefix := "  "
+		if i == m.columnCursor {
+			prefix = "> "
+		}
+		mark := "[ ]"
+		if containsString(m.prefs.Columns, c) {
+			mark = "[x]"
+		}
+		b.WriteString(fmt.Sprintf("%s%s %s\n", prefix, mark, c))
+	}
+	b.WriteString("\n↑/↓ choose  space toggle  esc close\n")
+	return b.String()
+}
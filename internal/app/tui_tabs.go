@@ -0,0 +1,539 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/pfm/internal/db"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TUI tabs, in tab-bar order.
+const (
+	tabDashboard = iota
+	tabTransactions
+	tabBudgets
+	tabReports
+	tabRules
+	tabGoals
+	tabInbox
+)
+
+var tuiTabNames = []string{"Dashboard", "Transactions", "Budgets", "Reports", "Rules", "Goals", "Inbox"}
+
+// tuiRuleSample is how many recent transactions rules are tested against.
+const tuiRuleSample = 5000
+
+type tuiDashboard struct {
+	Summary db.MonthSummary
+	Top     []db.CategoryTotal
+	Budgets []budgetLine
+}
+
+type tuiBudgetRow struct {
+	Line budgetLine
+	Pace budgetPace
+}
+
+type tuiReportLine struct {
+	Category string
+	ThisBani int64
+	PrevBani int64
+}
+
+type tuiReport struct {
+	Summary db.MonthSummary
+	Prev    db.MonthSummary
+	Lines   []tuiReportLine
+}
+
+// switchTab moves to tab and loads its data.
+func (m tuiModel) switchTab(tab int) tuiModel {
+	m.tab = tab
+	return m.load()
+}
+
+// load re-queries the data of the current tab for the current month.
+func (m tuiModel) load() tuiModel {
+	var err error
+	m.overCache = map[string]map[string]bool{}
+	switch m.tab {
+	case tabDashboard:
+		m.dash, err = loadDashboard(m)
+	case tabBudgets:
+		m.budgetRows, err = loadBudgetRows(m)
+		if m.budgetCursor >= len(m.budgetRows) {
+			m.budgetCursor = 0
+		}
+	case tabReports:
+		m.report, err = loadReport(m)
+	case tabRules:
+		m.rules, err = db.ListRules(m.conn)
+		if err == nil && m.ruleSample == nil {
+			m.ruleSample, err = db.SearchTransactions(m.conn, db.SearchFilter{Limit: tuiRuleSample})
+		}
+		if m.ruleCursor >= len(m.rules) {
+			m.ruleCursor = 0
+		}
+	case tabGoals:
+		m.goals, err = loadGoalProgress(m.conn, time.Now())
+	case tabInbox:
+		m, err = loadInbox(m)
+	}
+	if err != nil {
+		m.status = err.Error()
+	}
+	return m
+}
+
+func loadDashboard(m tuiModel) (tuiDashboard, error) {
+	var d tuiDashboard
+	var err error
+	if d.Summary, err = db.GetMonthSummary(m.conn, m.month, ""); err != nil {
+		return d, err
+	}
+	if d.Top, _, err = db.GetCategoryTotalsForMonth(m.conn, m.month, true, ""); err != nil {
+		return d, err
+	}
+	if len(d.Top) > 5 {
+		d.Top = d.Top[:5]
+	}
+	if d.Budgets, err = resolveBudgets(m.conn, m.month); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+func loadBudgetRows(m tuiModel) ([]tuiBudgetRow, error) {
+	lines, err := resolveBudgets(m.conn, m.month)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tuiBudgetRow, 0, len(lines))
+	for _, l := range lines {
+		p, err := paceBudget(m.conn, l, m.month, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tuiBudgetRow{Line: l, Pace: p})
+	}
+	return out, nil
+}
+
+// loadReport compares the month's categories with the previous month.
+func loadReport(m tuiModel) (tuiReport, error) {
+	var r tuiReport
+	var err error
+	prev := addMonths(m.month, -1)
+	if r.Summary, err = db.GetMonthSummary(m.conn, m.month, ""); err != nil {
+		return r, err
+	}
+	if r.Prev, err = db.GetMonthSummary(m.conn, prev, ""); err != nil {
+		return r, err
+	}
+	cur, _, err := db.GetCategoryTotalsForMonth(m.conn, m.month, false, "")
+	if err != nil {
+		return r, err
+	}
+	old, _, err := db.GetCategoryTotalsForMonth(m.conn, prev, false, "")
+	if err != nil {
+		return r, err
+	}
+
+	byCat := map[string]*tuiReportLine{}
+	for _, c := range cur {
+		byCat[c.Category] = &tuiReportLine{Category: c.Category, ThisBani: c.TotalBani}
+	}
+	for _, c := range old {
+		l := byCat[c.Category]
+		if l == nil {
+			l = &tuiReportLine{Category: c.Category}
+			byCat[c.Category] = l
+		}
+		l.PrevBani = c.TotalBani
+	}
+	for _, l := range byCat {
+		r.Lines = append(r.Lines, *l)
+	}
+	sort.Slice(r.Lines, func(i, j int) bool {
+		if r.Lines[i].ThisBani != r.Lines[j].ThisBani {
+			return r.Lines[i].ThisBani < r.Lines[j].ThisBani
+		}
+		return r.Lines[i].Category < r.Lines[j].Category
+	})
+	return r, nil
+}
+
+func (m tuiModel) tabBar() string {
+	var parts []string
+	for i, name := range tuiTabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i == m.tab {
+			label = "[" + label + "]"
+		} else {
+			label = " " + label + " "
+		}
+		parts = append(parts, label)
+	}
+	bar := strings.Join(parts, " ")
+	switch m.tab {
+	case tabDashboard, tabBudgets, tabReports:
+		bar += "   ‹ " + m.month + " ›"
+	}
+	return bar
+}
+
+func (m tuiModel) dashboardView() string {
+	d := m.dash
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %d transaction(s)\n", m.month, d.Summary.Count))
+	b.WriteString(fmt.Sprintf("  Income:   %s\n", m.theme.amountStyle(d.Summary.IncomeBani).Render(m.disp.money(d.Summary.IncomeBani))))
+	b.WriteString(fmt.Sprintf("  Expenses: %s\n", m.theme.amountStyle(d.Summary.ExpenseBani).Render(m.disp.money(d.Summary.ExpenseBani))))
+	b.WriteString(fmt.Sprintf("  Net:      %s\n", m.theme.amountStyle(d.Summary.NetBani).Render(m.disp.money(d.Summary.NetBani))))
+
+	b.WriteString("\nTop categories\n")
+	if len(d.Top) == 0 {
+		b.WriteString("  (no expenses)\n")
+	}
+	for _, c := range d.Top {
+		b.WriteString(fmt.Sprintf("  %s  %-14s  %d tx\n", cell(c.Category, 18), m.disp.money(c.TotalBani), c.Count))
+	}
+
+	b.WriteString("\nBudgets\n")
+	if len(d.Budgets) == 0 {
+		b.WriteString("  (no budgets for this month)\n")
+	}
+	for _, l := range d.Budgets {
+		pct, status := budgetStatus(l.SpentBani, l.EffectiveBani(), m.warnPct)
+		b.WriteString(fmt.Sprintf("  %s  %s  %4d%%  %s  %s of %s\n",
+			cell(l.Category, 18), progressBar(pct, 20), pct, m.theme.statusStyle(status).Render(fmt.Sprintf("%-4s", status)),
+			m.disp.money(l.SpentBani), m.disp.money(l.EffectiveBani())))
+	}
+	return b.String()
+}
+
+func (m tuiModel) budgetsView() string {
+	if len(m.budgetRows) == 0 {
+		return "No budgets for " + m.month + ". Press n to add one.\n"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  %-18s  %-12s  %-12s  %-12s  %-12s  %-6s  %-6s  %s\n",
+		"CATEGORY", "LIMIT", "CARRY IN", "EFFECTIVE", "SPENT", "USED", "STATUS", "PROJECTED"))
+	b.WriteString("  ------------------  ------------  ------------  ------------  ------------  ------  ------  ------------\n")
+	for i, r := range m.budgetRows {
+		prefix := "  "
+		if i == m.budgetCursor {
+			prefix = "> "
+		}
+		effective := r.Line.EffectiveBani()
+		pct, status := budgetStatus(r.Line.SpentBani, effective, m.warnPct)
+		carry := "-"
+		if r.Line.Rollover {
+			carry = m.disp.money(r.Line.CarryBani)
+		}
+		projected := m.disp.money(r.Pace.ProjectedBani)
+		if r.Pace.PendingBani > 0 {
+			projected += "*"
+		}
+		b.WriteString(fmt.Sprintf("%s%s  %-12s  %-12s  %-12s  %-12s  %5d%%  %s  %s\n",
+			prefix, cell(r.Line.Category, 18), m.disp.money(r.Line.LimitBani), carry,
+			m.disp.money(effective), m.disp.money(r.Line.SpentBani), pct, m.theme.statusStyle(status).Render(fmt.Sprintf("%-6s", status)), projected))
+	}
+	return b.String()
+}
+
+func (m tuiModel) reportsView() string {
+	r := m.report
+	prev := addMonths(m.month, -1)
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-10s  %-14s  %-14s  %-14s  %s\n", "MONTH", "INCOME", "EXPENSES", "NET", "COUNT"))
+	b.WriteString("----------  --------------  --------------  --------------  -----\n")
+	for _, s := range []db.MonthSummary{r.Summary, r.Prev} {
+		b.WriteString(fmt.Sprintf("%-10s  %-14s  %-14s  %-14s  %d\n",
+			s.Month, m.disp.money(s.IncomeBani), m.disp.money(s.ExpenseBani), m.disp.money(s.NetBani), s.Count))
+	}
+
+	b.WriteString(fmt.Sprintf("\n%-18s  %-14s  %-14s  %s\n", "CATEGORY", m.month, prev, "CHANGE"))
+	b.WriteString("------------------  --------------  --------------  --------------\n")
+	if len(r.Lines) == 0 {
+		b.WriteString("(no transactions)\n")
+	}
+	limit := m.height - 16
+	if limit < 5 {
+		limit = 5
+	}
+	for i, l := range r.Lines {
+		if i == limit {
+			b.WriteString(fmt.Sprintf("... %d more\n", len(r.Lines)-limit))
+			break
+		}
+		b.WriteString(fmt.Sprintf("%s  %-14s  %-14s  %s\n",
+			cell(l.Category, 18), m.disp.money(l.ThisBani), m.disp.money(l.PrevBani), m.disp.money(l.ThisBani-l.PrevBani)))
+	}
+	return b.String()
+}
+
+// ruleImpact sums up testRule for the rules tab, with up to examples lines
+// describing matched transactions.
+func ruleImpact(d display, rules []compiledRule, sample []db.TxRow, cand compiledRule, examples int) (matched, fresh, change, shadowed int, lines []string) {
+	for _, row := range testRule(rules, sample, cand) {
+		matched++
+		var result string
+		switch row.Outcome {
+		case ruleShadowed:
+			shadowed++
+			result = fmt.Sprintf("shadowed by #%d %s", row.Winner.ID, row.Winner.Name)
+		case ruleMatch, ruleUnchanged:
+			result = row.Outcome
+		case ruleSets:
+			fresh++
+			result = "would set " + cand.Category
+		case ruleChanges:
+			change++
+			result = "would change to " + cand.Category
+		}
+		if len(lines) < examples {
+			t := row.Tx
+			lines = append(lines, fmt.Sprintf("  %-10s  %s  %s  %s",
+				d.date(t.PostedAt), cell(t.Payee, 18), cell(t.Category, 14), result))
+		}
+	}
+	return matched, fresh, change, shadowed, lines
+}
+
+// ruleHint is the live test line shown under the rule form.
+func (m tuiModel) ruleHint(id, priority int64, v []string) string {
+	if v[1] == "" {
+		return ""
+	}
+	re, err := regexp.Compile(v[1])
+	if err != nil {
+		return "Invalid regex: " + err.Error()
+	}
+	rules, err := compileRules(m.rules)
+	if err != nil {
+		return err.Error()
+	}
+	cand := compiledRule{ID: id, Name: v[0], Category: strings.TrimSpace(v[2]), Re: re, Priority: priority}
+	matched, fresh, change, shadowed, _ := ruleImpact(m.disp, rules, m.ruleSample, cand, 0)
+	return fmt.Sprintf("Matches %d of %d recent transaction(s): %d uncategorized, %d would change, %d shadowed",
+		matched, len(m.ruleSample), fresh, change, shadowed)
+}
+
+func (m tuiModel) rulesView() string {
+	if len(m.rules) == 0 {
+		return "No rules yet. Press n to add one.\n"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  %-5s  %-8s  %-3s  %-18s  %-28s  %-16s  %s\n", "ID", "PRIORITY", "ON", "NAME", "PATTERN", "CATEGORY", "HITS"))
+	b.WriteString("  -----  --------  ---  ------------------  ----------------------------  ----------------  ----\n")
+	for i, r := range m.rules {
+		prefix := "  "
+		if i == m.ruleCursor {
+			prefix = "> "
+		}
+		on := "yes"
+		if !r.Enabled {
+			on = "no"
+		}
+		b.WriteString(fmt.Sprintf("%s%-5d  %-8d  %-3s  %s  %s  %s  %d\n",
+			prefix, r.ID, r.Priority, on, cell(r.Name, 18), cell(r.Pattern, 28), cell(r.Category, 16), r.HitCount))
+	}
+
+	if m.showRuleTest && m.ruleCursor < len(m.rules) {
+		r := m.rules[m.ruleCursor]
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return b.String() + "\nInvalid regex: " + err.Error() + "\n"
+		}
+		rules, err := compileRules(m.rules)
+		if err != nil {
+			return b.String() + "\n" + err.Error() + "\n"
+		}
+		cand := compiledRule{ID: r.ID, Name: r.Name, Category: r.Category, Re: re, Priority: r.Priority}
+		matched, fresh, change, shadowed, lines := ruleImpact(m.disp, rules, m.ruleSample, cand, 10)
+		b.WriteString(fmt.Sprintf("\nRule #%d matches %d of %d recent transaction(s): %d uncategorized, %d would change, %d shadowed\n",
+			r.ID, matched, len(m.ruleSample), fresh, change, shadowed))
+		for _, l := range lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (m tuiModel) updateBudgets(k string) (tea.Model, tea.Cmd) {
+	switch k {
+	case "up", "k":
+		if m.budgetCursor > 0 {
+			m.budgetCursor--
+		}
+	case "down", "j":
+		if m.budgetCursor < len(m.budgetRows)-1 {
+			m.budgetCursor++
+		}
+	case "e", "enter":
+		if m.budgetCursor >= len(m.budgetRows) {
+			return m, nil
+		}
+		l := m.budgetRows[m.budgetCursor].Line
+		return m.openForm(tuiFormState{
+			title:  fmt.Sprintf("Limit for %s in %s (overrides a recurring budget for this month)", l.Category, m.month),
+			labels: []string{"Limit"},
+			values: []string{formatAmount(l.LimitBani)},
+			save: func(m tuiModel, v []string) (tuiModel, error) {
+				return m.saveBudget(l.Category, v[0])
+			},
+		}), nil
+	case "n":
+		return m.openForm(tuiFormState{
+			title:  "New budget for " + m.month,
+			labels: []string{"Category", "Limit"},
+			values: []string{"", ""},
+			save: func(m tuiModel, v []string) (tuiModel, error) {
+				cat := strings.TrimSpace(v[0])
+				if cat == "" {
+					return m, errors.New("category must not be empty")
+				}
+				return m.saveBudget(cat, v[1])
+			},
+		}), nil
+	}
+	return m, nil
+}
+
+func (m tuiModel) saveBudget(category, limitStr string) (tuiModel, error) {
+	limit, err := ParseRON(limitStr)
+	if err != nil {
+		return m, fmt.Errorf("invalid limit: %w", err)
+	}
+	if limit < 0 {
+		return m, errors.New("limit must not be negative")
+	}
+	if err := db.UpsertBudget(m.conn, m.month, category, limit); err != nil {
+		return m, err
+	}
+	m = m.load()
+	if m.status == "" {
+		m.status = fmt.Sprintf("Budget set: %s %s = %s", m.month, category, m.disp.money(limit))
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateRules(k string) (tea.Model, tea.Cmd) {
+	switch k {
+	case "up", "k":
+		if m.ruleCursor > 0 {
+			m.ruleCursor--
+		}
+	case "down", "j":
+		if m.ruleCursor < len(m.rules)-1 {
+			m.ruleCursor++
+		}
+	case "t", "enter":
+		m.showRuleTest = !m.showRuleTest
+	case "n":
+		return m.openRuleForm(db.RuleRow{Priority: m.nextRulePriority()}), nil
+	case "e":
+		if m.ruleCursor < len(m.rules) {
+			return m.openRuleForm(m.rules[m.ruleCursor]), nil
+		}
+	case "x":
+		if m.ruleCursor < len(m.rules) {
+			r := m.rules[m.ruleCursor]
+			if err := db.SetRuleEnabled(m.conn, r.ID, !r.Enabled); err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+			m = m.load()
+		}
+	case "K", "J":
+		i := m.ruleCursor
+		j := i - 1
+		if k == "J" {
+			j = i + 1
+		}
+		if i >= len(m.rules) || j < 0 || j >= len(m.rules) {
+			return m, nil
+		}
+		ids := make([]int64, len(m.rules))
+		for n, r := range m.rules {
+			ids[n] = r.ID
+		}
+		ids[i], ids[j] = ids[j], ids[i]
+		if err := db.ReorderRules(m.conn, ids); err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.ruleCursor = j
+		m = m.load()
+	}
+	return m, nil
+}
+
+// nextRulePriority places a new rule after the existing ones.
+func (m tuiModel) nextRulePriority() int64 {
+	priority := int64(100)
+	if n := len(m.rules); n > 0 && m.rules[n-1].Priority >= priority {
+		priority = m.rules[n-1].Priority + 10
+	}
+	return priority
+}
+
+// openRuleForm adds a rule (r.ID == 0) or edits r, testing the pattern
+// against recent transactions as it is typed.
+func (m tuiModel) openRuleForm(r db.RuleRow) tuiModel {
+	title := "New rule"
+	if r.ID != 0 {
+		title = fmt.Sprintf("Edit rule #%d", r.ID)
+	}
+	return m.openForm(tuiFormState{
+		title:  title,
+		labels: []string{"Name", "Pattern", "Category"},
+		values: []string{r.Name, r.Pattern, r.Category},
+		hint: func(m tuiModel, v []string) string {
+			return m.ruleHint(r.ID, r.Priority, v)
+		},
+		save: func(m tuiModel, v []string) (tuiModel, error) {
+			r.Name, r.Pattern, r.Category = strings.TrimSpace(v[0]), v[1], strings.TrimSpace(v[2])
+			if r.Name == "" || r.Pattern == "" || r.Category == "" {
+				return m, errors.New("name, pattern and category are required")
+			}
+			if _, err := regexp.Compile(r.Pattern); err != nil {
+				return m, fmt.Errorf("invalid regex: %w", err)
+			}
+			var err error
+			if r.ID == 0 {
+				r.ID, err = db.AddRule(m.conn, r.Name, r.Pattern, r.Category, r.Priority)
+			} else {
+				err = db.UpdateRule(m.conn, r)
+			}
+			if err != nil {
+				return m, err
+			}
+			applied := 0
+			if m.tab == tabInbox {
+				if m, applied, err = m.inboxApplyRule(r.ID); err != nil {
+					return m, err
+				}
+			}
+			m = m.load()
+			for i, x := range m.rules {
+				if x.ID == r.ID {
+					m.ruleCursor = i
+				}
+			}
+			if m.status == "" {
+				m.status = fmt.Sprintf("Rule #%d saved: %s -> %s", r.ID, r.Name, r.Category)
+				if m.tab == tabInbox {
+					m.status += fmt.Sprintf("; categorized %d queued transaction(s)", applied)
+				}
+			}
+			return m, nil
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// AlertKey identifies an alert that should fire at most once: a period
+// (YYYY-MM for monthly budgets, YYYY for yearly ones), a category and a kind.
+type AlertKey struct {
+	Period   string
+	Category string
+	Kind     string
+}
+
+// ListSentAlerts returns the alerts already sent for the given periods.
+func ListSentAlerts(conn *sql.DB, periods ...string) (map[AlertKey]bool, error) {
+	out := map[AlertKey]bool{}
+	for _, p := range periods {
+		rows, err := conn.Query(`
+			SELECT period, category, kind
+			FROM budget_alerts
+			WHERE period = ?
+		`, p)
+		if err != nil {
+			return nil, fmt.Errorf("list alerts: %w", err)
+		}
+		for rows.Next() {
+			var k AlertKey
+			if err := rows.Scan(&k.Period, &k.Category, &k.Kind); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			out[k] = true
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rows.Close()
+	}
+	return out, nil
+}
+
+func RecordAlert(conn *sql.DB, k AlertKey, message string) error {
+	_, err := conn.Exec(`
+		INSERT INTO budget_alerts (period, category, kind, message)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(period, category, kind) DO NOTHING
+	`, k.Period, k.Category, k.Kind, message)
+	if err != nil {
+		return fmt.Errorf("record alert: %w", err)
+	}
+	return nil
+}
+
+// ResetAlerts forgets the alerts sent for a period so they can fire again.
+func ResetAlerts(conn *sql.DB, period string) (int64, error) {
+	res, err := conn.Exec(`DELETE FROM budget_alerts WHERE period = ?`, period)
+	if err != nil {
+		return 0, fmt.Errorf("reset alerts: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// AssetRow is something owned or owed outside the tracked accounts, such as
+// an apartment, a car, a pension fund or a personal debt. Liabilities are
+// valued as positive amounts and subtracted from net worth.
+type AssetRow struct {
+	ID        int64
+	Name      string
+	Class     string
+	Liability bool
+	// Latest valuation, if any.
+	ValueBani int64
+	ValuedAt  string
+}
+
+type Valuation struct {
+	AssetID   int64
+	ValuedAt  string // YYYY-MM-DD
+	ValueBani int64
+}
+
+// AssetValuation is one row of an asset import: the asset, created if
+// needed, and its value on a date.
+type AssetValuation struct {
+	Name      string
+	Class     string
+	Liability bool
+	ValuedAt  string // YYYY-MM-DD
+	ValueBani int64
+	Note      string
+}
+
+// AddAsset creates an asset, or returns the id of the existing one with that
+// name. An existing asset with another class or liability flag is an error.
+func AddAsset(conn *sql.DB, name, class string, liability bool) (int64, error) {
+	return addAsset(conn, name, class, liability)
+}
+
+func addAsset(conn queryExecer, name, class string, liability bool) (int64, error) {
+	var (
+		id       int64
+		curClass string
+		curLiab  bool
+	)
+	err := conn.QueryRow(`SELECT id, class, liability FROM assets WHERE name = ?`, name).Scan(&id, &curClass, &curLiab)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := conn.Exec(`INSERT INTO assets (name, class, liability) VALUES (?, ?, ?)`, name, class, liability)
+		if err != nil {
+			return 0, fmt.Errorf("add asset: %w", err)
+		}
+		return res.LastInsertId()
+	case err != nil:
+		return 0, fmt.Errorf("asset id: %w", err)
+	case curClass != class:
+		return 0, fmt.Errorf("asset %q already exists with class %q, not %q", name, curClass, class)
+	case curLiab && !liability:
+		return 0, fmt.Errorf("asset %q already exists as a liability", name)
+	case !curLiab && liability:
+		return 0, fmt.Errorf("asset %q already exists and is not a liability", name)
+	}
+	return id, nil
+}
+
+// ImportValuations adds every valuation, creating assets as needed, in one
+// transaction: either all rows are stored or none.
+func ImportValuations(conn *sql.DB, vals []AssetValuation) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("import valuations: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, v := range vals {
+		id, err := addAsset(tx, v.Name, v.Class, v.Liability)
+		if err != nil {
+			return err
+		}
+		if err := upsertValuation(tx, id, v.ValuedAt, v.ValueBani, v.Note); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func GetAssetID(conn *sql.DB, name string) (int64, error) {
+	var id int64
+	err := conn.QueryRow(`SELECT id FROM assets WHERE name = ?`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("asset %q not found", name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get asset: %w", err)
+	}
+	return id, nil
+}
+
+// UpsertValuation sets an asset's value on a date, replacing any earlier
+// valuation for the same date.
+func UpsertValuation(conn *sql.DB, assetID int64, date string, valueBani int64, note string) error {
+	return upsertValuation(conn, assetID, date, valueBani, note)
+}
+
+func upsertValuation(conn execer, assetID int64, date string, valueBani int64, note string) error {
+	_, err := conn.Exec(`
+		INSERT INTO asset_valuations (asset_id, valued_at, value_bani, note)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(asset_id, valued_at) DO UPDATE SET
+			value_bani = excluded.value_bani,
+			note = excluded.note
+	`, assetID, date, valueBani, note)
+	if err != nil {
+		return fmt.Errorf("upsert valuation: %w", err)
+	}
+	return nil
+}
+
+func ListAssets(conn *sql.DB) ([]AssetRow, error) {
+	rows, err := conn.Query(`
+		SELECT a.id, a.name, a.class, a.liability,
+		       COALESCE(v.value_bani, 0), COALESCE(v.valued_at, '')
+		FROM assets a
+		LEFT JOIN asset_valuations v ON v.id = (
+			SELECT id FROM asset_valuations
+			WHERE asset_id = a.id
+			ORDER BY valued_at DESC
+			LIMIT 1
+		)
+		ORDER BY a.liability, a.class, a.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list assets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AssetRow
+	for rows.Next() {
+		var a AssetRow
+		if err := rows.Scan(&a.ID, &a.Name, &a.Class, &a.Liability, &a.ValueBani, &a.ValuedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListValuations returns every valuation ordered by asset and date.
+func ListValuations(conn *sql.DB) ([]Valuation, error) {
+	rows, err := conn.Query(`
+		SELECT asset_id, valued_at, value_bani
+		FROM asset_valuations
+		ORDER BY asset_id, valued_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list valuations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Valuation
+	for rows.Next() {
+		var v Valuation
+		if err := rows.Scan(&v.AssetID, &v.ValuedAt, &v.ValueBani); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBalanceAsOf sums all transactions posted on or before date.
+func GetBalanceAsOf(conn *sql.DB, date string) (int64, error) {
+	var bal int64
+	err := conn.QueryRow(`
+		SELECT COALESCE(SUM(amount_bani), 0)
+		FROM transactions
+		WHERE posted_at <= ?
+	`, date).Scan(&bal)
+	if err != nil {
+		return 0, fmt.Errorf("balance: %w", err)
+	}
+	return bal, nil
+}
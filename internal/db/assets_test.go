@@ -0,0 +1,65 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportValuations(t *testing.T) {
+	conn := openTestDB(t, nil)
+	if _, err := AddAsset(conn, "Flat", "property", false); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := AddAsset(conn, "Flat", "property", false); err != nil || id != 1 {
+		t.Errorf("AddAsset existing = %d, %v; want 1", id, err)
+	}
+
+	tests := []struct {
+		name string
+		vals []AssetValuation
+		err  string
+	}{
+		{"class mismatch", []AssetValuation{
+			{Name: "Car", Class: "vehicle", ValuedAt: "2026-01-31", ValueBani: 100},
+			{Name: "Flat", Class: "other", ValuedAt: "2026-01-31", ValueBani: 100},
+		}, `asset "Flat" already exists with class "property", not "other"`},
+		{"liability mismatch", []AssetValuation{
+			{Name: "Flat", Class: "property", Liability: true, ValuedAt: "2026-01-31", ValueBani: 100},
+		}, `asset "Flat" already exists and is not a liability`},
+		{"mismatch within the file", []AssetValuation{
+			{Name: "Debt", Class: "personal", Liability: true, ValuedAt: "2026-01-31", ValueBani: 100},
+			{Name: "Debt", Class: "personal", ValuedAt: "2026-02-28", ValueBani: 50},
+		}, `asset "Debt" already exists as a liability`},
+	}
+	for _, tt := range tests {
+		err := ImportValuations(conn, tt.vals)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	// Failed imports leave nothing behind.
+	assets, err := ListAssets(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].ValuedAt != "" {
+		t.Fatalf("after failed imports: %+v, want only Flat without valuations", assets)
+	}
+
+	err = ImportValuations(conn, []AssetValuation{
+		{Name: "Flat", Class: "property", ValuedAt: "2026-01-31", ValueBani: 900},
+		{Name: "Car", Class: "vehicle", ValuedAt: "2026-01-31", ValueBani: 300},
+		{Name: "Car", Class: "vehicle", ValuedAt: "2026-02-28", ValueBani: 280},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := ListValuations(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vals) != 3 {
+		t.Errorf("%d valuations, want 3", len(vals))
+	}
+}
@@ -64,3 +64,174 @@ func GetSpentForMonthCategory(conn *sql.DB, month, category string) (int64, erro
 	}
 	return spent, nil
 }
+
+// BudgetTemplate is a recurring monthly budget that applies from StartMonth
+// until a template with a later StartMonth replaces it.
+type BudgetTemplate struct {
+	Category   string
+	StartMonth string // YYYY-MM
+	LimitBani  int64
+	Rollover   bool
+}
+
+func UpsertBudgetTemplate(conn *sql.DB, t BudgetTemplate) error {
+	_, err := conn.Exec(`
+		INSERT INTO budget_templates (category, start_month, limit_bani, rollover)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(category, start_month) DO UPDATE SET
+			limit_bani = excluded.limit_bani,
+			rollover = excluded.rollover
+	`, t.Category, t.StartMonth, t.LimitBani, t.Rollover)
+	if err != nil {
+		return fmt.Errorf("upsert budget template: %w", err)
+	}
+	return nil
+}
+
+// ListBudgetTemplates returns every template ordered by category, then start month.
+func ListBudgetTemplates(conn *sql.DB) ([]BudgetTemplate, error) {
+	rows, err := conn.Query(`
+		SELECT category, start_month, limit_bani, rollover
+		FROM budget_templates
+		ORDER BY category ASC, start_month ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list budget templates: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BudgetTemplate
+	for rows.Next() {
+		var t BudgetTemplate
+		if err := rows.Scan(&t.Category, &t.StartMonth, &t.LimitBani, &t.Rollover); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListBudgetsForCategory returns the one-off monthly budgets of a category.
+func ListBudgetsForCategory(conn *sql.DB, category string) ([]BudgetRow, error) {
+	rows, err := conn.Query(`
+		SELECT month, category, limit_bani
+		FROM budgets
+		WHERE category = ?
+		ORDER BY month ASC
+	`, category)
+	if err != nil {
+		return nil, fmt.Errorf("list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BudgetRow
+	for rows.Next() {
+		var b BudgetRow
+		if err := rows.Scan(&b.Month, &b.Category, &b.LimitBani); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type AnnualBudgetRow struct {
+	Year      string
+	Category  string
+	LimitBani int64
+}
+
+func UpsertAnnualBudget(conn *sql.DB, year, category string, limitBani int64) error {
+	_, err := conn.Exec(`
+		INSERT INTO annual_budgets (year, category, limit_bani)
+		VALUES (?, ?, ?)
+		ON CONFLICT(year, category) DO UPDATE SET limit_bani = excluded.limit_bani
+	`, year, category, limitBani)
+	if err != nil {
+		return fmt.Errorf("upsert annual budget: %w", err)
+	}
+	return nil
+}
+
+func ListAnnualBudgets(conn *sql.DB, year string) ([]AnnualBudgetRow, error) {
+	rows, err := conn.Query(`
+		SELECT year, category, limit_bani
+		FROM annual_budgets
+		WHERE year = ?
+		ORDER BY category ASC
+	`, year)
+	if err != nil {
+		return nil, fmt.Errorf("list annual budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AnnualBudgetRow
+	for rows.Next() {
+		var b AnnualBudgetRow
+		if err := rows.Scan(&b.Year, &b.Category, &b.LimitBani); err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSpentByMonth returns a category's spending (negative) per month for
+// months in [fromMonth, toMonth]. Months without spending are absent.
+func GetSpentByMonth(conn *sql.DB, category, fromMonth, toMonth string) (map[string]int64, error) {
+	rows, err := conn.Query(`
+		SELECT substr(posted_at, 1, 7) AS month, COALESCE(SUM(amount_bani), 0)
+		FROM transactions
+		WHERE category = ?
+		  AND amount_bani < 0
+		  AND substr(posted_at, 1, 7) BETWEEN ? AND ?
+		GROUP BY month
+	`, category, fromMonth, toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("spent by month: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]int64{}
+	for rows.Next() {
+		var (
+			month string
+			spent int64
+		)
+		if err := rows.Scan(&month, &spent); err != nil {
+			return nil, err
+		}
+		out[month] = spent
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSpentForRange returns a category's spending (negative) between two
+// dates, inclusive.
+func GetSpentForRange(conn *sql.DB, category, from, to string) (int64, error) {
+	row := conn.QueryRow(`
+		SELECT COALESCE(SUM(amount_bani), 0)
+		FROM transactions
+		WHERE posted_at BETWEEN ? AND ?
+		  AND category = ?
+		  AND amount_bani < 0
+	`, from, to, category)
+
+	var spent int64
+	if err := row.Scan(&spent); err != nil {
+		return 0, fmt.Errorf("spent query: %w", err)
+	}
+	return spent, nil
+}
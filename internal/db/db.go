@@ -2,10 +2,27 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"strings"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
+func init() {
+	// casefold lowercases text as strings.ToLower does, so a column can be
+	// compared with a value lowered in Go. SQLite's LOWER only lowercases
+	// ASCII, which would leave "Ș" unmatched by "ș".
+	sqlite.MustRegisterDeterministicScalarFunction("casefold", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return strings.ToLower(v), nil
+		case []byte:
+			return strings.ToLower(string(v)), nil
+		}
+		return args[0], nil
+	})
+}
+
 func Open(path string) (*sql.DB, error) {
 	return sql.Open("sqlite", path)
 }
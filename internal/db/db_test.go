@@ -0,0 +1,27 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB creates a migrated database holding txs as
+// (date, payee, category, account, amount in bani).
+func openTestDB(t *testing.T, txs [][5]any) *sql.DB {
+	t.Helper()
+	conn, err := Open(filepath.Join(t.TempDir(), "pfm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := Migrate(conn, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range txs {
+		if _, err := conn.Exec(`INSERT INTO transactions (posted_at, payee, category, account, amount_bani) VALUES (?, ?, ?, ?, ?)`, tx[:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// AssignmentTotal is the net amount assigned to a category's envelope in a month.
+type AssignmentTotal struct {
+	Month        string
+	Category     string
+	AssignedBani int64
+}
+
+// AddAssignment records money assigned to (or, if negative, taken from) an
+// envelope and keeps the month's budget limit equal to the net assigned.
+func AddAssignment(conn *sql.DB, month, category string, amountBani int64, note string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("assign: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := addAssignmentTx(tx, month, category, amountBani, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MoveAssignment moves amountBani from one envelope to another within a month.
+func MoveAssignment(conn *sql.DB, month, from, to string, amountBani int64, note string) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("move: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := addAssignmentTx(tx, month, from, -amountBani, note); err != nil {
+		return err
+	}
+	if err := addAssignmentTx(tx, month, to, amountBani, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addAssignmentTx(tx *sql.Tx, month, category string, amountBani int64, note string) error {
+	_, err := tx.Exec(`
+		INSERT INTO budget_assignments (month, category, amount_bani, note)
+		VALUES (?, ?, ?, ?)
+	`, month, category, amountBani, note)
+	if err != nil {
+		return fmt.Errorf("add assignment: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO budgets (month, category, limit_bani)
+		SELECT ?, ?, MAX(COALESCE(SUM(amount_bani), 0), 0)
+		FROM budget_assignments
+		WHERE month = ? AND category = ?
+		ON CONFLICT(month, category) DO UPDATE SET limit_bani = excluded.limit_bani
+	`, month, category, month, category)
+	if err != nil {
+		return fmt.Errorf("sync budget: %w", err)
+	}
+	return nil
+}
+
+// ListAssignmentTotals returns net assignments per month and category for
+// months up to and including toMonth.
+func ListAssignmentTotals(conn *sql.DB, toMonth string) ([]AssignmentTotal, error) {
+	rows, err := conn.Query(`
+		SELECT month, category, SUM(amount_bani)
+		FROM budget_assignments
+		WHERE month <= ?
+		GROUP BY month, category
+		ORDER BY month ASC, category ASC
+	`, toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("list assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []AssignmentTotal
+	for rows.Next() {
+		var a AssignmentTotal
+		if err := rows.Scan(&a.Month, &a.Category, &a.AssignedBani); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetIncomeBetweenMonths sums positive transactions in [fromMonth, toMonth]
+// outside the exclude categories.
+func GetIncomeBetweenMonths(conn *sql.DB, fromMonth, toMonth string, exclude []string) (int64, error) {
+	args := []any{fromMonth, toMonth}
+	notIn := ""
+	if len(exclude) > 0 {
+		notIn = "AND category NOT IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(exclude)), ", ") + ")"
+		for _, c := range exclude {
+			args = append(args, c)
+		}
+	}
+	row := conn.QueryRow(`
+		SELECT COALESCE(SUM(amount_bani), 0)
+		FROM transactions
+		WHERE amount_bani > 0
+		  AND substr(posted_at, 1, 7) BETWEEN ? AND ?
+		  `+notIn, args...)
+
+	var income int64
+	if err := row.Scan(&income); err != nil {
+		return 0, fmt.Errorf("income query: %w", err)
+	}
+	return income, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import "testing"
+
+func TestGetIncomeBetweenMonths(t *testing.T) {
+	conn := openTestDB(t, [][5]any{
+		{"2025-12-25", "Employer", "salary", "ing", int64(400000)},
+		{"2026-01-25", "Employer", "salary", "ing", int64(500000)},
+		{"2026-01-26", "Lidl", "groceries", "ing", int64(-3000)},
+		{"2026-01-27", "Lidl", "groceries", "ing", int64(1000)},
+		{"2026-02-03", "Broker", "investments", "ing", int64(90000)},
+		{"2026-02-04", "Bank", "loan:home", "ing", int64(5000)},
+		{"2026-02-25", "Employer", "salary", "ing", int64(500000)},
+		{"2026-03-25", "Employer", "salary", "ing", int64(500000)},
+	})
+
+	tests := []struct {
+		exclude []string
+		want    int64
+	}{
+		{nil, 1096000},
+		{[]string{"investments"}, 1006000},
+		{[]string{"investments", "loan:home"}, 1001000},
+	}
+	for _, tt := range tests {
+		got, err := GetIncomeBetweenMonths(conn, "2026-01", "2026-02", tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("exclude %v: income %d, want %d", tt.exclude, got, tt.want)
+		}
+	}
+}
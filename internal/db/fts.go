@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Text search goes through transactions_fts, an FTS5 index over payee,
+// payee_raw and memo kept in sync by triggers. Its tokenizer folds case and
+// diacritics, so "stefan" finds "Ștefan".
+
+// ftsSchema creates the index and its triggers. It is not part of schema.sql:
+// the triggers must not exist while the column migrations rewrite older
+// transactions, since they would update an index that has not been built yet.
+const ftsSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS transactions_fts USING fts5(
+  payee, payee_raw, memo,
+  content='transactions', content_rowid='id',
+  tokenize='unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_ai AFTER INSERT ON transactions BEGIN
+  INSERT INTO transactions_fts(rowid, payee, payee_raw, memo)
+  VALUES (new.id, new.payee, new.payee_raw, new.memo);
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_ad AFTER DELETE ON transactions BEGIN
+  INSERT INTO transactions_fts(transactions_fts, rowid, payee, payee_raw, memo)
+  VALUES ('delete', old.id, old.payee, old.payee_raw, old.memo);
+END;
+
+CREATE TRIGGER IF NOT EXISTS transactions_fts_au AFTER UPDATE OF payee, payee_raw, memo ON transactions BEGIN
+  INSERT INTO transactions_fts(transactions_fts, rowid, payee, payee_raw, memo)
+  VALUES ('delete', old.id, old.payee, old.payee_raw, old.memo);
+  INSERT INTO transactions_fts(rowid, payee, payee_raw, memo)
+  VALUES (new.id, new.payee, new.payee_raw, new.memo);
+END;
+`
+
+// textMatchFilter matches the transactions whose text matches an FTS5 query.
+const textMatchFilter = `id IN (SELECT rowid FROM transactions_fts WHERE transactions_fts MATCH ?)`
+
+// ftsRankWeights weighs a hit in the payee above one in the bank's payee
+// text, and both above a hit in the memo.
+const ftsRankWeights = "4.0, 2.0, 1.0"
+
+// settingFTSBuilt records that transactions_fts was filled from the rows that
+// existed before the index (and its triggers) did.
+const settingFTSBuilt = "fts.transactions"
+
+// ensureFTS creates the index, and fills it once for databases created
+// before it existed.
+func ensureFTS(conn *sql.DB) error {
+	if _, err := conn.Exec(ftsSchema); err != nil {
+		return fmt.Errorf("create text index: %w", err)
+	}
+	built, err := GetSetting(conn, settingFTSBuilt, "")
+	if err != nil {
+		return err
+	}
+	if built != "" {
+		return nil
+	}
+	if _, err := conn.Exec(`INSERT INTO transactions_fts(transactions_fts) VALUES ('rebuild')`); err != nil {
+		return fmt.Errorf("build text index: %w", err)
+	}
+	return SetSetting(conn, settingFTSBuilt, "1")
+}
+
+// ftsMatch turns search text into an FTS5 query: every word must match the
+// start of a word ("ube" finds UBER) and a "quoted phrase" must match its
+// words in order. Words are quoted, so FTS5 operators in the text are taken
+// literally. It returns "" when the text has nothing to search for.
+func ftsMatch(text string) string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			if hasWordChars(part) {
+				terms = append(terms, `"`+strings.Join(strings.Fields(part), " ")+`"`)
+			}
+			continue
+		}
+		for _, w := range strings.Fields(part) {
+			if hasWordChars(w) {
+				terms = append(terms, `"`+w+`"*`)
+			}
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// hasWordChars reports whether s has anything the tokenizer indexes.
+func hasWordChars(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0
+}
+
+// textCondition is the condition for search text: the index when the text
+// has words, otherwise a plain substring match (for text like "*" or "%").
+func textCondition(text string) (string, []any) {
+	if m := ftsMatch(text); m != "" {
+		return textMatchFilter, []any{m}
+	}
+	q := Query{Op: QueryTerm, Field: FieldText, Cmp: "~", Value: text}
+	return q.SQL()
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GoalRow is a savings target. A goal may be linked to an account (its
+// transactions count as contributions) or to a category (spending in it, such
+// as transfers to savings, counts as contributions).
+type GoalRow struct {
+	ID         int64
+	Name       string
+	TargetBani int64
+	TargetDate string // YYYY-MM-DD, or "" for no deadline
+	Account    string
+	Category   string
+}
+
+const goalColumns = `id, name, target_bani, target_date, account, category`
+
+func scanGoal(sc interface{ Scan(...any) error }) (GoalRow, error) {
+	var g GoalRow
+	err := sc.Scan(&g.ID, &g.Name, &g.TargetBani, &g.TargetDate, &g.Account, &g.Category)
+	return g, err
+}
+
+func AddGoal(conn *sql.DB, g GoalRow) (int64, error) {
+	res, err := conn.Exec(`
+		INSERT INTO goals (name, target_bani, target_date, account, category)
+		VALUES (?, ?, ?, ?, ?)
+	`, g.Name, g.TargetBani, g.TargetDate, g.Account, g.Category)
+	if err != nil {
+		return 0, fmt.Errorf("add goal: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("goal id: %w", err)
+	}
+	return id, nil
+}
+
+func ListGoals(conn *sql.DB) ([]GoalRow, error) {
+	rows, err := conn.Query(`SELECT ` + goalColumns + ` FROM goals ORDER BY target_date = '', target_date, name`)
+	if err != nil {
+		return nil, fmt.Errorf("list goals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []GoalRow
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func GetGoalByName(conn *sql.DB, name string) (GoalRow, error) {
+	g, err := scanGoal(conn.QueryRow(`SELECT `+goalColumns+` FROM goals WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return GoalRow{}, fmt.Errorf("goal %q not found", name)
+	}
+	if err != nil {
+		return GoalRow{}, fmt.Errorf("get goal: %w", err)
+	}
+	return g, nil
+}
+
+func AddGoalContribution(conn *sql.DB, goalID int64, date string, amountBani int64, note string) error {
+	_, err := conn.Exec(`
+		INSERT INTO goal_contributions (goal_id, contributed_at, amount_bani, note)
+		VALUES (?, ?, ?, ?)
+	`, goalID, date, amountBani, note)
+	if err != nil {
+		return fmt.Errorf("add contribution: %w", err)
+	}
+	return nil
+}
+
+// GetGoalContributionsByMonth returns net contributions per month (YYYY-MM):
+// manual contributions plus those implied by the goal's linked account or
+// category.
+func GetGoalContributionsByMonth(conn *sql.DB, g GoalRow) (map[string]int64, error) {
+	out := map[string]int64{}
+
+	add := func(query string, args ...any) error {
+		rows, err := conn.Query(query, args...)
+		if err != nil {
+			return fmt.Errorf("goal contributions: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				month  string
+				amount int64
+			)
+			if err := rows.Scan(&month, &amount); err != nil {
+				return err
+			}
+			out[month] += amount
+		}
+		return rows.Err()
+	}
+
+	if err := add(`
+		SELECT substr(contributed_at, 1, 7) AS month, SUM(amount_bani)
+		FROM goal_contributions
+		WHERE goal_id = ?
+		GROUP BY month
+	`, g.ID); err != nil {
+		return nil, err
+	}
+
+	if g.Account != "" {
+		if err := add(`
+			SELECT substr(posted_at, 1, 7) AS month, SUM(amount_bani)
+			FROM transactions
+			WHERE account = ?
+			GROUP BY month
+		`, g.Account); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.Category != "" {
+		if err := add(`
+			SELECT substr(posted_at, 1, 7) AS month, -SUM(amount_bani)
+			FROM transactions
+			WHERE category = ?
+			GROUP BY month
+		`, g.Category); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
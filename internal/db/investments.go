@@ -0,0 +1,223 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Trade kinds.
+const (
+	TradeBuy      = "buy"
+	TradeSell     = "sell"
+	TradeDividend = "dividend"
+)
+
+type SecurityRow struct {
+	ID     int64
+	Symbol string
+	Name   string
+}
+
+// TradeRow is a buy, sell or dividend. AmountBani is the cash effect on the
+// account: negative for buys (including fees), positive for sells (net of
+// fees) and dividends. QtyMicro is in millionths of a unit.
+type TradeRow struct {
+	ID            int64
+	SecurityID    int64
+	Symbol        string
+	Account       string
+	TradeDate     string
+	Kind          string
+	QtyMicro      int64
+	PriceBani     int64
+	FeeBani       int64
+	AmountBani    int64
+	TransactionID *int64
+}
+
+// AddSecurity creates a security, or returns the id of the existing one.
+// A non-empty name replaces the stored one.
+func AddSecurity(conn *sql.DB, symbol, name string) (int64, error) {
+	_, err := conn.Exec(`
+		INSERT INTO securities (symbol, name)
+		VALUES (?, ?)
+		ON CONFLICT(symbol) DO UPDATE SET name = CASE WHEN excluded.name <> '' THEN excluded.name ELSE name END
+	`, symbol, name)
+	if err != nil {
+		return 0, fmt.Errorf("add security: %w", err)
+	}
+	var id int64
+	if err := conn.QueryRow(`SELECT id FROM securities WHERE symbol = ?`, symbol).Scan(&id); err != nil {
+		return 0, fmt.Errorf("security id: %w", err)
+	}
+	return id, nil
+}
+
+func GetSecurityID(conn *sql.DB, symbol string) (int64, error) {
+	var id int64
+	err := conn.QueryRow(`SELECT id FROM securities WHERE symbol = ?`, symbol).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("security %q not found", symbol)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get security: %w", err)
+	}
+	return id, nil
+}
+
+func ListSecurities(conn *sql.DB) ([]SecurityRow, error) {
+	rows, err := conn.Query(`SELECT id, symbol, name FROM securities ORDER BY symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("list securities: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SecurityRow
+	for rows.Next() {
+		var s SecurityRow
+		if err := rows.Scan(&s.ID, &s.Symbol, &s.Name); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func AddTrade(conn *sql.DB, t TradeRow) (int64, error) {
+	return addTrade(conn, t)
+}
+
+func addTrade(conn execer, t TradeRow) (int64, error) {
+	res, err := conn.Exec(`
+		INSERT INTO trades (security_id, account, trade_date, kind, qty_micro, price_bani, fee_bani, amount_bani, transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.SecurityID, t.Account, t.TradeDate, t.Kind, t.QtyMicro, t.PriceBani, t.FeeBani, t.AmountBani, t.TransactionID)
+	if err != nil {
+		return 0, fmt.Errorf("add trade: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("trade id: %w", err)
+	}
+	return id, nil
+}
+
+// RecordTrade writes the cash leg p to transactions and the trade linked to
+// it in one transaction, so neither is stored without the other.
+func RecordTrade(conn *sql.DB, p AddTxParams, t TradeRow) (int64, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("record trade: %w", err)
+	}
+	defer tx.Rollback()
+
+	txID, _, err := insertTransaction(tx, p)
+	if err != nil {
+		return 0, err
+	}
+	t.TransactionID = &txID
+	id, err := addTrade(tx, t)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// DeleteTrade removes a trade and the cash leg linked to it.
+func DeleteTrade(conn *sql.DB, id int64) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("delete trade: %w", err)
+	}
+	defer tx.Rollback()
+
+	var txID sql.NullInt64
+	err = tx.QueryRow(`SELECT transaction_id FROM trades WHERE id = ?`, id).Scan(&txID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("trade #%d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("delete trade: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM trades WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete trade: %w", err)
+	}
+	if txID.Valid {
+		for _, q := range []string{
+			`DELETE FROM transaction_tags WHERE transaction_id = ?`,
+			`DELETE FROM transactions WHERE id = ?`,
+		} {
+			if _, err := tx.Exec(q, txID.Int64); err != nil {
+				return fmt.Errorf("delete trade: %w", err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// ListTrades returns trades on or before toDate in execution order. A zero
+// securityID returns trades of every security.
+func ListTrades(conn *sql.DB, securityID int64, toDate string) ([]TradeRow, error) {
+	rows, err := conn.Query(`
+		SELECT t.id, t.security_id, s.symbol, t.account, t.trade_date, t.kind,
+		       t.qty_micro, t.price_bani, t.fee_bani, t.amount_bani, t.transaction_id
+		FROM trades t
+		JOIN securities s ON s.id = t.security_id
+		WHERE (? = 0 OR t.security_id = ?)
+		  AND t.trade_date <= ?
+		ORDER BY t.trade_date ASC, t.id ASC
+	`, securityID, securityID, toDate)
+	if err != nil {
+		return nil, fmt.Errorf("list trades: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TradeRow
+	for rows.Next() {
+		var t TradeRow
+		if err := rows.Scan(&t.ID, &t.SecurityID, &t.Symbol, &t.Account, &t.TradeDate, &t.Kind,
+			&t.QtyMicro, &t.PriceBani, &t.FeeBani, &t.AmountBani, &t.TransactionID); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpsertPrice sets a security's closing price for a date.
+func UpsertPrice(conn *sql.DB, securityID int64, date string, priceBani int64) error {
+	_, err := conn.Exec(`
+		INSERT INTO prices (security_id, priced_at, price_bani)
+		VALUES (?, ?, ?)
+		ON CONFLICT(security_id, priced_at) DO UPDATE SET price_bani = excluded.price_bani
+	`, securityID, date, priceBani)
+	if err != nil {
+		return fmt.Errorf("upsert price: %w", err)
+	}
+	return nil
+}
+
+// GetPriceAsOf returns the latest price on or before date, and its date.
+// ok is false when there is none.
+func GetPriceAsOf(conn *sql.DB, securityID int64, date string) (priceBani int64, pricedAt string, ok bool, err error) {
+	err = conn.QueryRow(`
+		SELECT price_bani, priced_at
+		FROM prices
+		WHERE security_id = ? AND priced_at <= ?
+		ORDER BY priced_at DESC
+		LIMIT 1
+	`, securityID, date).Scan(&priceBani, &pricedAt)
+	if err == sql.ErrNoRows {
+		return 0, "", false, nil
+	}
+	if err != nil {
+		return 0, "", false, fmt.Errorf("price: %w", err)
+	}
+	return priceBani, pricedAt, true, nil
+}
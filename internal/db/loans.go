@@ -0,0 +1,210 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// LoanRow is an amortizing loan paid monthly. RateBP is the annual interest
+// rate in basis points (5.25% = 525).
+type LoanRow struct {
+	ID            int64
+	Name          string
+	PrincipalBani int64
+	RateBP        int64
+	TermMonths    int
+	StartDate     string // first payment, YYYY-MM-DD
+	PaymentBani   int64  // scheduled monthly payment
+	Pattern       string // regex on payee/memo recognizing payments; "" for none
+	Category      string
+}
+
+// LoanPayment is one payment split into principal and interest.
+type LoanPayment struct {
+	ID            int64
+	LoanID        int64
+	TransactionID *int64
+	PaidAt        string
+	AmountBani    int64
+	PrincipalBani int64
+	InterestBani  int64
+	BalanceBani   int64 // after the payment
+}
+
+const loanColumns = `id, name, principal_bani, rate_bp, term_months, start_date, payment_bani, pattern, category`
+
+func scanLoan(sc interface{ Scan(...any) error }) (LoanRow, error) {
+	var l LoanRow
+	err := sc.Scan(&l.ID, &l.Name, &l.PrincipalBani, &l.RateBP, &l.TermMonths, &l.StartDate, &l.PaymentBani, &l.Pattern, &l.Category)
+	return l, err
+}
+
+func AddLoan(conn *sql.DB, l LoanRow) (int64, error) {
+	res, err := conn.Exec(`
+		INSERT INTO loans (name, principal_bani, rate_bp, term_months, start_date, payment_bani, pattern, category)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, l.Name, l.PrincipalBani, l.RateBP, l.TermMonths, l.StartDate, l.PaymentBani, l.Pattern, l.Category)
+	if err != nil {
+		return 0, fmt.Errorf("add loan: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("loan id: %w", err)
+	}
+	return id, nil
+}
+
+func ListLoans(conn *sql.DB) ([]LoanRow, error) {
+	rows, err := conn.Query(`SELECT ` + loanColumns + ` FROM loans ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list loans: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LoanRow
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func GetLoanByName(conn *sql.DB, name string) (LoanRow, error) {
+	l, err := scanLoan(conn.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE name = ?`, name))
+	if err == sql.ErrNoRows {
+		return LoanRow{}, fmt.Errorf("loan %q not found", name)
+	}
+	if err != nil {
+		return LoanRow{}, fmt.Errorf("get loan: %w", err)
+	}
+	return l, nil
+}
+
+// GetLoanForTransaction returns the loan that transaction txID is a payment
+// on; ok is false if it is not a loan payment.
+func GetLoanForTransaction(conn *sql.DB, txID int64) (l LoanRow, ok bool, err error) {
+	l, err = scanLoan(conn.QueryRow(`
+		SELECT `+loanColumns+` FROM loans
+		WHERE id = (SELECT loan_id FROM loan_payments WHERE transaction_id = ?)
+	`, txID))
+	if err == sql.ErrNoRows {
+		return LoanRow{}, false, nil
+	}
+	if err != nil {
+		return LoanRow{}, false, fmt.Errorf("get loan: %w", err)
+	}
+	return l, true, nil
+}
+
+// GetLoanBalance returns the principal still owed after recorded payments.
+func GetLoanBalance(conn *sql.DB, l LoanRow) (int64, error) {
+	var paid int64
+	err := conn.QueryRow(`
+		SELECT COALESCE(SUM(principal_bani), 0)
+		FROM loan_payments
+		WHERE loan_id = ?
+	`, l.ID).Scan(&paid)
+	if err != nil {
+		return 0, fmt.Errorf("loan balance: %w", err)
+	}
+	return l.PrincipalBani - paid, nil
+}
+
+func RecordLoanPayment(conn *sql.DB, p LoanPayment) (int64, error) {
+	res, err := conn.Exec(`
+		INSERT INTO loan_payments (loan_id, transaction_id, paid_at, amount_bani, principal_bani, interest_bani, balance_bani)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, p.LoanID, p.TransactionID, p.PaidAt, p.AmountBani, p.PrincipalBani, p.InterestBani, p.BalanceBani)
+	if err != nil {
+		return 0, fmt.Errorf("record loan payment: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// UpdateLoanPaymentSplit stores a payment's principal, interest and balance.
+func UpdateLoanPaymentSplit(conn *sql.DB, p LoanPayment) error {
+	_, err := conn.Exec(`
+		UPDATE loan_payments SET principal_bani = ?, interest_bani = ?, balance_bani = ?
+		WHERE id = ?
+	`, p.PrincipalBani, p.InterestBani, p.BalanceBani, p.ID)
+	if err != nil {
+		return fmt.Errorf("update loan payment: %w", err)
+	}
+	return nil
+}
+
+func ListLoanPayments(conn *sql.DB, loanID int64) ([]LoanPayment, error) {
+	rows, err := conn.Query(`
+		SELECT id, loan_id, transaction_id, paid_at, amount_bani, principal_bani, interest_bani, balance_bani
+		FROM loan_payments
+		WHERE loan_id = ?
+		ORDER BY paid_at, id
+	`, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("list loan payments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LoanPayment
+	for rows.Next() {
+		var p LoanPayment
+		if err := rows.Scan(&p.ID, &p.LoanID, &p.TransactionID, &p.PaidAt, &p.AmountBani, &p.PrincipalBani, &p.InterestBani, &p.BalanceBani); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListUnlinkedExpenses returns expenses not yet recorded as a loan payment,
+// oldest first, as candidates for `loan apply`.
+func ListUnlinkedExpenses(conn *sql.DB) ([]TxForCategorize, error) {
+	rows, err := conn.Query(`
+		SELECT t.id, t.payee, t.payee_raw, t.memo, t.amount_bani, t.category, t.category_source, t.posted_at
+		FROM transactions t
+		WHERE t.amount_bani < 0
+		  AND NOT EXISTS (SELECT 1 FROM loan_payments p WHERE p.transaction_id = t.id)
+		ORDER BY t.posted_at ASC, t.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list unlinked expenses: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TxForCategorize
+	for rows.Next() {
+		var t TxForCategorize
+		if err := rows.Scan(&t.ID, &t.Payee, &t.PayeeRaw, &t.Memo, &t.AmountBani, &t.Category, &t.CategorySource, &t.PostedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetLoanBalanceAsOf returns the principal owed after payments made on or
+// before date.
+func GetLoanBalanceAsOf(conn *sql.DB, l LoanRow, date string) (int64, error) {
+	var paid int64
+	err := conn.QueryRow(`
+		SELECT COALESCE(SUM(principal_bani), 0)
+		FROM loan_payments
+		WHERE loan_id = ? AND paid_at <= ?
+	`, l.ID, date).Scan(&paid)
+	if err != nil {
+		return 0, fmt.Errorf("loan balance: %w", err)
+	}
+	return l.PrincipalBani - paid, nil
+}
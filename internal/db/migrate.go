@@ -2,17 +2,125 @@ package db
 
 import (
 	"database/sql"
+	_ "embed"
 	"fmt"
 	"os"
 )
 
+// schemaSQL is the schema built into the binary, so pfm works from any
+// directory.
+//
+//go:embed schema.sql
+var schemaSQL string
+
+// columnMigration adds a column that was introduced after its table was first
+// created. CREATE TABLE IF NOT EXISTS leaves existing databases untouched, so
+// new columns are listed both in schema.sql and here.
+type columnMigration struct {
+	Table  string
+	Column string
+	Def    string
+	// After runs once, right after the column has been added.
+	After string
+}
+
+var columnMigrations = []columnMigration{
+	{Table: "category_rules", Column: "enabled", Def: "INTEGER NOT NULL DEFAULT 1"},
+	{Table: "category_rules", Column: "hit_count", Def: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "category_rules", Column: "last_matched_at", Def: "TEXT"},
+	{
+		Table: "transactions", Column: "category_source", Def: "TEXT NOT NULL DEFAULT 'none'",
+		// Where a category came from is unknown for older rows, so protect them.
+		After: "UPDATE transactions SET category_source = 'manual' WHERE category <> 'uncategorized'",
+	},
+	{
+		Table: "transactions", Column: "payee_raw", Def: "TEXT NOT NULL DEFAULT ''",
+		After: "UPDATE transactions SET payee_raw = payee",
+	},
+	{Table: "transactions", Column: "payee_manual", Def: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// Migrate applies the schema at schemaPath, or the built-in one when
+// schemaPath is "", and the column migrations.
 func Migrate(conn *sql.DB, schemaPath string) error {
-	b, err := os.ReadFile(schemaPath)
-	if err != nil {
-		return fmt.Errorf("read schema: %w", err)
+	schema := schemaSQL
+	if schemaPath != "" {
+		b, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("read schema: %w", err)
+		}
+		schema = string(b)
 	}
-	if _, err := conn.Exec(string(b)); err != nil {
+	if _, err := conn.Exec(schema); err != nil {
 		return fmt.Errorf("apply schema: %w", err)
 	}
+	for _, m := range columnMigrations {
+		if err := ensureColumn(conn, m); err != nil {
+			return err
+		}
+	}
+	if err := ensureFTS(conn); err != nil {
+		return err
+	}
+	return repairPayeeRaw(conn)
+}
+
+// settingPayeeRawRepaired records that repairPayeeRaw has run.
+const settingPayeeRawRepaired = "migrate.payee_raw"
+
+// repairPayeeRaw fills payee_raw on rows left empty by an earlier version,
+// whose backfill failed on databases that predate the text index. Every
+// insert sets payee_raw, so an empty one is always such a row.
+func repairPayeeRaw(conn *sql.DB) error {
+	done, err := GetSetting(conn, settingPayeeRawRepaired, "")
+	if err != nil || done != "" {
+		return err
+	}
+	if _, err := conn.Exec(`UPDATE transactions SET payee_raw = payee WHERE payee_raw = ''`); err != nil {
+		return fmt.Errorf("repair payee_raw: %w", err)
+	}
+	return SetSetting(conn, settingPayeeRawRepaired, "1")
+}
+
+func ensureColumn(conn *sql.DB, m columnMigration) error {
+	rows, err := conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", m.Table))
+	if err != nil {
+		return fmt.Errorf("table info %s: %w", m.Table, err)
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			typ       string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == m.Column {
+			found = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if found {
+		return nil
+	}
+	if _, err := conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.Table, m.Column, m.Def)); err != nil {
+		return fmt.Errorf("add column %s.%s: %w", m.Table, m.Column, err)
+	}
+	if m.After != "" {
+		if _, err := conn.Exec(m.After); err != nil {
+			return fmt.Errorf("migrate %s.%s: %w", m.Table, m.Column, err)
+		}
+	}
 	return nil
 }
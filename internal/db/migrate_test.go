@@ -0,0 +1,84 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openBaseline creates a database with the schema of the first release and a
+// few transactions in it.
+func openBaseline(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := Open(filepath.Join(t.TempDir(), "pfm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	schema, err := os.ReadFile(filepath.Join("testdata", "schema_baseline.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(string(schema)); err != nil {
+		t.Fatal(err)
+	}
+	for _, payee := range []string{"UBER *TRIP", "Lidl", "Ștefan cel Mare", "Kaufland", "Uber Eats"} {
+		if _, err := conn.Exec(`INSERT INTO transactions (posted_at, payee, amount_bani) VALUES ('2025-10-01', ?, -1000)`, payee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return conn
+}
+
+func checkMigrated(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	var empty int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM transactions WHERE payee_raw <> payee`).Scan(&empty); err != nil {
+		t.Fatal(err)
+	}
+	if empty != 0 {
+		t.Errorf("%d rows with payee_raw not filled in from payee", empty)
+	}
+
+	for text, want := range map[string]int{"uber": 2, "stefan": 1, "lidl": 1, "nothing": 0} {
+		rows, err := SearchTransactions(conn, SearchFilter{Query: &Query{Op: QueryTerm, Field: FieldText, Cmp: "match", Value: text}})
+		if err != nil {
+			t.Fatalf("search %q: %v", text, err)
+		}
+		if len(rows) != want {
+			t.Errorf("search %q: %d rows, want %d", text, len(rows), want)
+		}
+	}
+	if _, err := conn.Exec(`INSERT INTO transactions_fts(transactions_fts) VALUES ('integrity-check')`); err != nil {
+		t.Errorf("text index: %v", err)
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	conn := openBaseline(t)
+	if err := Migrate(conn, ""); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	checkMigrated(t, conn)
+
+	// Migrating again is a no-op.
+	if err := Migrate(conn, ""); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+	checkMigrated(t, conn)
+}
+
+// TestMigrateRepairsPayeeRaw covers databases where payee_raw was added but
+// its backfill failed, leaving it empty.
+func TestMigrateRepairsPayeeRaw(t *testing.T) {
+	conn := openBaseline(t)
+	if _, err := conn.Exec(`ALTER TABLE transactions ADD COLUMN payee_raw TEXT NOT NULL DEFAULT ''`); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(conn, ""); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	checkMigrated(t, conn)
+}
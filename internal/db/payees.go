@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type PayeeRow struct {
+	ID      int64
+	Name    string
+	Aliases int64
+	TxCount int64
+}
+
+type PayeeAlias struct {
+	ID      int64
+	PayeeID int64
+	Name    string // canonical payee name
+	Pattern string
+}
+
+// AddPayee creates a canonical payee, or returns the id of the existing one.
+func AddPayee(conn *sql.DB, name string) (int64, error) {
+	if _, err := conn.Exec(`INSERT OR IGNORE INTO payees (name) VALUES (?)`, name); err != nil {
+		return 0, fmt.Errorf("add payee: %w", err)
+	}
+	var id int64
+	if err := conn.QueryRow(`SELECT id FROM payees WHERE name = ?`, name).Scan(&id); err != nil {
+		return 0, fmt.Errorf("payee id: %w", err)
+	}
+	return id, nil
+}
+
+func AddPayeeAlias(conn *sql.DB, payeeID int64, pattern string) (int64, error) {
+	res, err := conn.Exec(`
+		INSERT INTO payee_aliases (payee_id, pattern)
+		VALUES (?, ?)
+	`, payeeID, pattern)
+	if err != nil {
+		return 0, fmt.Errorf("add payee alias: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("alias id: %w", err)
+	}
+	return id, nil
+}
+
+func DeletePayeeAlias(conn *sql.DB, id int64) error {
+	res, err := conn.Exec(`DELETE FROM payee_aliases WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete payee alias: %w", err)
+	}
+	return requireOneRow(res, "alias", id)
+}
+
+// ListPayeeAliases returns aliases in the order they are applied: the first
+// matching alias wins.
+func ListPayeeAliases(conn *sql.DB) ([]PayeeAlias, error) {
+	rows, err := conn.Query(`
+		SELECT a.id, a.payee_id, p.name, a.pattern
+		FROM payee_aliases a
+		JOIN payees p ON p.id = a.payee_id
+		ORDER BY a.id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list payee aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PayeeAlias
+	for rows.Next() {
+		var a PayeeAlias
+		if err := rows.Scan(&a.ID, &a.PayeeID, &a.Name, &a.Pattern); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func ListPayees(conn *sql.DB) ([]PayeeRow, error) {
+	rows, err := conn.Query(`
+		SELECT p.id, p.name,
+			(SELECT COUNT(*) FROM payee_aliases a WHERE a.payee_id = p.id),
+			(SELECT COUNT(*) FROM transactions t WHERE t.payee = p.name)
+		FROM payees p
+		ORDER BY p.name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list payees: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PayeeRow
+	for rows.Next() {
+		var p PayeeRow
+		if err := rows.Scan(&p.ID, &p.Name, &p.Aliases, &p.TxCount); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type TxPayee struct {
+	ID       int64
+	Payee    string
+	PayeeRaw string
+	// Manual is set when the payee was edited by hand.
+	Manual bool
+}
+
+func ListTxPayees(conn *sql.DB) ([]TxPayee, error) {
+	rows, err := conn.Query(`SELECT id, payee, payee_raw, payee_manual FROM transactions ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list tx payees: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TxPayee
+	for rows.Next() {
+		var t TxPayee
+		if err := rows.Scan(&t.ID, &t.Payee, &t.PayeeRaw, &t.Manual); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateTxPayee sets the canonical payee; payee_raw is left untouched.
+func UpdateTxPayee(conn *sql.DB, id int64, payee string) error {
+	_, err := conn.Exec(`UPDATE transactions SET payee = ? WHERE id = ?`, payee, id)
+	if err != nil {
+		return fmt.Errorf("update payee: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Query is a parsed search expression: a tree of and/or/not over field
+// terms. It compiles to a parameterized WHERE condition; values never become
+// part of the SQL text.
+type Query struct {
+	Op    string   // QueryAnd, QueryOr, QueryNot or QueryTerm
+	Args  []*Query // operands of and, or and not
+	Field string   // term: one of the Field* names
+	Cmp   string   // term: "=", "~" (contains), "<", "<=", ">", ">=" or "match" (full-text, FieldText only)
+	Value any      // term: string; int64 bani for FieldAmount; YYYY-MM-DD for FieldDate
+}
+
+const (
+	QueryAnd  = "and"
+	QueryOr   = "or"
+	QueryNot  = "not"
+	QueryTerm = "term"
+)
+
+// Query fields.
+const (
+	FieldText     = "text" // payee, bank payee text or memo
+	FieldPayee    = "payee"
+	FieldMemo     = "memo"
+	FieldCategory = "category"
+	FieldAccount  = "account"
+	FieldTag      = "tag"
+	FieldDate     = "date"
+	FieldAmount   = "amount"
+)
+
+// textColumns are the columns a text field matches; a term matches when any
+// of them does.
+var textColumns = map[string][]string{
+	FieldText:     {"payee", "payee_raw", "memo"},
+	FieldPayee:    {"payee", "payee_raw"},
+	FieldMemo:     {"memo"},
+	FieldCategory: {"category"},
+	FieldAccount:  {"account"},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern is a LIKE pattern (with ESCAPE '\') matching s anywhere.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(s)) + "%"
+}
+
+// And combines queries, skipping nil ones.
+func And(qs ...*Query) *Query {
+	var args []*Query
+	for _, q := range qs {
+		if q != nil {
+			args = append(args, q)
+		}
+	}
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	}
+	return &Query{Op: QueryAnd, Args: args}
+}
+
+// SQL returns the condition and its arguments.
+func (q *Query) SQL() (string, []any) {
+	switch q.Op {
+	case QueryAnd, QueryOr:
+		parts := make([]string, len(q.Args))
+		var args []any
+		for i, a := range q.Args {
+			s, as := a.SQL()
+			parts[i] = s
+			args = append(args, as...)
+		}
+		return "(" + strings.Join(parts, " "+strings.ToUpper(q.Op)+" ") + ")", args
+	case QueryNot:
+		s, args := q.Args[0].SQL()
+		return "NOT " + s, args
+	}
+
+	if q.Cmp == "match" {
+		return textCondition(q.Value.(string))
+	}
+	switch q.Field {
+	case FieldDate:
+		return "posted_at " + q.Cmp + " ?", []any{q.Value}
+	case FieldAmount:
+		return "amount_bani " + q.Cmp + " ?", []any{q.Value}
+	case FieldTag:
+		cond, arg := "g.name = ?", any(strings.ToLower(q.Value.(string)))
+		if q.Cmp == "~" {
+			cond, arg = `casefold(g.name) LIKE ? ESCAPE '\'`, containsPattern(q.Value.(string))
+		}
+		return `id IN (SELECT tt.transaction_id FROM transaction_tags tt JOIN tags g ON g.id = tt.tag_id WHERE ` + cond + `)`, []any{arg}
+	}
+
+	cols := textColumns[q.Field]
+	parts := make([]string, len(cols))
+	args := make([]any, len(cols))
+	for i, c := range cols {
+		if q.Cmp == "~" {
+			parts[i] = "casefold(" + c + `) LIKE ? ESCAPE '\'`
+			args[i] = containsPattern(q.Value.(string))
+		} else {
+			parts[i] = "casefold(" + c + ") = ?"
+			args[i] = strings.ToLower(q.Value.(string))
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0], args
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// matches collects the full-text terms that a row can match by, leaving out
+// negated ones, as FTS5 queries for ranking.
+func (q *Query) matches() []string {
+	switch q.Op {
+	case QueryAnd, QueryOr:
+		var out []string
+		for _, a := range q.Args {
+			out = append(out, a.matches()...)
+		}
+		return out
+	case QueryTerm:
+		if m := ftsMatch(fmt.Sprint(q.Value)); q.Cmp == "match" && m != "" {
+			return []string{m}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuerySQLFoldsCase(t *testing.T) {
+	conn, err := Open(filepath.Join(t.TempDir(), "pfm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := Migrate(conn, ""); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []AddTxParams{
+		{PostedAt: day, Payee: "Mega Image Ștefan cel Mare", PayeeRaw: "MEGA IMAGE 0412 BUCUREȘTI", Memo: "Cumpărături", AmountBani: -1000, Category: "Băcănie"},
+		{PostedAt: day, Payee: "Lidl", AmountBani: -2000, Category: "groceries"},
+	} {
+		if _, _, err := InsertTransaction(conn, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		field, cmp, value string
+		want              int
+	}{
+		{FieldPayee, "=", "Mega Image Ștefan cel Mare", 1},
+		{FieldPayee, "=", "mega image ștefan cel mare", 1},
+		{FieldPayee, "=", "MEGA IMAGE ȘTEFAN CEL MARE", 1},
+		{FieldPayee, "~", "Ștefan", 1},
+		{FieldPayee, "~", "ștefan", 1},
+		{FieldPayee, "~", "stefan", 0},
+		{FieldPayee, "~", "bucurești", 1},
+		{FieldMemo, "~", "CUMPĂRĂTURI", 1},
+		{FieldCategory, "=", "băcănie", 1},
+		{FieldCategory, "~", "BĂC", 1},
+		{FieldPayee, "~", "lidl", 1},
+		{FieldPayee, "~", "%", 0},
+	}
+	for _, tt := range tests {
+		q := &Query{Op: QueryTerm, Field: tt.field, Cmp: tt.cmp, Value: tt.value}
+		rows, err := SearchTransactions(conn, SearchFilter{Query: q})
+		if err != nil {
+			t.Errorf("%s%s%s: %v", tt.field, tt.cmp, tt.value, err)
+			continue
+		}
+		if len(rows) != tt.want {
+			t.Errorf("%s%s%s: %d rows, want %d", tt.field, tt.cmp, tt.value, len(rows), tt.want)
+		}
+	}
+}
@@ -13,20 +13,28 @@ type MonthSummary struct {
 	NetBani      int64
 }
 
-func GetMonthSummary(conn *sql.DB, month string) (MonthSummary, error) {
+// GetMonthSummary totals a month, optionally only transactions tagged tag.
+func GetMonthSummary(conn *sql.DB, month, tag string) (MonthSummary, error) {
 	// month: YYYY-MM
 	var s MonthSummary
 	s.Month = month
 
-	row := conn.QueryRow(`
+	where := "posted_at LIKE ?"
+	args := []any{month + "-%"}
+	if tag != "" {
+		where += " AND " + tagFilter
+		args = append(args, tag)
+	}
+
+	row := conn.QueryRow(fmt.Sprintf(`
 		SELECT
 			COUNT(*) AS cnt,
 			COALESCE(SUM(CASE WHEN amount_bani > 0 THEN amount_bani ELSE 0 END), 0) AS income,
 			COALESCE(SUM(CASE WHEN amount_bani < 0 THEN amount_bani ELSE 0 END), 0) AS expense,
 			COALESCE(SUM(amount_bani), 0) AS net
 		FROM transactions
-		WHERE posted_at LIKE ?
-	`, month+"-%")
+		WHERE %s
+	`, where), args...)
 
 	if err := row.Scan(&s.Count, &s.IncomeBani, &s.ExpenseBani, &s.NetBani); err != nil {
 		return MonthSummary{}, fmt.Errorf("month summary: %w", err)
@@ -40,11 +48,16 @@ type CategoryTotal struct {
 	Count      int64
 }
 
-func GetCategoryTotalsForMonth(conn *sql.DB, month string, expensesOnly bool) ([]CategoryTotal, int64, error) {
+func GetCategoryTotalsForMonth(conn *sql.DB, month string, expensesOnly bool, tag string) ([]CategoryTotal, int64, error) {
 	where := "posted_at LIKE ?"
+	args := []any{month + "-%"}
 	if expensesOnly {
 		where += " AND amount_bani < 0"
 	}
+	if tag != "" {
+		where += " AND " + tagFilter
+		args = append(args, tag)
+	}
 
 	rows, err := conn.Query(fmt.Sprintf(`
 		SELECT category, COUNT(*) AS cnt, COALESCE(SUM(amount_bani), 0) AS total
@@ -52,7 +65,7 @@ func GetCategoryTotalsForMonth(conn *sql.DB, month string, expensesOnly bool) ([
 		WHERE %s
 		GROUP BY category
 		ORDER BY total ASC, category ASC
-	`, where), month+"-%")
+	`, where), args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("category totals: %w", err)
 	}
@@ -74,3 +87,150 @@ func GetCategoryTotalsForMonth(conn *sql.DB, month string, expensesOnly bool) ([
 	}
 	return out, grand, nil
 }
+
+type PayeeTotal struct {
+	Payee     string
+	TotalBani int64
+	Count     int64
+}
+
+// GetPayeeTotalsForMonth groups a month's transactions by canonical payee,
+// optionally within one category and/or tag.
+func GetPayeeTotalsForMonth(conn *sql.DB, month, category string, expensesOnly bool, tag string) ([]PayeeTotal, int64, error) {
+	where := "posted_at LIKE ?"
+	args := []any{month + "-%"}
+	if category != "" {
+		where += " AND category = ?"
+		args = append(args, category)
+	}
+	if expensesOnly {
+		where += " AND amount_bani < 0"
+	}
+	if tag != "" {
+		where += " AND " + tagFilter
+		args = append(args, tag)
+	}
+
+	rows, err := conn.Query(fmt.Sprintf(`
+		SELECT payee, COUNT(*) AS cnt, COALESCE(SUM(amount_bani), 0) AS total
+		FROM transactions
+		WHERE %s
+		GROUP BY payee
+		ORDER BY total ASC, payee ASC
+	`, where), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("payee totals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PayeeTotal
+	var grand int64
+
+	for rows.Next() {
+		var p PayeeTotal
+		if err := rows.Scan(&p.Payee, &p.Count, &p.TotalBani); err != nil {
+			return nil, 0, err
+		}
+		out = append(out, p)
+		grand += p.TotalBani
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return out, grand, nil
+}
+
+type TagTotal struct {
+	Tag       string
+	TotalBani int64
+	Count     int64
+}
+
+// GetTagTotalsForMonth groups a month's transactions by tag. A transaction
+// with several tags counts toward each of them, so totals may overlap.
+func GetTagTotalsForMonth(conn *sql.DB, month string, expensesOnly bool) ([]TagTotal, error) {
+	where := "t.posted_at LIKE ?"
+	if expensesOnly {
+		where += " AND t.amount_bani < 0"
+	}
+
+	rows, err := conn.Query(fmt.Sprintf(`
+		SELECT g.name, COUNT(*) AS cnt, COALESCE(SUM(t.amount_bani), 0) AS total
+		FROM transactions t
+		JOIN transaction_tags tt ON tt.transaction_id = t.id
+		JOIN tags g ON g.id = tt.tag_id
+		WHERE %s
+		GROUP BY g.id
+		ORDER BY total ASC, g.name ASC
+	`, where), month+"-%")
+	if err != nil {
+		return nil, fmt.Errorf("tag totals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TagTotal
+	for rows.Next() {
+		var t TagTotal
+		if err := rows.Scan(&t.Tag, &t.Count, &t.TotalBani); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type TaxBucketTotal struct {
+	Month     string
+	Bucket    string
+	TotalBani int64
+	Count     int64
+}
+
+// taxBucketExpr resolves a transaction's tax bucket: a mapped tag wins over
+// a mapped category; NULL when neither is mapped.
+const taxBucketExpr = `COALESCE(
+	(SELECT m.bucket FROM tax_mappings m
+	 JOIN tags g ON g.name = m.value AND m.match_type = 'tag'
+	 JOIN transaction_tags tt ON tt.tag_id = g.id
+	 WHERE tt.transaction_id = transactions.id
+	 ORDER BY m.id LIMIT 1),
+	(SELECT m.bucket FROM tax_mappings m
+	 WHERE m.match_type = 'category' AND m.value = transactions.category)
+)`
+
+// GetTaxTotalsForYear groups a year's transactions by month and tax bucket,
+// like GetCategoryTotalsForMonth does by category. Unmapped transactions are
+// left out. Totals keep the sign of the amounts.
+func GetTaxTotalsForYear(conn *sql.DB, year int) ([]TaxBucketTotal, error) {
+	rows, err := conn.Query(fmt.Sprintf(`
+		SELECT month, bucket, COUNT(*) AS cnt, COALESCE(SUM(amount_bani), 0) AS total
+		FROM (
+			SELECT substr(posted_at, 1, 7) AS month, %s AS bucket, amount_bani
+			FROM transactions
+			WHERE posted_at LIKE ?
+		)
+		WHERE bucket IS NOT NULL
+		GROUP BY month, bucket
+		ORDER BY month ASC, bucket ASC
+	`, taxBucketExpr), fmt.Sprintf("%04d-%%", year))
+	if err != nil {
+		return nil, fmt.Errorf("tax totals: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TaxBucketTotal
+	for rows.Next() {
+		var t TaxBucketTotal
+		if err := rows.Scan(&t.Month, &t.Bucket, &t.Count, &t.TotalBani); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
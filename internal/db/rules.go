@@ -3,14 +3,19 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type RuleRow struct {
-	ID       int64
-	Name     string
-	Pattern  string
-	Category string
-	Priority int64
+	ID            int64
+	Name          string
+	Pattern       string
+	Category      string
+	Priority      int64
+	Enabled       bool
+	HitCount      int64
+	LastMatchedAt string // "" if never matched
 }
 
 func AddRule(conn *sql.DB, name, pattern, category string, priority int64) (int64, error) {
@@ -28,9 +33,18 @@ func AddRule(conn *sql.DB, name, pattern, category string, priority int64) (int6
 	return id, nil
 }
 
+const ruleColumns = `id, name, pattern, category, priority, enabled, hit_count, COALESCE(last_matched_at, '')`
+
+func scanRule(sc interface{ Scan(...any) error }) (RuleRow, error) {
+	var r RuleRow
+	err := sc.Scan(&r.ID, &r.Name, &r.Pattern, &r.Category, &r.Priority, &r.Enabled, &r.HitCount, &r.LastMatchedAt)
+	return r, err
+}
+
+// ListRules returns all rules, enabled or not, in evaluation order.
 func ListRules(conn *sql.DB) ([]RuleRow, error) {
 	rows, err := conn.Query(`
-		SELECT id, name, pattern, category, priority
+		SELECT ` + ruleColumns + `
 		FROM category_rules
 		ORDER BY priority ASC, id ASC
 	`)
@@ -41,8 +55,8 @@ func ListRules(conn *sql.DB) ([]RuleRow, error) {
 
 	var out []RuleRow
 	for rows.Next() {
-		var r RuleRow
-		if err := rows.Scan(&r.ID, &r.Name, &r.Pattern, &r.Category, &r.Priority); err != nil {
+		r, err := scanRule(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, r)
@@ -53,31 +67,176 @@ func ListRules(conn *sql.DB) ([]RuleRow, error) {
 	return out, nil
 }
 
+func GetRule(conn *sql.DB, id int64) (RuleRow, error) {
+	r, err := scanRule(conn.QueryRow(`SELECT `+ruleColumns+` FROM category_rules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return RuleRow{}, fmt.Errorf("rule #%d not found", id)
+	}
+	if err != nil {
+		return RuleRow{}, fmt.Errorf("get rule: %w", err)
+	}
+	return r, nil
+}
+
+// UpdateRule overwrites name, pattern, category and priority of rule r.ID.
+func UpdateRule(conn *sql.DB, r RuleRow) error {
+	res, err := conn.Exec(`
+		UPDATE category_rules
+		SET name = ?, pattern = ?, category = ?, priority = ?
+		WHERE id = ?
+	`, r.Name, r.Pattern, r.Category, r.Priority, r.ID)
+	if err != nil {
+		return fmt.Errorf("update rule: %w", err)
+	}
+	return requireOneRow(res, "rule", r.ID)
+}
+
+func DeleteRule(conn *sql.DB, id int64) error {
+	res, err := conn.Exec(`DELETE FROM category_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+	return requireOneRow(res, "rule", id)
+}
+
+func SetRuleEnabled(conn *sql.DB, id int64, enabled bool) error {
+	res, err := conn.Exec(`UPDATE category_rules SET enabled = ? WHERE id = ?`, enabled, id)
+	if err != nil {
+		return fmt.Errorf("set rule enabled: %w", err)
+	}
+	return requireOneRow(res, "rule", id)
+}
+
+// ReorderRules renumbers priorities so that ids run in the given order,
+// spaced by 10 to leave room for rules added later.
+func ReorderRules(conn *sql.DB, ids []int64) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("reorder rules: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		if _, err := tx.Exec(`UPDATE category_rules SET priority = ? WHERE id = ?`, (i+1)*10, id); err != nil {
+			return fmt.Errorf("reorder rules: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordRuleHits adds hits[id] to each rule's hit counter and stamps it as
+// matched now.
+func RecordRuleHits(conn *sql.DB, hits map[int64]int) error {
+	for id, n := range hits {
+		_, err := conn.Exec(`
+			UPDATE category_rules
+			SET hit_count = hit_count + ?, last_matched_at = datetime('now')
+			WHERE id = ?
+		`, n, id)
+		if err != nil {
+			return fmt.Errorf("record rule hits: %w", err)
+		}
+	}
+	return nil
+}
+
+// ImportRules inserts rules in one transaction. With replace, existing rules
+// are deleted first; otherwise rules identical in name, pattern and category
+// to an existing one are skipped.
+func ImportRules(conn *sql.DB, rules []RuleRow, replace bool) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("import rules: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM category_rules`); err != nil {
+			return 0, fmt.Errorf("import rules: %w", err)
+		}
+	}
+
+	added := 0
+	for _, r := range rules {
+		res, err := tx.Exec(`
+			INSERT INTO category_rules (name, pattern, category, priority, enabled)
+			SELECT ?, ?, ?, ?, ?
+			WHERE NOT EXISTS (
+				SELECT 1 FROM category_rules WHERE name = ? AND pattern = ? AND category = ?
+			)
+		`, r.Name, r.Pattern, r.Category, r.Priority, r.Enabled, r.Name, r.Pattern, r.Category)
+		if err != nil {
+			return 0, fmt.Errorf("import rule %q: %w", r.Name, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("import rules: %w", err)
+	}
+	return added, nil
+}
+
+func requireOneRow(res sql.Result, what string, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s #%d not found", what, id)
+	}
+	return nil
+}
+
 type TxForCategorize struct {
-	ID       int64
-	Payee    string
-	Memo     string
-	Category string
-	PostedAt string // YYYY-MM-DD
+	ID             int64
+	Payee          string
+	PayeeRaw       string // as the bank sent it
+	Memo           string
+	AmountBani     int64
+	Category       string
+	CategorySource string
+	PostedAt       string // YYYY-MM-DD
+}
+
+type CategorizeFilter struct {
+	Month string // YYYY-MM
+	From  *time.Time
+	To    *time.Time
+	// Recategorize also returns rows already categorized by a rule or an
+	// import. Manually categorized rows and loan payments are never returned.
+	Recategorize bool
 }
 
-func ListTxForCategorize(conn *sql.DB, month string, all bool) ([]TxForCategorize, error) {
-	where := "category = 'uncategorized'"
+func ListTxForCategorize(conn *sql.DB, f CategorizeFilter) ([]TxForCategorize, error) {
+	where := []string{"category = 'uncategorized'"}
 	args := []any{}
 
-	if month != "" {
-		where += " AND posted_at LIKE ?"
-		args = append(args, month+"-%")
+	if f.Recategorize {
+		where[0] = "category_source NOT IN (?, ?)"
+		args = append(args, CategoryManual, CategoryLoan)
 	}
-	if all {
+	if f.Month != "" {
+		where = append(where, "posted_at LIKE ?")
+		args = append(args, f.Month+"-%")
+	}
+	if f.From != nil {
+		where = append(where, "posted_at >= ?")
+		args = append(args, f.From.Format("2006-01-02"))
+	}
+	if f.To != nil {
+		where = append(where, "posted_at <= ?")
+		args = append(args, f.To.Format("2006-01-02"))
 	}
 
 	rows, err := conn.Query(fmt.Sprintf(`
-		SELECT id, payee, memo, category, posted_at
+		SELECT id, payee, payee_raw, memo, amount_bani, category, category_source, posted_at
 		FROM transactions
 		WHERE %s
 		ORDER BY posted_at DESC, id DESC
-	`, where), args...)
+	`, strings.Join(where, " AND ")), args...)
 	if err != nil {
 		return nil, fmt.Errorf("list tx for categorize: %w", err)
 	}
@@ -86,7 +245,35 @@ func ListTxForCategorize(conn *sql.DB, month string, all bool) ([]TxForCategoriz
 	var out []TxForCategorize
 	for rows.Next() {
 		var t TxForCategorize
-		if err := rows.Scan(&t.ID, &t.Payee, &t.Memo, &t.Category, &t.PostedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.Payee, &t.PayeeRaw, &t.Memo, &t.AmountBani, &t.Category, &t.CategorySource, &t.PostedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListCategorizedTx returns every categorized transaction, used as training
+// data for category suggestions.
+func ListCategorizedTx(conn *sql.DB) ([]TxForCategorize, error) {
+	rows, err := conn.Query(`
+		SELECT id, payee, payee_raw, memo, amount_bani, category, category_source, posted_at
+		FROM transactions
+		WHERE category <> 'uncategorized'
+		ORDER BY posted_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list categorized tx: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TxForCategorize
+	for rows.Next() {
+		var t TxForCategorize
+		if err := rows.Scan(&t.ID, &t.Payee, &t.PayeeRaw, &t.Memo, &t.AmountBani, &t.Category, &t.CategorySource, &t.PostedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, t)
@@ -97,8 +284,8 @@ func ListTxForCategorize(conn *sql.DB, month string, all bool) ([]TxForCategoriz
 	return out, nil
 }
 
-func UpdateTxCategory(conn *sql.DB, id int64, newCategory string) error {
-	_, err := conn.Exec(`UPDATE transactions SET category = ? WHERE id = ?`, newCategory, id)
+func UpdateTxCategory(conn *sql.DB, id int64, newCategory, source string) error {
+	_, err := conn.Exec(`UPDATE transactions SET category = ?, category_source = ? WHERE id = ?`, newCategory, source, id)
 	if err != nil {
 		return fmt.Errorf("update category: %w", err)
 	}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type SavedSearch struct {
+	Name      string
+	Query     string
+	CreatedAt string
+}
+
+// SaveSearch stores query under name, replacing an earlier one.
+func SaveSearch(conn *sql.DB, name, query string) error {
+	_, err := conn.Exec(`
+		INSERT INTO saved_searches (name, query) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET query = excluded.query
+	`, name, query)
+	if err != nil {
+		return fmt.Errorf("save search: %w", err)
+	}
+	return nil
+}
+
+func GetSavedSearch(conn *sql.DB, name string) (string, error) {
+	var q string
+	err := conn.QueryRow(`SELECT query FROM saved_searches WHERE name = ?`, name).Scan(&q)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("saved search %q not found", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("get saved search: %w", err)
+	}
+	return q, nil
+}
+
+func ListSavedSearches(conn *sql.DB) ([]SavedSearch, error) {
+	rows, err := conn.Query(`SELECT name, query, created_at FROM saved_searches ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var out []SavedSearch
+	for rows.Next() {
+		var s SavedSearch
+		if err := rows.Scan(&s.Name, &s.Query, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func DeleteSavedSearch(conn *sql.DB, name string) error {
+	res, err := conn.Exec(`DELETE FROM saved_searches WHERE name = ?`, name)
+	if err != nil {
+		return fmt.Errorf("delete saved search: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("saved search %q not found", name)
+	}
+	return nil
+}
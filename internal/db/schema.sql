@@ -4,9 +4,12 @@ CREATE TABLE IF NOT EXISTS transactions (
   id            INTEGER PRIMARY KEY AUTOINCREMENT,
   posted_at     TEXT NOT NULL,
   payee         TEXT NOT NULL,
+  payee_raw     TEXT NOT NULL DEFAULT '',
+  payee_manual  INTEGER NOT NULL DEFAULT 0,
   memo          TEXT NOT NULL DEFAULT '',
   amount_bani   INTEGER NOT NULL,
   category      TEXT NOT NULL DEFAULT 'uncategorized',
+  category_source TEXT NOT NULL DEFAULT 'none',
   account       TEXT NOT NULL DEFAULT 'default',
   source        TEXT NOT NULL DEFAULT 'manual',
   external_id   TEXT,
@@ -19,13 +22,21 @@ WHERE external_id IS NOT NULL;
 
 CREATE INDEX IF NOT EXISTS ix_transactions_posted_at ON transactions(posted_at);
 CREATE INDEX IF NOT EXISTS ix_transactions_category ON transactions(category);
+CREATE INDEX IF NOT EXISTS ix_transactions_amount ON transactions(amount_bani, posted_at);
+CREATE INDEX IF NOT EXISTS ix_transactions_payee ON transactions(payee COLLATE NOCASE, posted_at);
+
+-- The full-text index transactions_fts and its triggers are created by
+-- Migrate after the column migrations; see fts.go.
 
 CREATE TABLE IF NOT EXISTS category_rules (
   id        INTEGER PRIMARY KEY AUTOINCREMENT,
   name      TEXT NOT NULL,
   pattern   TEXT NOT NULL,
   category  TEXT NOT NULL,
-  priority  INTEGER NOT NULL DEFAULT 100
+  priority  INTEGER NOT NULL DEFAULT 100,
+  enabled   INTEGER NOT NULL DEFAULT 1,
+  hit_count INTEGER NOT NULL DEFAULT 0,
+  last_matched_at TEXT
 );
 
 CREATE TABLE IF NOT EXISTS budgets (
@@ -36,3 +47,185 @@ CREATE TABLE IF NOT EXISTS budgets (
   created_at      TEXT NOT NULL DEFAULT (datetime('now')),
   UNIQUE(month, category)
 );
+
+CREATE TABLE IF NOT EXISTS payees (
+  id    INTEGER PRIMARY KEY AUTOINCREMENT,
+  name  TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS payee_aliases (
+  id        INTEGER PRIMARY KEY AUTOINCREMENT,
+  payee_id  INTEGER NOT NULL REFERENCES payees(id) ON DELETE CASCADE,
+  pattern   TEXT NOT NULL,
+  UNIQUE(payee_id, pattern)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+  id    INTEGER PRIMARY KEY AUTOINCREMENT,
+  name  TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS transaction_tags (
+  transaction_id  INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  tag_id          INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (transaction_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_transaction_tags_tag ON transaction_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS budget_templates (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  category     TEXT NOT NULL,
+  start_month  TEXT NOT NULL,
+  limit_bani   INTEGER NOT NULL,
+  rollover     INTEGER NOT NULL DEFAULT 0,
+  created_at   TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(category, start_month)
+);
+
+CREATE TABLE IF NOT EXISTS annual_budgets (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  year         TEXT NOT NULL,
+  category     TEXT NOT NULL,
+  limit_bani   INTEGER NOT NULL,
+  created_at   TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(year, category)
+);
+
+CREATE TABLE IF NOT EXISTS budget_assignments (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  month        TEXT NOT NULL,
+  category     TEXT NOT NULL,
+  amount_bani  INTEGER NOT NULL,
+  note         TEXT NOT NULL DEFAULT '',
+  created_at   TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_budget_assignments_month ON budget_assignments(month, category);
+
+CREATE TABLE IF NOT EXISTS budget_alerts (
+  id        INTEGER PRIMARY KEY AUTOINCREMENT,
+  period    TEXT NOT NULL,
+  category  TEXT NOT NULL,
+  kind      TEXT NOT NULL,
+  message   TEXT NOT NULL DEFAULT '',
+  sent_at   TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(period, category, kind)
+);
+
+CREATE TABLE IF NOT EXISTS goals (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  name         TEXT NOT NULL UNIQUE,
+  target_bani  INTEGER NOT NULL,
+  target_date  TEXT NOT NULL DEFAULT '',
+  account      TEXT NOT NULL DEFAULT '',
+  category     TEXT NOT NULL DEFAULT '',
+  created_at   TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS goal_contributions (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  goal_id         INTEGER NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+  contributed_at  TEXT NOT NULL,
+  amount_bani     INTEGER NOT NULL,
+  note            TEXT NOT NULL DEFAULT '',
+  created_at      TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_goal_contributions_goal ON goal_contributions(goal_id);
+
+CREATE TABLE IF NOT EXISTS loans (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  name            TEXT NOT NULL UNIQUE,
+  principal_bani  INTEGER NOT NULL,
+  rate_bp         INTEGER NOT NULL,
+  term_months     INTEGER NOT NULL,
+  start_date      TEXT NOT NULL,
+  payment_bani    INTEGER NOT NULL,
+  pattern         TEXT NOT NULL DEFAULT '',
+  category        TEXT NOT NULL,
+  created_at      TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS loan_payments (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  loan_id         INTEGER NOT NULL REFERENCES loans(id) ON DELETE CASCADE,
+  transaction_id  INTEGER UNIQUE REFERENCES transactions(id) ON DELETE SET NULL,
+  paid_at         TEXT NOT NULL,
+  amount_bani     INTEGER NOT NULL,
+  principal_bani  INTEGER NOT NULL,
+  interest_bani   INTEGER NOT NULL,
+  balance_bani    INTEGER NOT NULL,
+  created_at      TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_loan_payments_loan ON loan_payments(loan_id, paid_at);
+
+CREATE TABLE IF NOT EXISTS assets (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  name        TEXT NOT NULL UNIQUE,
+  class       TEXT NOT NULL,
+  liability   INTEGER NOT NULL DEFAULT 0,
+  created_at  TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS asset_valuations (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  asset_id    INTEGER NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+  valued_at   TEXT NOT NULL,
+  value_bani  INTEGER NOT NULL,
+  note        TEXT NOT NULL DEFAULT '',
+  created_at  TEXT NOT NULL DEFAULT (datetime('now')),
+  UNIQUE(asset_id, valued_at)
+);
+
+CREATE TABLE IF NOT EXISTS securities (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  symbol      TEXT NOT NULL UNIQUE,
+  name        TEXT NOT NULL DEFAULT '',
+  created_at  TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE TABLE IF NOT EXISTS trades (
+  id              INTEGER PRIMARY KEY AUTOINCREMENT,
+  security_id     INTEGER NOT NULL REFERENCES securities(id) ON DELETE CASCADE,
+  account         TEXT NOT NULL,
+  trade_date      TEXT NOT NULL,
+  kind            TEXT NOT NULL,
+  qty_micro       INTEGER NOT NULL DEFAULT 0,
+  price_bani      INTEGER NOT NULL DEFAULT 0,
+  fee_bani        INTEGER NOT NULL DEFAULT 0,
+  amount_bani     INTEGER NOT NULL,
+  transaction_id  INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+  created_at      TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS ix_trades_security ON trades(security_id, trade_date);
+
+CREATE TABLE IF NOT EXISTS prices (
+  id           INTEGER PRIMARY KEY AUTOINCREMENT,
+  security_id  INTEGER NOT NULL REFERENCES securities(id) ON DELETE CASCADE,
+  priced_at    TEXT NOT NULL,
+  price_bani   INTEGER NOT NULL,
+  UNIQUE(security_id, priced_at)
+);
+
+CREATE TABLE IF NOT EXISTS tax_mappings (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  bucket      TEXT NOT NULL,
+  kind        TEXT NOT NULL,
+  match_type  TEXT NOT NULL,
+  value       TEXT NOT NULL,
+  UNIQUE(match_type, value)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+  key    TEXT PRIMARY KEY,
+  value  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+  name        TEXT PRIMARY KEY,
+  query       TEXT NOT NULL,
+  created_at  TEXT NOT NULL DEFAULT (datetime('now'))
+);
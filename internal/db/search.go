@@ -7,6 +7,20 @@ import (
 	"time"
 )
 
+type TxRow struct {
+	ID         int64
+	PostedAt   time.Time
+	Payee      string
+	PayeeRaw   string
+	Memo       string
+	AmountBani int64
+	Category   string
+	Account    string
+	Source     string
+	Tags       []string
+	Score      float64 // relevance, when sorted by SortRelevance
+}
+
 type SearchFilter struct {
 	Month    string // YYYY-MM
 	From     *time.Time
@@ -16,10 +30,132 @@ type SearchFilter struct {
 	MinBani  *int64
 	MaxBani  *int64
 	Account  string
+	Tag      string
+	Query    *Query // further conditions from the query language
 	Limit    int
+	Offset   int // rows to skip, for page-by-number listings; keyset paging ignores it
+
+	// List order: Sort is one of the Sort* columns (default SortDate), ties
+	// broken by date and id. Descending unless Asc. SortRelevance ranks by
+	// how well the text terms match, best first; without text terms it falls
+	// back to the date.
+	Sort string
+	Asc  bool
+
+	// Keyset paging in list order. After returns the rows that follow the
+	// key, Before the rows just preceding it, and Oldest the last page. Rows
+	// always come back in list order.
+	After  *TxKey
+	Before *TxKey
+	Oldest bool
 }
 
-func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
+// Sort columns of SearchFilter.
+const (
+	SortDate     = "date"
+	SortAmount   = "amount"
+	SortPayee    = "payee"
+	SortCategory = "category"
+	SortAccount  = "account"
+
+	SortRelevance = "relevance"
+)
+
+// SortColumns lists the sort columns in the order the TUI cycles through them.
+var SortColumns = []string{SortDate, SortAmount, SortPayee, SortCategory, SortAccount}
+
+// sortExpr is the leading ORDER BY expression of each sort column other than
+// the date.
+var sortExpr = map[string]string{
+	SortAmount:   "amount_bani",
+	SortPayee:    "payee COLLATE NOCASE",
+	SortCategory: "category COLLATE NOCASE",
+	SortAccount:  "account COLLATE NOCASE",
+
+	SortRelevance: "COALESCE(fts_rank.score, 0)",
+}
+
+// TxKey is a transaction's position in list order, for any sort column.
+type TxKey struct {
+	PostedAt   time.Time
+	ID         int64
+	AmountBani int64
+	Payee      string
+	Category   string
+	Account    string
+	Score      float64
+}
+
+func (r TxRow) Key() TxKey {
+	return TxKey{PostedAt: r.PostedAt, ID: r.ID, AmountBani: r.AmountBani, Payee: r.Payee, Category: r.Category, Account: r.Account, Score: r.Score}
+}
+
+// rankMatch is the FTS5 query relevance is scored by: any of f's text terms.
+func (f SearchFilter) rankMatch() string {
+	var terms []string
+	if m := ftsMatch(f.Text); m != "" {
+		terms = append(terms, m)
+	}
+	if f.Query != nil {
+		terms = append(terms, f.Query.matches()...)
+	}
+	for i, t := range terms {
+		terms[i] = "(" + t + ")"
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// HasText reports whether f searches for text, so that its rows can be
+// sorted by relevance.
+func (f SearchFilter) HasText() bool {
+	return f.rankMatch() != ""
+}
+
+// orderColumns returns the ORDER BY expressions of f's sort and, for key,
+// the matching values.
+func orderColumns(f SearchFilter, key *TxKey) ([]string, []any) {
+	cols := []string{"posted_at", "id"}
+	var vals []any
+	if key != nil {
+		vals = []any{key.PostedAt.Format("2006-01-02"), key.ID}
+	}
+	expr, ok := sortExpr[f.Sort]
+	if !ok {
+		return cols, vals
+	}
+	cols = append([]string{expr}, cols...)
+	if key != nil {
+		var v any
+		switch f.Sort {
+		case SortAmount:
+			v = key.AmountBani
+		case SortPayee:
+			v = key.Payee
+		case SortCategory:
+			v = key.Category
+		case SortAccount:
+			v = key.Account
+		case SortRelevance:
+			v = key.Score
+		}
+		vals = append([]any{v}, vals...)
+	}
+	return cols, vals
+}
+
+// ValidSort reports whether s is a sort column.
+func ValidSort(s string) bool {
+	for _, c := range SortColumns {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+// searchWhere builds the WHERE clause shared by SearchTransactions and
+// CountTransactions; paging keys are left out.
+func searchWhere(f SearchFilter) (string, []any) {
 	where := make([]string, 0, 10)
 	args := make([]any, 0, 10)
 
@@ -43,10 +179,14 @@ func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
 		where = append(where, "account = ?")
 		args = append(args, f.Account)
 	}
+	if f.Tag != "" {
+		where = append(where, tagFilter)
+		args = append(args, f.Tag)
+	}
 	if f.Text != "" {
-		where = append(where, "(LOWER(payee) LIKE ? OR LOWER(memo) LIKE ?)")
-		p := "%" + strings.ToLower(f.Text) + "%"
-		args = append(args, p, p)
+		cond, targs := textCondition(f.Text)
+		where = append(where, cond)
+		args = append(args, targs...)
 	}
 	if f.MinBani != nil {
 		where = append(where, "amount_bani >= ?")
@@ -56,21 +196,85 @@ func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
 		where = append(where, "amount_bani <= ?")
 		args = append(args, *f.MaxBani)
 	}
+	if f.Query != nil {
+		cond, qargs := f.Query.SQL()
+		where = append(where, cond)
+		args = append(args, qargs...)
+	}
+	if len(where) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
+	// Relevance is -bm25 of the text terms (higher is better); rows that
+	// matched only by other terms score 0. The scores are computed once up
+	// front: joined directly, the index would be queried again for every row.
+	with, from, score := "", "transactions", "0"
+	var args []any
+	if f.Sort == SortRelevance {
+		if m := f.rankMatch(); m != "" {
+			with = `WITH fts_rank AS MATERIALIZED (
+				SELECT rowid AS fts_id, -bm25(transactions_fts, ` + ftsRankWeights + `) AS score
+				FROM transactions_fts WHERE transactions_fts MATCH ?
+			)`
+			from = "transactions LEFT JOIN fts_rank ON fts_rank.fts_id = transactions.id"
+			score = sortExpr[SortRelevance]
+			args = append(args, m)
+		} else {
+			f.Sort = SortDate
+		}
+	}
+	where, wargs := searchWhere(f)
+	args = append(args, wargs...)
+
+	// Rows before a key, or the last page, are read in reverse and flipped
+	// afterwards so the LIMIT keeps the ones nearest the key.
+	reverse := f.Before != nil || f.Oldest
+	asc := f.Asc != reverse
+
+	var keyset string
+	key := f.After
+	if key == nil {
+		key = f.Before
+	}
+	cols, vals := orderColumns(f, key)
+	if key != nil {
+		op := "<"
+		if asc {
+			op = ">"
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?, ", len(vals)), ", ")
+		keyset = fmt.Sprintf("(%s) %s (%s)", strings.Join(cols, ", "), op, marks)
+		args = append(args, vals...)
+	}
+	if keyset != "" {
+		if where == "" {
+			where = " WHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
+	}
 
 	limit := f.Limit
 	if limit <= 0 {
 		limit = 200
 	}
 
-	query := `
-		SELECT id, posted_at, payee, memo, amount_bani, category, account, source
-		FROM transactions
-	`
-	if len(where) > 0 {
-		query += " WHERE " + strings.Join(where, " AND ")
+	query := with + `
+		SELECT id, posted_at, payee, payee_raw, memo, amount_bani, category, account, source, ` + tagsColumn + `, ` + score + `
+		FROM ` + from + `
+	` + where
+	dir := " DESC"
+	if asc {
+		dir = " ASC"
 	}
-	query += " ORDER BY posted_at DESC, id DESC"
+	query += " ORDER BY " + strings.Join(cols, dir+", ") + dir
 	query += fmt.Sprintf(" LIMIT %d", limit)
+	if f.Offset > 0 && key == nil && !f.Oldest {
+		query += fmt.Sprintf(" OFFSET %d", f.Offset)
+	}
 
 	rows, err := conn.Query(query, args...)
 	if err != nil {
@@ -84,13 +288,16 @@ func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
 			id         int64
 			postedAtS  string
 			payee      string
+			payeeRaw   string
 			memo       string
 			amountBani int64
 			category   string
 			account    string
 			source     string
+			tagsS      string
+			score      float64
 		)
-		if err := rows.Scan(&id, &postedAtS, &payee, &memo, &amountBani, &category, &account, &source); err != nil {
+		if err := rows.Scan(&id, &postedAtS, &payee, &payeeRaw, &memo, &amountBani, &category, &account, &source, &tagsS, &score); err != nil {
 			return nil, err
 		}
 		postedAt, err := time.Parse("2006-01-02", postedAtS)
@@ -101,15 +308,33 @@ func SearchTransactions(conn *sql.DB, f SearchFilter) ([]TxRow, error) {
 			ID:         id,
 			PostedAt:   postedAt,
 			Payee:      payee,
+			PayeeRaw:   payeeRaw,
 			Memo:       memo,
 			AmountBani: amountBani,
 			Category:   category,
 			Account:    account,
 			Source:     source,
+			Tags:       splitTags(tagsS),
+			Score:      score,
 		})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	if reverse {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
 	return out, nil
 }
+
+// CountTransactions counts the rows SearchTransactions would page through.
+func CountTransactions(conn *sql.DB, f SearchFilter) (int, error) {
+	where, args := searchWhere(f)
+	var n int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM transactions`+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count transactions: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetSetting returns the stored value of key, or def when it was never set.
+func GetSetting(conn *sql.DB, key, def string) (string, error) {
+	var v string
+	err := conn.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&v)
+	if err == sql.ErrNoRows {
+		return def, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("get setting %s: %w", key, err)
+	}
+	return v, nil
+}
+
+func SetSetting(conn *sql.DB, key, value string) error {
+	_, err := conn.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("set setting %s: %w", key, err)
+	}
+	return nil
+}
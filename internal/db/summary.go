@@ -0,0 +1,100 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Group columns of SummarizeTransactions.
+const (
+	GroupCategory = "category"
+	GroupPayee    = "payee"
+	GroupAccount  = "account"
+	GroupMonth    = "month"
+	GroupWeek     = "week" // ISO week, e.g. 2026-W02
+)
+
+var GroupColumns = []string{GroupCategory, GroupPayee, GroupAccount, GroupMonth, GroupWeek}
+
+var groupExpr = map[string]string{
+	"":            "''",
+	GroupCategory: "category",
+	GroupPayee:    "payee",
+	GroupAccount:  "account",
+	GroupMonth:    "substr(posted_at, 1, 7)",
+	GroupWeek:     "strftime('%G-W%V', posted_at)",
+}
+
+// ValidGroup reports whether s is a group column.
+func ValidGroup(s string) bool {
+	_, ok := groupExpr[s]
+	return ok && s != ""
+}
+
+// TxGroup aggregates the transactions sharing a group key.
+type TxGroup struct {
+	Key        string
+	Count      int
+	TotalBani  int64
+	AvgBani    int64
+	MedianBani int64
+}
+
+// SummarizeTransactions aggregates every transaction matching f (its limit,
+// offset, order and paging are ignored) by groupBy, or into a single group
+// when groupBy is "". Months and weeks come back in date order, other groups
+// by total, largest spending first.
+func SummarizeTransactions(conn *sql.DB, f SearchFilter, groupBy string) ([]TxGroup, error) {
+	expr, ok := groupExpr[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", groupBy)
+	}
+	where, args := searchWhere(f)
+	rows, err := conn.Query(`SELECT `+expr+`, amount_bani FROM transactions`+where+` ORDER BY 1, 2`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("summarize transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TxGroup
+	var amounts []int64
+	flush := func() {
+		if len(amounts) == 0 {
+			return
+		}
+		g := &out[len(out)-1]
+		g.AvgBani = int64(math.Round(float64(g.TotalBani) / float64(g.Count)))
+		mid := len(amounts) / 2
+		g.MedianBani = amounts[mid]
+		if len(amounts)%2 == 0 {
+			g.MedianBani = int64(math.Round(float64(amounts[mid-1]+amounts[mid]) / 2))
+		}
+		amounts = amounts[:0]
+	}
+	for rows.Next() {
+		var key sql.NullString
+		var amount int64
+		if err := rows.Scan(&key, &amount); err != nil {
+			return nil, err
+		}
+		if len(out) == 0 || out[len(out)-1].Key != key.String {
+			flush()
+			out = append(out, TxGroup{Key: key.String})
+		}
+		g := &out[len(out)-1]
+		g.Count++
+		g.TotalBani += amount
+		amounts = append(amounts, amount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	if groupBy != GroupMonth && groupBy != GroupWeek {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].TotalBani < out[j].TotalBani })
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeTransactions(t *testing.T) {
+	conn := openTestDB(t, [][5]any{
+		{"2025-12-29", "Lidl", "groceries", "ing", int64(-1000)},
+		{"2026-01-01", "Lidl", "groceries", "ing", int64(-3000)},
+		{"2026-01-05", "Kaufland", "groceries", "bt", int64(-2000)},
+		{"2026-01-20", "Uber", "transport", "ing", int64(-301)},
+		{"2026-02-02", "Uber", "transport", "ing", int64(-401)},
+		{"2026-02-10", "Uber", "transport", "bt", int64(-10000)},
+		{"2026-02-15", "Uber", "transport", "bt", int64(-500)},
+		{"2026-02-25", "Employer", "salary", "ing", int64(500000)},
+	})
+
+	tests := []struct {
+		group string
+		want  []TxGroup
+	}{
+		{"", []TxGroup{
+			{Key: "", Count: 8, TotalBani: 482798, AvgBani: 60350, MedianBani: -750},
+		}},
+		// Odd counts take the middle amount; even counts average the two
+		// middle ones, rounding half away from zero. Spending sorts first.
+		{GroupCategory, []TxGroup{
+			{Key: "transport", Count: 4, TotalBani: -11202, AvgBani: -2801, MedianBani: -451},
+			{Key: "groceries", Count: 3, TotalBani: -6000, AvgBani: -2000, MedianBani: -2000},
+			{Key: "salary", Count: 1, TotalBani: 500000, AvgBani: 500000, MedianBani: 500000},
+		}},
+		{GroupAccount, []TxGroup{
+			{Key: "bt", Count: 3, TotalBani: -12500, AvgBani: -4167, MedianBani: -2000},
+			{Key: "ing", Count: 5, TotalBani: 495298, AvgBani: 99060, MedianBani: -401},
+		}},
+		{GroupMonth, []TxGroup{
+			{Key: "2025-12", Count: 1, TotalBani: -1000, AvgBani: -1000, MedianBani: -1000},
+			{Key: "2026-01", Count: 3, TotalBani: -5301, AvgBani: -1767, MedianBani: -2000},
+			{Key: "2026-02", Count: 4, TotalBani: 489099, AvgBani: 122275, MedianBani: -451},
+		}},
+		// 2025-12-29 is in the first ISO week of 2026.
+		{GroupWeek, []TxGroup{
+			{Key: "2026-W01", Count: 2, TotalBani: -4000, AvgBani: -2000, MedianBani: -2000},
+			{Key: "2026-W02", Count: 1, TotalBani: -2000, AvgBani: -2000, MedianBani: -2000},
+			{Key: "2026-W04", Count: 1, TotalBani: -301, AvgBani: -301, MedianBani: -301},
+			{Key: "2026-W06", Count: 1, TotalBani: -401, AvgBani: -401, MedianBani: -401},
+			{Key: "2026-W07", Count: 2, TotalBani: -10500, AvgBani: -5250, MedianBani: -5250},
+			{Key: "2026-W09", Count: 1, TotalBani: 500000, AvgBani: 500000, MedianBani: 500000},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := SummarizeTransactions(conn, SearchFilter{}, tt.group)
+		if err != nil {
+			t.Errorf("group %q: %v", tt.group, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("group %q:\n got %+v\nwant %+v", tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestSummarizeTransactionsFilter(t *testing.T) {
+	conn := openTestDB(t, [][5]any{
+		{"2026-01-01", "Lidl", "groceries", "ing", int64(-100)},
+		{"2026-01-02", "Lidl", "groceries", "ing", int64(-200)},
+		{"2026-02-01", "Lidl", "groceries", "ing", int64(-900)},
+	})
+
+	got, err := SummarizeTransactions(conn, SearchFilter{Month: "2026-01", Limit: 1, Offset: 1}, GroupPayee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TxGroup{{Key: "Lidl", Count: 2, TotalBani: -300, AvgBani: -150, MedianBani: -150}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got, err := SummarizeTransactions(conn, SearchFilter{Month: "2027-01"}, GroupCategory); err != nil || len(got) != 0 {
+		t.Errorf("no matches: got %+v, %v; want none", got, err)
+	}
+	if _, err := SummarizeTransactions(conn, SearchFilter{}, "memo"); err == nil {
+		t.Error("unknown group: want an error")
+	}
+}
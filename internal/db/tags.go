@@ -0,0 +1,154 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// tagsColumn selects a transaction's tags as a comma-separated list.
+const tagsColumn = `COALESCE((
+	SELECT GROUP_CONCAT(g.name, ',')
+	FROM transaction_tags tt JOIN tags g ON g.id = tt.tag_id
+	WHERE tt.transaction_id = transactions.id
+), '')`
+
+// tagFilter restricts a query on transactions to rows carrying a tag.
+const tagFilter = `id IN (
+	SELECT tt.transaction_id FROM transaction_tags tt JOIN tags g ON g.id = tt.tag_id
+	WHERE casefold(g.name) = casefold(?)
+)`
+
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	out := strings.Split(s, ",")
+	sort.Strings(out)
+	return out
+}
+
+// NormalizeTag lowercases a tag name and rejects names that are empty or
+// contain whitespace or a list separator (',', ';', '|'), since tags are
+// stored and imported as separated lists.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("empty tag name")
+	}
+	for _, r := range tag {
+		if r == ',' || r == ';' || r == '|' || unicode.IsSpace(r) {
+			return "", fmt.Errorf("tag %q: names cannot contain spaces or any of , ; |", tag)
+		}
+	}
+	return tag, nil
+}
+
+type TagRow struct {
+	Name    string
+	TxCount int64
+}
+
+// TagTransactions attaches tag to every id, creating the tag if needed. It
+// returns how many transactions were newly tagged.
+func TagTransactions(conn *sql.DB, tag string, ids []int64) (int, error) {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("tag transactions: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tagID int64
+	err = tx.QueryRow(`SELECT id FROM tags WHERE casefold(name) = ? ORDER BY id LIMIT 1`, tag).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		var res sql.Result
+		if res, err = tx.Exec(`INSERT INTO tags (name) VALUES (?)`, tag); err == nil {
+			tagID, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("add tag: %w", err)
+	}
+
+	n := 0
+	for _, id := range ids {
+		res, err := tx.Exec(`
+			INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_id)
+			SELECT id, ? FROM transactions WHERE id = ?
+		`, tagID, id)
+		if err != nil {
+			return 0, fmt.Errorf("tag transaction #%d: %w", id, err)
+		}
+		if c, _ := res.RowsAffected(); c > 0 {
+			n++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("tag transactions: %w", err)
+	}
+	return n, nil
+}
+
+// UntagTransactions detaches tag from every id and returns how many
+// transactions lost it.
+func UntagTransactions(conn *sql.DB, tag string, ids []int64) (int, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("untag transactions: %w", err)
+	}
+	defer tx.Rollback()
+
+	n := 0
+	for _, id := range ids {
+		res, err := tx.Exec(`
+			DELETE FROM transaction_tags
+			WHERE transaction_id = ? AND tag_id IN (SELECT id FROM tags WHERE casefold(name) = casefold(?))
+		`, id, strings.TrimSpace(tag))
+		if err != nil {
+			return 0, fmt.Errorf("untag transaction #%d: %w", id, err)
+		}
+		if c, _ := res.RowsAffected(); c > 0 {
+			n++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("untag transactions: %w", err)
+	}
+	return n, nil
+}
+
+func ListTags(conn *sql.DB) ([]TagRow, error) {
+	rows, err := conn.Query(`
+		SELECT g.name, COUNT(tt.transaction_id)
+		FROM tags g
+		LEFT JOIN transaction_tags tt ON tt.tag_id = g.id
+		GROUP BY g.id
+		ORDER BY g.name ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TagRow
+	for rows.Next() {
+		var t TagRow
+		if err := rows.Scan(&t.Name, &t.TxCount); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
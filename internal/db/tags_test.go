@@ -0,0 +1,55 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	good := map[string]string{
+		"vacation-2026": "vacation-2026",
+		" Kid ":         "kid",
+		"ȘCOALĂ":        "școală",
+	}
+	for in, want := range good {
+		if got, err := NormalizeTag(in); err != nil || got != want {
+			t.Errorf("NormalizeTag(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "  ", "a,b", "a;b", "a|b", "two words", "tab\there"} {
+		if got, err := NormalizeTag(in); err == nil {
+			t.Errorf("NormalizeTag(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestTagTransactions(t *testing.T) {
+	conn := openTestDB(t, [][5]any{
+		{"2026-01-01", "Lidl", "groceries", "ing", int64(-100)},
+		{"2026-01-02", "Lidl", "groceries", "ing", int64(-200)},
+	})
+
+	if _, err := TagTransactions(conn, "a,b", []int64{1}); err == nil {
+		t.Error("tag with a separator: want an error")
+	}
+	if n, err := TagTransactions(conn, "Vacation", []int64{1}); err != nil || n != 1 {
+		t.Fatalf("tag Vacation = %d, %v; want 1", n, err)
+	}
+	if n, err := TagTransactions(conn, "VACATION", []int64{1, 2}); err != nil || n != 1 {
+		t.Fatalf("tag VACATION = %d, %v; want 1", n, err)
+	}
+	tags, err := ListTags(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []TagRow{{Name: "vacation", TxCount: 2}}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %+v, want %+v", tags, want)
+	}
+	rows, err := SearchTransactions(conn, SearchFilter{Tag: "Vacation"})
+	if err != nil || len(rows) != 2 {
+		t.Errorf("search --tag Vacation = %d rows, %v; want 2", len(rows), err)
+	}
+	if n, err := UntagTransactions(conn, "Vacation", []int64{2}); err != nil || n != 1 {
+		t.Errorf("untag Vacation = %d, %v; want 1", n, err)
+	}
+}
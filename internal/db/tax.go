@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Tax bucket kinds.
+const (
+	TaxIncome    = "income"
+	TaxDeduction = "deduction"
+)
+
+// TaxMapping sends transactions with a category (or a tag) to a tax bucket.
+// Tag mappings take precedence over category mappings.
+type TaxMapping struct {
+	ID        int64
+	Bucket    string
+	Kind      string // TaxIncome or TaxDeduction
+	MatchType string // "category" or "tag"
+	Value     string
+}
+
+// UpsertTaxMapping maps a category or tag to a bucket, replacing an earlier
+// mapping of the same category or tag. A bucket keeps one kind.
+func UpsertTaxMapping(conn *sql.DB, m TaxMapping) (int64, error) {
+	var kind string
+	err := conn.QueryRow(`SELECT kind FROM tax_mappings WHERE bucket = ? AND NOT (match_type = ? AND value = ?) LIMIT 1`,
+		m.Bucket, m.MatchType, m.Value).Scan(&kind)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("tax bucket kind: %w", err)
+	}
+	if err == nil && kind != m.Kind {
+		return 0, fmt.Errorf("bucket %q is already an %s bucket", m.Bucket, kind)
+	}
+
+	_, err = conn.Exec(`
+		INSERT INTO tax_mappings (bucket, kind, match_type, value)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(match_type, value) DO UPDATE SET
+			bucket = excluded.bucket,
+			kind = excluded.kind
+	`, m.Bucket, m.Kind, m.MatchType, m.Value)
+	if err != nil {
+		return 0, fmt.Errorf("upsert tax mapping: %w", err)
+	}
+	var id int64
+	if err := conn.QueryRow(`SELECT id FROM tax_mappings WHERE match_type = ? AND value = ?`, m.MatchType, m.Value).Scan(&id); err != nil {
+		return 0, fmt.Errorf("tax mapping id: %w", err)
+	}
+	return id, nil
+}
+
+func DeleteTaxMapping(conn *sql.DB, id int64) error {
+	res, err := conn.Exec(`DELETE FROM tax_mappings WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete tax mapping: %w", err)
+	}
+	return requireOneRow(res, "tax mapping", id)
+}
+
+func ListTaxMappings(conn *sql.DB) ([]TaxMapping, error) {
+	rows, err := conn.Query(`
+		SELECT id, bucket, kind, match_type, value
+		FROM tax_mappings
+		ORDER BY kind DESC, bucket, match_type, value
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list tax mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []TaxMapping
+	for rows.Next() {
+		var m TaxMapping
+		if err := rows.Scan(&m.ID, &m.Bucket, &m.Kind, &m.MatchType, &m.Value); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
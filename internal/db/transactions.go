@@ -6,34 +6,77 @@ import (
 	"time"
 )
 
+// Category sources record who set a transaction's category. Re-running rules
+// never overwrites CategoryManual or CategoryLoan.
+const (
+	CategoryNone    = "none"
+	CategoryRule    = "rule"
+	CategoryImport  = "import"
+	CategorySuggest = "suggest"
+	CategoryManual  = "manual"
+	CategoryLoan    = "loan"
+)
+
 type AddTxParams struct {
-	PostedAt    time.Time
-	Payee       string
-	Memo        string
-	AmountBani  int64
-	Category    string
-	Account     string
-	Source      string
-	ExternalID  *string
+	PostedAt time.Time
+	Payee    string
+	// PayeeRaw is the payee text as the bank sent it; defaults to Payee.
+	PayeeRaw   string
+	Memo       string
+	AmountBani int64
+	Category   string
+	// CategorySource defaults to CategoryNone for uncategorized rows and
+	// CategoryManual otherwise.
+	CategorySource string
+	Account        string
+	Source         string
+	ExternalID     *string
 }
 
 func InsertTransaction(conn *sql.DB, p AddTxParams) (int64, bool, error) {
+	return insertTransaction(conn, p)
+}
+
+// execer is a *sql.DB or a *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// queryExecer is a *sql.DB or a *sql.Tx, for helpers that also read.
+type queryExecer interface {
+	execer
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func insertTransaction(conn execer, p AddTxParams) (int64, bool, error) {
 	var (
 		res sql.Result
 		err error
 	)
 
+	if p.PayeeRaw == "" {
+		p.PayeeRaw = p.Payee
+	}
+	if p.CategorySource == "" {
+		p.CategorySource = CategoryManual
+		if p.Category == "uncategorized" {
+			p.CategorySource = CategoryNone
+		}
+	}
+
 	if p.ExternalID != nil {
 		res, err = conn.Exec(`
 			INSERT OR IGNORE INTO transactions
-			(posted_at, payee, memo, amount_bani, category, account, source, external_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			(posted_at, payee, payee_raw, memo, amount_bani, category, category_source, account, source, external_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
 			p.PostedAt.Format("2006-01-02"),
 			p.Payee,
+			p.PayeeRaw,
 			p.Memo,
 			p.AmountBani,
 			p.Category,
+			p.CategorySource,
 			p.Account,
 			p.Source,
 			p.ExternalID,
@@ -41,14 +84,16 @@ func InsertTransaction(conn *sql.DB, p AddTxParams) (int64, bool, error) {
 	} else {
 		res, err = conn.Exec(`
 			INSERT INTO transactions
-			(posted_at, payee, memo, amount_bani, category, account, source, external_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			(posted_at, payee, payee_raw, memo, amount_bani, category, category_source, account, source, external_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`,
 			p.PostedAt.Format("2006-01-02"),
 			p.Payee,
+			p.PayeeRaw,
 			p.Memo,
 			p.AmountBani,
 			p.Category,
+			p.CategorySource,
 			p.Account,
 			p.Source,
 			p.ExternalID,
@@ -67,3 +112,155 @@ func InsertTransaction(conn *sql.DB, p AddTxParams) (int64, bool, error) {
 	inserted := id != 0
 	return id, inserted, nil
 }
+
+func GetTransaction(conn *sql.DB, id int64) (TxRow, error) {
+	row := conn.QueryRow(`
+		SELECT id, posted_at, payee, payee_raw, memo, amount_bani, category, account, source, `+tagsColumn+`
+		FROM transactions
+		WHERE id = ?
+	`, id)
+
+	var (
+		r         TxRow
+		postedAtS string
+		tagsS     string
+	)
+	err := row.Scan(&r.ID, &postedAtS, &r.Payee, &r.PayeeRaw, &r.Memo, &r.AmountBani, &r.Category, &r.Account, &r.Source, &tagsS)
+	if err == sql.ErrNoRows {
+		return TxRow{}, fmt.Errorf("transaction #%d not found", id)
+	}
+	if err != nil {
+		return TxRow{}, fmt.Errorf("get transaction: %w", err)
+	}
+	r.PostedAt, err = time.Parse("2006-01-02", postedAtS)
+	if err != nil {
+		return TxRow{}, fmt.Errorf("bad posted_at in db: %q: %w", postedAtS, err)
+	}
+	r.Tags = splitTags(tagsS)
+	return r, nil
+}
+
+// TxEdit holds the fields of a transaction that can be edited in place.
+type TxEdit struct {
+	PostedAt   time.Time
+	Payee      string
+	Memo       string
+	AmountBani int64
+}
+
+// UpdateTransaction rewrites a transaction's date, payee, memo and amount.
+// The bank's payee text is kept; a changed payee is marked as set by hand so
+// `payee apply` leaves it alone. A linked loan payment takes the new date and
+// amount (the caller re-splits the loan); a trade's cash leg keeps its date
+// and amount, which follow from the trade.
+func UpdateTransaction(conn *sql.DB, id int64, e TxEdit) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("update transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		postedAt string
+		amount   int64
+		link     string
+	)
+	err = tx.QueryRow(`
+		SELECT posted_at, amount_bani,
+		       CASE
+		           WHEN EXISTS (SELECT 1 FROM loan_payments WHERE transaction_id = t.id) THEN 'loan payment'
+		           WHEN EXISTS (SELECT 1 FROM trades WHERE transaction_id = t.id) THEN 'trade'
+		           ELSE ''
+		       END
+		FROM transactions t
+		WHERE id = ?
+	`, id).Scan(&postedAt, &amount, &link)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("transaction #%d not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("update transaction: %w", err)
+	}
+	date := e.PostedAt.Format("2006-01-02")
+	if date != postedAt || e.AmountBani != amount {
+		switch link {
+		case "trade":
+			return fmt.Errorf("transaction #%d is the cash leg of a trade: only its payee and memo can be edited", id)
+		case "loan payment":
+			if e.AmountBani >= 0 {
+				return fmt.Errorf("transaction #%d is a loan payment: the amount must be negative", id)
+			}
+			if _, err := tx.Exec(`
+				UPDATE loan_payments SET paid_at = ?, amount_bani = ? WHERE transaction_id = ?
+			`, date, -e.AmountBani, id); err != nil {
+				return fmt.Errorf("update loan payment: %w", err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE transactions
+		SET posted_at = ?, payee = ?, memo = ?, amount_bani = ?,
+		    payee_manual = CASE WHEN payee <> ? THEN 1 ELSE payee_manual END
+		WHERE id = ?
+	`, date, e.Payee, e.Memo, e.AmountBani, e.Payee, id); err != nil {
+		return fmt.Errorf("update transaction: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DeleteTransaction removes a transaction and its tags. Loan payments and
+// trades that pointed at it are kept, unlinked.
+func DeleteTransaction(conn *sql.DB, id int64) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, q := range []string{
+		`DELETE FROM transaction_tags WHERE transaction_id = ?`,
+		`UPDATE loan_payments SET transaction_id = NULL WHERE transaction_id = ?`,
+		`UPDATE trades SET transaction_id = NULL WHERE transaction_id = ?`,
+	} {
+		if _, err := tx.Exec(q, id); err != nil {
+			return fmt.Errorf("delete transaction: %w", err)
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM transactions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete transaction: %w", err)
+	}
+	if err := requireOneRow(res, "transaction", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListCategories returns every category in use by transactions, budgets or
+// rules, sorted.
+func ListCategories(conn *sql.DB) ([]string, error) {
+	rows, err := conn.Query(`
+		SELECT category FROM transactions
+		UNION SELECT category FROM budgets
+		UNION SELECT category FROM category_rules
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
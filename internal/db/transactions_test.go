@@ -0,0 +1,43 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateTransactionLinked(t *testing.T) {
+	conn := openTestDB(t, nil)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	secID, err := AddSecurity(conn, "VWCE", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecordTrade(conn,
+		AddTxParams{PostedAt: day, Payee: "Buy VWCE", AmountBani: -10000, Category: "investments"},
+		TradeRow{SecurityID: secID, TradeDate: "2026-03-02", Kind: TradeBuy, QtyMicro: 1000000, PriceBani: 10000, AmountBani: -10000}); err != nil {
+		t.Fatal(err)
+	}
+	trades, err := ListTrades(conn, secID, "9999-12-31")
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("trades = %+v, %v", trades, err)
+	}
+	txID := *trades[0].TransactionID
+
+	if err := UpdateTransaction(conn, txID, TxEdit{PostedAt: day, Payee: "Broker", Memo: "monthly", AmountBani: -10000}); err != nil {
+		t.Errorf("payee and memo of a trade's cash leg: %v", err)
+	}
+	for _, e := range []TxEdit{
+		{PostedAt: day, Payee: "Broker", AmountBani: -12000},
+		{PostedAt: day.AddDate(0, 0, 1), Payee: "Broker", AmountBani: -10000},
+	} {
+		err := UpdateTransaction(conn, txID, e)
+		if err == nil || !strings.Contains(err.Error(), "cash leg of a trade") {
+			t.Errorf("edit %+v: error %v, want a refusal", e, err)
+		}
+	}
+	if err := UpdateTransaction(conn, 999, TxEdit{PostedAt: day, Payee: "x"}); err == nil {
+		t.Error("missing transaction: want an error")
+	}
+}